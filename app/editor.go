@@ -3,7 +3,10 @@ package app
 import (
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
@@ -11,11 +14,20 @@ import (
 
 	"github.com/aretext/aretext/config"
 	"github.com/aretext/aretext/display"
+	"github.com/aretext/aretext/file"
 	"github.com/aretext/aretext/input"
 	"github.com/aretext/aretext/locate"
 	"github.com/aretext/aretext/state"
+	"github.com/aretext/aretext/undo"
 )
 
+// recoverySnapshotInterval is how often the editor saves a snapshot of unsaved changes for recovery.
+const recoverySnapshotInterval = 5 * time.Second
+
+// statusBarShellCmdCheckInterval is how often the editor checks whether to rerun the status bar shell command.
+// The command runs only if its configured interval has elapsed since the last run.
+const statusBarShellCmdCheckInterval = time.Second
+
 // Editor is a terminal-based text editing program.
 type Editor struct {
 	inputInterpreter  *input.Interpreter
@@ -23,11 +35,14 @@ type Editor struct {
 	screen            tcell.Screen
 	palette           *display.Palette
 	documentLoadCount int
+	themeChangeCount  int
 	termEventChan     chan tcell.Event
+	signalChan        chan os.Signal
 }
 
 // NewEditor instantiates a new editor that uses the provided screen.
-func NewEditor(screen tcell.Screen, path string, lineNum uint64, configRuleSet config.RuleSet) *Editor {
+// If hex is true, the document is loaded as a hex dump.
+func NewEditor(screen tcell.Screen, path string, lineNum uint64, hex bool, configRuleSet config.RuleSet) *Editor {
 	screenWidth, screenHeight := screen.Size()
 	editorState := state.NewEditorState(
 		uint64(screenWidth),
@@ -39,19 +54,45 @@ func NewEditor(screen tcell.Screen, path string, lineNum uint64, configRuleSet c
 	palette := display.NewPalette()
 	documentLoadCount := editorState.DocumentLoadCount()
 	termEventChan := make(chan tcell.Event, 1)
+	signalChan := make(chan os.Signal, 1)
 	editor := &Editor{
 		inputInterpreter,
 		editorState,
 		screen,
 		palette,
 		documentLoadCount,
+		0,
 		termEventChan,
+		signalChan,
+	}
+
+	// Load themes that the user can select from the menu.
+	state.SetThemes(editorState, LoadThemes())
+
+	// Persist undo history across sessions in the user's cache directory.
+	undoHistoryDir, err := undo.DefaultHistoryCacheDir()
+	if err != nil {
+		log.Printf("Undo history will not be saved: %v\n", err)
+	} else {
+		state.SetUndoHistoryCache(editorState, undo.NewHistoryCache(undoHistoryDir))
+	}
+
+	// Save snapshots of unsaved changes, so they can be recovered if the editor exits unexpectedly.
+	recoveryDir, err := file.DefaultRecoveryDir()
+	if err != nil {
+		log.Printf("Unsaved changes will not be saved for recovery: %v\n", err)
+	} else {
+		state.SetRecoveryStore(editorState, file.NewRecoveryStore(recoveryDir))
 	}
 
 	// Attempt to load the file.
 	// If it doesn't exist, this will start with an empty document
 	// that the user can edit and save to the specified path.
-	state.LoadDocument(
+	loadDocument := state.LoadDocument
+	if hex {
+		loadDocument = state.LoadDocumentAsHex
+	}
+	loadDocument(
 		editorState,
 		effectivePath(path),
 		false,
@@ -83,6 +124,7 @@ func effectivePath(path string) string {
 func (e *Editor) RunEventLoop() {
 	e.redraw(true)
 	go e.pollTermEvents()
+	signal.Notify(e.signalChan, syscall.SIGHUP, syscall.SIGTERM)
 	e.runMainEventLoop()
 	e.shutdown()
 }
@@ -95,11 +137,34 @@ func (e *Editor) pollTermEvents() {
 }
 
 func (e *Editor) runMainEventLoop() {
+	recoveryTicker := time.NewTicker(recoverySnapshotInterval)
+	defer recoveryTicker.Stop()
+
+	statusBarShellCmdTicker := time.NewTicker(statusBarShellCmdCheckInterval)
+	defer statusBarShellCmdTicker.Stop()
+	state.RunStatusBarShellCmdIfDue(e.editorState, time.Now())
+
 	for {
 		select {
 		case event := <-e.termEventChan:
 			e.handleTermEvent(event)
 
+		case sig := <-e.signalChan:
+			log.Printf("Received signal %s, saving unsaved changes for recovery and exiting...\n", sig)
+			state.QuitWithRecoverySnapshot(e.editorState)
+
+		case <-recoveryTicker.C:
+			state.WriteRecoverySnapshot(e.editorState)
+
+		case now := <-statusBarShellCmdTicker.C:
+			state.RunStatusBarShellCmdIfDue(e.editorState, now)
+
+		case actionFunc := <-e.editorState.StatusBarShellCmdResultChan():
+			actionFunc(e.editorState)
+
+		case actionFunc := <-e.editorState.BackgroundResultChan():
+			actionFunc(e.editorState)
+
 		case actionFunc := <-e.editorState.TaskResultChan():
 			log.Printf("Task completed, executing resulting action...\n")
 			actionFunc(e.editorState)
@@ -109,6 +174,10 @@ func (e *Editor) runMainEventLoop() {
 		}
 
 		e.handleIfDocumentLoaded()
+		e.handleIfThemeChanged()
+
+		// In large file mode, load more of the file if the user scrolled near the end of the loaded text.
+		state.LoadLargeFilePagesNearView(e.editorState)
 
 		if e.editorState.QuitFlag() {
 			log.Printf("Quit flag set, exiting event loop...\n")
@@ -131,8 +200,8 @@ func (e *Editor) handleTermEvent(event tcell.Event) {
 }
 
 func (e *Editor) handleFileChanged() {
-	log.Printf("File change detected, reloading file...\n")
-	state.AbortIfUnsavedChanges(e.editorState, state.ReloadDocument, false)
+	log.Printf("File change detected, reloading or merging file...\n")
+	state.MergeOrReloadDocument(e.editorState)
 }
 
 func (e *Editor) handleIfDocumentLoaded() {
@@ -152,7 +221,17 @@ func (e *Editor) handleIfDocumentLoaded() {
 	}
 }
 
+func (e *Editor) handleIfThemeChanged() {
+	themeChangeCount := e.editorState.ThemeChangeCount()
+	if themeChangeCount != e.themeChangeCount {
+		log.Printf("Detected theme changed, updating palette")
+		e.palette = display.NewPaletteFromConfigStyles(e.editorState.Styles())
+		e.themeChangeCount = themeChangeCount
+	}
+}
+
 func (e *Editor) shutdown() {
+	signal.Stop(e.signalChan)
 	e.editorState.FileWatcher().Stop()
 }
 
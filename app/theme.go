@@ -0,0 +1,88 @@
+package app
+
+import (
+	"embed"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/adrg/xdg"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+
+	"github.com/aretext/aretext/config"
+)
+
+//go:embed themes/*.yaml
+var builtinThemesFS embed.FS
+
+// ThemesDir returns the path to the directory containing user-defined themes.
+func ThemesDir() string {
+	return filepath.Join(xdg.ConfigHome, "aretext", "themes")
+}
+
+// LoadThemes loads the built-in themes and any themes in the user's themes directory.
+// Each theme is a YAML file mapping style names to styles, and the theme name is the file name
+// without the extension. Themes in the user's directory replace built-in themes with the same name.
+func LoadThemes() []config.Theme {
+	themes, err := loadThemesFromFS(builtinThemesFS, "themes")
+	if err != nil {
+		// Should never happen because the built-in themes are embedded in the binary.
+		log.Printf("Error loading built-in themes: %v\n", err)
+	}
+
+	dir := ThemesDir()
+	userThemes, err := loadThemesFromFS(os.DirFS(dir), ".")
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Printf("Error loading themes from '%s': %v\n", dir, err)
+	}
+
+	return append(themes, userThemes...)
+}
+
+func loadThemesFromFS(fsys fs.FS, dir string) ([]config.Theme, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "fs.ReadDir")
+	}
+
+	var themes []config.Theme
+	for _, entry := range entries {
+		name, ext := entry.Name(), filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+
+		theme, err := loadThemeFile(fsys, path.Join(dir, name), strings.TrimSuffix(name, ext))
+		if err != nil {
+			// Skip the invalid theme, so the user can still select other themes.
+			log.Printf("Error loading theme '%s': %v\n", name, err)
+			continue
+		}
+		themes = append(themes, theme)
+	}
+
+	sort.Slice(themes, func(i, j int) bool {
+		return themes[i].Name < themes[j].Name
+	})
+
+	return themes, nil
+}
+
+func loadThemeFile(fsys fs.FS, filePath string, name string) (config.Theme, error) {
+	data, err := fs.ReadFile(fsys, filePath)
+	if err != nil {
+		return config.Theme{}, errors.Wrap(err, "fs.ReadFile")
+	}
+
+	var m map[string]any
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return config.Theme{}, errors.Wrap(err, "yaml")
+	}
+
+	return config.ThemeFromUntypedMap(name, m), nil
+}
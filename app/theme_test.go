@@ -0,0 +1,61 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/config"
+)
+
+func TestBuiltinThemesValid(t *testing.T) {
+	themes, err := loadThemesFromFS(builtinThemesFS, "themes")
+	require.NoError(t, err)
+
+	var names []string
+	for _, theme := range themes {
+		names = append(names, theme.Name)
+	}
+	assert.Equal(t, []string{"dark", "default", "light", "monochrome"}, names)
+
+	for _, theme := range themes {
+		if theme.Name == config.DefaultTheme {
+			assert.Empty(t, theme.Styles)
+		} else {
+			assert.Contains(t, theme.Styles, config.StyleSelection)
+			assert.Contains(t, theme.Styles, config.StyleTokenKeyword)
+		}
+	}
+}
+
+func TestLoadThemesFromDir(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name, data string) {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644)
+		require.NoError(t, err)
+	}
+	writeFile("solarized.yaml", `tokenKeyword: {color: "#859900", bold: true}`)
+	writeFile("plain.yml", `selection: {reverse: true}`)
+	writeFile("invalid.yaml", `tokenKeyword: [`)
+	writeFile("notes.txt", `not a theme`)
+
+	themes, err := loadThemesFromFS(os.DirFS(dir), ".")
+	require.NoError(t, err)
+	assert.Equal(t, []config.Theme{
+		{
+			Name: "plain",
+			Styles: map[string]config.StyleConfig{
+				config.StyleSelection: {Reverse: true},
+			},
+		},
+		{
+			Name: "solarized",
+			Styles: map[string]config.StyleConfig{
+				config.StyleTokenKeyword: {Color: "#859900", Bold: true},
+			},
+		},
+	}, themes)
+}
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"regexp"
+	"unicode/utf8"
 )
 
 const DefaultSyntaxLanguage = "plaintext"
@@ -11,9 +13,26 @@ const DefaultTabSize = 4
 const DefaultTabExpand = false
 const DefaultShowTabs = false
 const DefaultShowSpaces = false
+const DefaultShowIndentGuides = false
 const DefaultAutoIndent = false
+const DefaultAutoPair = false
 const DefaultShowLineNumbers = false
+const DefaultLineNumberMode = LineNumberModeAbsolute
+const DefaultShowBlame = false
+const DefaultHighlightCursorLine = false
 const DefaultLineWrap = LineWrapCharacter
+const DefaultTrimTrailingWhitespace = false
+const DefaultTrimTrailingBlankLines = false
+const DefaultFinalNewline = FinalNewlinePreserve
+const DefaultFallbackEncoding = FallbackEncodingNone
+const DefaultLargeFileThreshold = 64
+const DefaultUndoHistoryMaxSize = 1024
+const DefaultUndoHistoryMaxAge = 30
+const DefaultStatusBarShellCmdInterval = 5
+const DefaultTheme = "default"
+
+// DefaultAutoPairs are the pairs inserted by auto-pair if the configuration doesn't specify any.
+var DefaultAutoPairs = []string{"()", "[]", "{}", "\"\"", "''"}
 
 // Config is a configuration for the editor.
 type Config struct {
@@ -32,21 +51,92 @@ type Config struct {
 	// If enabled, display space characters in the document.
 	ShowSpaces bool
 
+	// If enabled, display vertical guides at each indentation level.
+	ShowIndentGuides bool
+
 	// If enabled, indent a new line to match indentation of the previous line.
 	AutoIndent bool
 
+	// IndentAfter overrides the line endings that increase indentation of the next line
+	// for the syntax language. This applies only when AutoIndent is enabled.
+	IndentAfter []string
+
+	// DedentOn overrides the strings that decrease indentation when typed at the start
+	// of a line for the syntax language. This applies only when AutoIndent is enabled.
+	DedentOn []string
+
+	// If enabled, typing an opening bracket or quote inserts the matching closing character.
+	AutoPair bool
+
+	// Pairs of opening and closing characters to insert when AutoPair is enabled.
+	// Each pair must be a string with exactly two characters, like "()".
+	// If empty, DefaultAutoPairs is used.
+	AutoPairs []string
+
 	// If enabled, show line numbers in the left margin.
 	ShowLineNumbers bool
 
+	// LineNumberMode controls whether line numbers are absolute or relative to the cursor line.
+	LineNumberMode string
+
+	// If enabled, show the commit that last changed the cursor line in the status bar.
+	ShowBlame bool
+
+	// If enabled, highlight the background of the line with the cursor.
+	HighlightCursorLine bool
+
+	// Rulers are columns to highlight, measured in cells from the start of each line.
+	// The first column is one.
+	Rulers []int
+
 	// LineWrap controls how lines are soft-wrapped.
 	LineWrap string
 
+	// If enabled, remove whitespace at the end of each line when saving the document.
+	TrimTrailingWhitespace bool
+
+	// If enabled, remove blank lines at the end of the document when saving.
+	TrimTrailingBlankLines bool
+
+	// FinalNewline controls whether a line feed is added at the end of the file when saving the document.
+	FinalNewline string
+
+	// FallbackEncoding is the encoding used to load a file that has no byte order mark and is not valid UTF-8.
+	FallbackEncoding string
+
+	// LargeFileThreshold is the file size in megabytes above which files are opened in large file mode.
+	// Large files are loaded incrementally and opened read-only. Zero disables large file mode.
+	LargeFileThreshold int
+
+	// UndoHistoryMaxSize is the maximum size in kilobytes of the undo history saved for a file.
+	// Undo history is saved when the document is saved or closed, and restored when the file is reopened unchanged.
+	// History larger than this is not saved.  Zero disables saving undo history.
+	UndoHistoryMaxSize int
+
+	// UndoHistoryMaxAge is the number of days after which saved undo history is deleted.
+	// Zero means saved undo history never expires.
+	UndoHistoryMaxAge int
+
+	// CommentPrefix overrides the comment prefix for the syntax language.
+	// This is useful for languages that aren't supported by syntax highlighting.
+	CommentPrefix string
+
+	// CommentSuffix overrides the comment suffix for the syntax language.
+	// This is used only if CommentPrefix is set.
+	CommentSuffix string
+
 	// User-defined commands to include in the menu.
 	MenuCommands []MenuCommandConfig
 
 	// Glob patterns for directories to exclude from file search.
 	HideDirectories []string
 
+	// Layout of the status bar.
+	StatusBar StatusBarConfig
+
+	// Name of the theme, which overrides styles from configuration.
+	Theme string
+
 	// Style overrides.
 	Styles map[string]StyleConfig
 }
@@ -56,6 +146,24 @@ const (
 	LineWrapWord      = "word"      // Break lines only between words.
 )
 
+const (
+	LineNumberModeAbsolute = "absolute" // Number lines from the start of the document.
+	LineNumberModeRelative = "relative" // Number lines by their distance from the cursor line.
+	LineNumberModeHybrid   = "hybrid"   // Relative, except the cursor line shows its absolute number.
+)
+
+const (
+	FinalNewlinePreserve = "preserve" // End the file with a line feed only if it had one when loaded.
+	FinalNewlineAlways   = "always"   // Always end the file with a line feed.
+	FinalNewlineNever    = "never"    // Never end the file with a line feed.
+)
+
+const (
+	FallbackEncodingNone        = "none"         // Fail to load files that are not valid UTF-8.
+	FallbackEncodingLatin1      = "latin-1"      // Decode as ISO 8859-1.
+	FallbackEncodingWindows1252 = "windows-1252" // Decode as Windows code page 1252.
+)
+
 const (
 	CmdModeSilent        = "silent"        // accepts no input and any output is discarded.
 	CmdModeTerminal      = "terminal"      // takes control of the terminal.
@@ -78,22 +186,103 @@ type MenuCommandConfig struct {
 	Save bool
 }
 
+// StatusBarConfig is a configuration for the layout of the status bar.
+// The left and right templates contain segment names in braces, like "{path} {lineCol}".
+// If both templates are empty, the status bar shows the file path and format.
+type StatusBarConfig struct {
+	// Left is the template for segments aligned to the left of the status bar.
+	Left string
+
+	// Right is the template for segments aligned to the right of the status bar.
+	Right string
+
+	// ShellCmd is a shell command whose output is displayed by the shellCmd segment.
+	ShellCmd string
+
+	// ShellCmdInterval is the number of seconds between runs of ShellCmd.
+	ShellCmdInterval int
+}
+
+// Names of segments that can be used in a status bar template.
+const (
+	StatusBarSegmentMode       = "mode"       // Input mode, like "NORMAL" or "INSERT".
+	StatusBarSegmentPath       = "path"       // File path relative to the working directory.
+	StatusBarSegmentAbsPath    = "absPath"    // Absolute file path.
+	StatusBarSegmentModified   = "modified"   // "[+]" if the document has unsaved changes.
+	StatusBarSegmentFlags      = "flags"      // Warnings about the file, like "[read-only]" or "[noeol]".
+	StatusBarSegmentLineCol    = "lineCol"    // Line and column of the cursor, starting from one.
+	StatusBarSegmentPercent    = "percent"    // Cursor line as a percentage of the document.
+	StatusBarSegmentLanguage   = "language"   // Syntax language.
+	StatusBarSegmentLineEnding = "lineEnding" // Line ending, either "LF" or "CRLF".
+	StatusBarSegmentEncoding   = "encoding"   // Character encoding.
+	StatusBarSegmentSelection  = "selection"  // Number of characters and lines selected in visual mode.
+	StatusBarSegmentMacro      = "macro"      // "recording" while recording a macro.
+	StatusBarSegmentBlame      = "blame"      // Commit that last changed the cursor line, if showBlame is enabled.
+	StatusBarSegmentShellCmd   = "shellCmd"   // First line of output from the status bar shell command.
+)
+
+// StatusBarSegments are the names of all status bar segments.
+var StatusBarSegments = []string{
+	StatusBarSegmentMode,
+	StatusBarSegmentPath,
+	StatusBarSegmentAbsPath,
+	StatusBarSegmentModified,
+	StatusBarSegmentFlags,
+	StatusBarSegmentLineCol,
+	StatusBarSegmentPercent,
+	StatusBarSegmentLanguage,
+	StatusBarSegmentLineEnding,
+	StatusBarSegmentEncoding,
+	StatusBarSegmentSelection,
+	StatusBarSegmentMacro,
+	StatusBarSegmentBlame,
+	StatusBarSegmentShellCmd,
+}
+
+// StatusBarTemplateSegmentRegexp matches a segment name in a status bar template.
+var StatusBarTemplateSegmentRegexp = regexp.MustCompile(`\{(\w*)\}`)
+
 // Names of styles that can be overridden by configuration.
 const (
-	StyleLineNum       = "lineNum"
-	StyleTokenOperator = "tokenOperator"
-	StyleTokenKeyword  = "tokenKeyword"
-	StyleTokenNumber   = "tokenNumber"
-	StyleTokenString   = "tokenString"
-	StyleTokenComment  = "tokenComment"
-	StyleTokenCustom1  = "tokenCustom1"
-	StyleTokenCustom2  = "tokenCustom2"
-	StyleTokenCustom3  = "tokenCustom3"
-	StyleTokenCustom4  = "tokenCustom4"
-	StyleTokenCustom5  = "tokenCustom5"
-	StyleTokenCustom6  = "tokenCustom6"
-	StyleTokenCustom7  = "tokenCustom7"
-	StyleTokenCustom8  = "tokenCustom8"
+	StyleLineNum              = "lineNum"
+	StyleSelection            = "selection"
+	StyleSearchMatch          = "searchMatch"
+	StyleStatusMsgSuccess     = "statusMsgSuccess"
+	StyleStatusMsgError       = "statusMsgError"
+	StyleStatusInputMode      = "statusInputMode"
+	StyleStatusInputBuffer    = "statusInputBuffer"
+	StyleStatusRecordingMacro = "statusRecordingMacro"
+	StyleStatusFilePath       = "statusFilePath"
+	StyleMenuBorder           = "menuBorder"
+	StylePaneBorder           = "paneBorder"
+	StyleMenuIcon             = "menuIcon"
+	StyleMenuPrompt           = "menuPrompt"
+	StyleMenuQuery            = "menuQuery"
+	StyleMenuCursor           = "menuCursor"
+	StyleMenuItemSelected     = "menuItemSelected"
+	StyleMenuItemUnselected   = "menuItemUnselected"
+	StyleSearchPrefix         = "searchPrefix"
+	StyleSearchQuery          = "searchQuery"
+	StyleTokenOperator        = "tokenOperator"
+	StyleTokenKeyword         = "tokenKeyword"
+	StyleTokenNumber          = "tokenNumber"
+	StyleTokenString          = "tokenString"
+	StyleTokenComment         = "tokenComment"
+	StyleTokenCustom1         = "tokenCustom1"
+	StyleTokenCustom2         = "tokenCustom2"
+	StyleTokenCustom3         = "tokenCustom3"
+	StyleTokenCustom4         = "tokenCustom4"
+	StyleTokenCustom5         = "tokenCustom5"
+	StyleTokenCustom6         = "tokenCustom6"
+	StyleTokenCustom7         = "tokenCustom7"
+	StyleTokenCustom8         = "tokenCustom8"
+	StyleDiffAdded            = "diffAdded"
+	StyleDiffRemoved          = "diffRemoved"
+	StyleDiffModified         = "diffModified"
+	StyleCursorLine           = "cursorLine"
+	StyleRuler                = "ruler"
+	StyleIndentGuide          = "indentGuide"
+	StyleFold                 = "fold"
 )
 
 // StyleConfig is a configuration for how text should be displayed.
@@ -121,23 +310,49 @@ type StyleConfig struct {
 
 	// Strikethrough sets the strikethrough attribute.
 	StrikeThrough bool
+
+	// Reverse swaps the foreground and background colors.
+	Reverse bool
+
+	// Dim sets the dim attribute.
+	Dim bool
 }
 
 // ConfigFromUntypedMap constructs a configuration from an untyped map.
 // The map is usually loaded from a JSON document.
 func ConfigFromUntypedMap(m map[string]any) Config {
 	return Config{
-		SyntaxLanguage:  stringOrDefault(m, "syntaxLanguage", DefaultSyntaxLanguage),
-		TabSize:         intOrDefault(m, "tabSize", DefaultTabSize),
-		TabExpand:       boolOrDefault(m, "tabExpand", DefaultTabExpand),
-		ShowTabs:        boolOrDefault(m, "showTabs", DefaultShowTabs),
-		ShowSpaces:      boolOrDefault(m, "showSpaces", DefaultShowSpaces),
-		AutoIndent:      boolOrDefault(m, "autoIndent", DefaultAutoIndent),
-		ShowLineNumbers: boolOrDefault(m, "showLineNumbers", DefaultShowLineNumbers),
-		LineWrap:        stringOrDefault(m, "lineWrap", DefaultLineWrap),
-		MenuCommands:    menuCommandsFromSlice(sliceOrNil(m, "menuCommands")),
-		HideDirectories: stringSliceOrNil(m, "hideDirectories"),
-		Styles:          stylesFromMap(mapOrNil(m, "styles")),
+		SyntaxLanguage:         stringOrDefault(m, "syntaxLanguage", DefaultSyntaxLanguage),
+		TabSize:                intOrDefault(m, "tabSize", DefaultTabSize),
+		TabExpand:              boolOrDefault(m, "tabExpand", DefaultTabExpand),
+		ShowTabs:               boolOrDefault(m, "showTabs", DefaultShowTabs),
+		ShowSpaces:             boolOrDefault(m, "showSpaces", DefaultShowSpaces),
+		ShowIndentGuides:       boolOrDefault(m, "showIndentGuides", DefaultShowIndentGuides),
+		AutoIndent:             boolOrDefault(m, "autoIndent", DefaultAutoIndent),
+		IndentAfter:            stringSliceOrNil(m, "indentAfter"),
+		DedentOn:               stringSliceOrNil(m, "dedentOn"),
+		AutoPair:               boolOrDefault(m, "autoPair", DefaultAutoPair),
+		AutoPairs:              stringSliceOrNil(m, "autoPairs"),
+		ShowLineNumbers:        boolOrDefault(m, "showLineNumbers", DefaultShowLineNumbers),
+		LineNumberMode:         stringOrDefault(m, "lineNumberMode", DefaultLineNumberMode),
+		ShowBlame:              boolOrDefault(m, "showBlame", DefaultShowBlame),
+		HighlightCursorLine:    boolOrDefault(m, "highlightCursorLine", DefaultHighlightCursorLine),
+		Rulers:                 intSliceOrNil(m, "rulers"),
+		LineWrap:               stringOrDefault(m, "lineWrap", DefaultLineWrap),
+		TrimTrailingWhitespace: boolOrDefault(m, "trimTrailingWhitespace", DefaultTrimTrailingWhitespace),
+		TrimTrailingBlankLines: boolOrDefault(m, "trimTrailingBlankLines", DefaultTrimTrailingBlankLines),
+		FinalNewline:           stringOrDefault(m, "finalNewline", DefaultFinalNewline),
+		FallbackEncoding:       stringOrDefault(m, "fallbackEncoding", DefaultFallbackEncoding),
+		LargeFileThreshold:     intOrDefault(m, "largeFileThreshold", DefaultLargeFileThreshold),
+		UndoHistoryMaxSize:     intOrDefault(m, "undoHistoryMaxSize", DefaultUndoHistoryMaxSize),
+		UndoHistoryMaxAge:      intOrDefault(m, "undoHistoryMaxAge", DefaultUndoHistoryMaxAge),
+		CommentPrefix:          stringOrDefault(m, "commentPrefix", ""),
+		CommentSuffix:          stringOrDefault(m, "commentSuffix", ""),
+		MenuCommands:           menuCommandsFromSlice(sliceOrNil(m, "menuCommands")),
+		HideDirectories:        stringSliceOrNil(m, "hideDirectories"),
+		StatusBar:              statusBarFromMap(mapOrNil(m, "statusBar")),
+		Theme:                  stringOrDefault(m, "theme", DefaultTheme),
+		Styles:                 stylesFromMap(mapOrNil(m, "styles")),
 	}
 }
 
@@ -151,6 +366,42 @@ func (c Config) Validate() error {
 		return fmt.Errorf("LineWrap must be either %q or %q", LineWrapCharacter, LineWrapWord)
 	}
 
+	if c.LineNumberMode != LineNumberModeAbsolute && c.LineNumberMode != LineNumberModeRelative && c.LineNumberMode != LineNumberModeHybrid {
+		return fmt.Errorf("LineNumberMode must be either %q, %q, or %q", LineNumberModeAbsolute, LineNumberModeRelative, LineNumberModeHybrid)
+	}
+
+	for _, ruler := range c.Rulers {
+		if ruler < 1 {
+			return errors.New("Rulers must be greater than zero")
+		}
+	}
+
+	if c.FinalNewline != FinalNewlinePreserve && c.FinalNewline != FinalNewlineAlways && c.FinalNewline != FinalNewlineNever {
+		return fmt.Errorf("FinalNewline must be either %q, %q, or %q", FinalNewlinePreserve, FinalNewlineAlways, FinalNewlineNever)
+	}
+
+	if c.FallbackEncoding != FallbackEncodingNone && c.FallbackEncoding != FallbackEncodingLatin1 && c.FallbackEncoding != FallbackEncodingWindows1252 {
+		return fmt.Errorf("FallbackEncoding must be either %q, %q, or %q", FallbackEncodingNone, FallbackEncodingLatin1, FallbackEncodingWindows1252)
+	}
+
+	if c.LargeFileThreshold < 0 {
+		return errors.New("LargeFileThreshold must be greater than or equal to zero")
+	}
+
+	if c.UndoHistoryMaxSize < 0 {
+		return errors.New("UndoHistoryMaxSize must be greater than or equal to zero")
+	}
+
+	if c.UndoHistoryMaxAge < 0 {
+		return errors.New("UndoHistoryMaxAge must be greater than or equal to zero")
+	}
+
+	for _, pair := range c.AutoPairs {
+		if utf8.RuneCountInString(pair) != 2 {
+			return fmt.Errorf("AutoPairs entry %q must have exactly two characters", pair)
+		}
+	}
+
 	for _, cmd := range c.MenuCommands {
 		if cmd.Mode != CmdModeSilent && cmd.Mode != CmdModeTerminal && cmd.Mode != CmdModeInsert && cmd.Mode != CmdModeFileLocations {
 			return fmt.Errorf(
@@ -164,9 +415,37 @@ func (c Config) Validate() error {
 		}
 	}
 
+	for _, template := range []string{c.StatusBar.Left, c.StatusBar.Right} {
+		if err := validateStatusBarTemplate(template); err != nil {
+			return err
+		}
+	}
+
+	if c.StatusBar.ShellCmdInterval < 1 {
+		return errors.New("StatusBar shellCmdInterval must be greater than zero")
+	}
+
 	return nil
 }
 
+func validateStatusBarTemplate(template string) error {
+	for _, match := range StatusBarTemplateSegmentRegexp.FindAllStringSubmatch(template, -1) {
+		if !isStatusBarSegment(match[1]) {
+			return fmt.Errorf("StatusBar template %q has unrecognized segment %q", template, match[1])
+		}
+	}
+	return nil
+}
+
+func isStatusBarSegment(name string) bool {
+	for _, segment := range StatusBarSegments {
+		if name == segment {
+			return true
+		}
+	}
+	return false
+}
+
 func stringOrDefault(m map[string]any, key string, defaultVal string) string {
 	v, ok := m[key]
 	if !ok {
@@ -247,6 +526,26 @@ func stringSliceOrNil(m map[string]any, key string) []string {
 	return stringSlice
 }
 
+func intSliceOrNil(m map[string]any, key string) []int {
+	slice := sliceOrNil(m, key)
+	if slice == nil {
+		return nil
+	}
+
+	intSlice := make([]int, 0, len(slice))
+	for i := 0; i < len(slice); i++ {
+		switch v := (slice[i]).(type) {
+		case int:
+			intSlice = append(intSlice, v)
+		case float64:
+			intSlice = append(intSlice, int(v))
+		default:
+			log.Printf("Could not decode int in slice for config key %q\n", key)
+		}
+	}
+	return intSlice
+}
+
 func mapOrNil(m map[string]any, key string) map[string]any {
 	v, ok := m[key]
 	if !ok {
@@ -281,6 +580,15 @@ func menuCommandsFromSlice(s []any) []MenuCommandConfig {
 	return result
 }
 
+func statusBarFromMap(m map[string]any) StatusBarConfig {
+	return StatusBarConfig{
+		Left:             stringOrDefault(m, "left", ""),
+		Right:            stringOrDefault(m, "right", ""),
+		ShellCmd:         stringOrDefault(m, "shellCmd", ""),
+		ShellCmdInterval: intOrDefault(m, "shellCmdInterval", DefaultStatusBarShellCmdInterval),
+	}
+}
+
 func stylesFromMap(m map[string]any) map[string]StyleConfig {
 	result := make(map[string]StyleConfig, len(m))
 	for k, v := range m {
@@ -297,6 +605,8 @@ func stylesFromMap(m map[string]any) map[string]StyleConfig {
 			Italic:          boolOrDefault(styleMap, "italic", false),
 			Underline:       boolOrDefault(styleMap, "underline", false),
 			StrikeThrough:   boolOrDefault(styleMap, "strikethrough", false),
+			Reverse:         boolOrDefault(styleMap, "reverse", false),
+			Dim:             boolOrDefault(styleMap, "dim", false),
 		}
 	}
 	return result
@@ -16,11 +16,74 @@ func TestConfigFromUntypedMap(t *testing.T) {
 			name:  "empty map",
 			input: map[string]any{},
 			expected: Config{
-				SyntaxLanguage: "plaintext",
-				TabSize:        4,
-				LineWrap:       "character",
-				MenuCommands:   []MenuCommandConfig{},
-				Styles:         map[string]StyleConfig{},
+				SyntaxLanguage:     "plaintext",
+				TabSize:            4,
+				LineNumberMode:     "absolute",
+				LineWrap:           "character",
+				FinalNewline:       "preserve",
+				FallbackEncoding:   "none",
+				LargeFileThreshold: 64,
+				UndoHistoryMaxSize: 1024,
+				UndoHistoryMaxAge:  30,
+				MenuCommands:       []MenuCommandConfig{},
+				StatusBar:          StatusBarConfig{ShellCmdInterval: 5},
+				Theme:              "default",
+				Styles:             map[string]StyleConfig{},
+			},
+		},
+		{
+			name: "rulers",
+			input: map[string]any{
+				"highlightCursorLine": true,
+				"rulers":              []any{72, 100.0, "invalid"},
+			},
+			expected: Config{
+				SyntaxLanguage:      "plaintext",
+				TabSize:             4,
+				LineNumberMode:      "absolute",
+				HighlightCursorLine: true,
+				Rulers:              []int{72, 100},
+				LineWrap:            "character",
+				FinalNewline:        "preserve",
+				FallbackEncoding:    "none",
+				LargeFileThreshold:  64,
+				UndoHistoryMaxSize:  1024,
+				UndoHistoryMaxAge:   30,
+				MenuCommands:        []MenuCommandConfig{},
+				StatusBar:           StatusBarConfig{ShellCmdInterval: 5},
+				Theme:               "default",
+				Styles:              map[string]StyleConfig{},
+			},
+		},
+		{
+			name: "status bar",
+			input: map[string]any{
+				"statusBar": map[string]any{
+					"left":             "{mode} {path}{modified}",
+					"right":            "{lineCol} {percent}",
+					"shellCmd":         "git branch --show-current",
+					"shellCmdInterval": 10.0,
+				},
+			},
+			expected: Config{
+				SyntaxLanguage:     "plaintext",
+				TabSize:            4,
+				LineNumberMode:     "absolute",
+				LineWrap:           "character",
+				FinalNewline:       "preserve",
+				FallbackEncoding:   "none",
+				LargeFileThreshold: 64,
+				UndoHistoryMaxSize: 1024,
+				UndoHistoryMaxAge:  30,
+				MenuCommands:       []MenuCommandConfig{},
+				StatusBar: StatusBarConfig{
+					Left:             "{mode} {path}{modified}",
+					Right:            "{lineCol} {percent}",
+					ShellCmd:         "git branch --show-current",
+					ShellCmdInterval: 10,
+				},
+				Theme:  "default",
+				Styles: map[string]StyleConfig{},
 			},
 		},
 		{
@@ -47,13 +110,25 @@ func TestConfigFromUntypedMap(t *testing.T) {
 					"tokenCustom4": map[string]any{
 						"backgroundColor": "black",
 					},
+					"selection": map[string]any{
+						"reverse": true,
+						"dim":     true,
+					},
 				},
 			},
 			expected: Config{
-				SyntaxLanguage: "customLang",
-				TabSize:        4,
-				LineWrap:       "character",
-				MenuCommands:   []MenuCommandConfig{},
+				SyntaxLanguage:     "customLang",
+				TabSize:            4,
+				LineNumberMode:     "absolute",
+				LineWrap:           "character",
+				FinalNewline:       "preserve",
+				FallbackEncoding:   "none",
+				LargeFileThreshold: 64,
+				UndoHistoryMaxSize: 1024,
+				UndoHistoryMaxAge:  30,
+				MenuCommands:       []MenuCommandConfig{},
+				StatusBar:          StatusBarConfig{ShellCmdInterval: 5},
+				Theme:              "default",
 				Styles: map[string]StyleConfig{
 					"lineNum": {
 						Color: "olive",
@@ -74,6 +149,10 @@ func TestConfigFromUntypedMap(t *testing.T) {
 					"tokenCustom4": {
 						BackgroundColor: "black",
 					},
+					"selection": {
+						Reverse: true,
+						Dim:     true,
+					},
 				},
 			},
 		},
@@ -112,6 +191,84 @@ func TestValidateConfig(t *testing.T) {
 			},
 			expectErrMsg: `LineWrap must be either "character" or "word"`,
 		},
+		{
+			name: "lineNumberMode is invalid",
+			updateFunc: func(c *Config) {
+				c.LineNumberMode = "invalid"
+			},
+			expectErrMsg: `LineNumberMode must be either "absolute", "relative", or "hybrid"`,
+		},
+		{
+			name: "rulers zero is invalid",
+			updateFunc: func(c *Config) {
+				c.Rulers = []int{80, 0}
+			},
+			expectErrMsg: "Rulers must be greater than zero",
+		},
+		{
+			name: "finalNewline is invalid",
+			updateFunc: func(c *Config) {
+				c.FinalNewline = "invalid"
+			},
+			expectErrMsg: `FinalNewline must be either "preserve", "always", or "never"`,
+		},
+		{
+			name: "fallbackEncoding is invalid",
+			updateFunc: func(c *Config) {
+				c.FallbackEncoding = "ebcdic"
+			},
+			expectErrMsg: `FallbackEncoding must be either "none", "latin-1", or "windows-1252"`,
+		},
+		{
+			name: "largeFileThreshold negative is invalid",
+			updateFunc: func(c *Config) {
+				c.LargeFileThreshold = -1
+			},
+			expectErrMsg: "LargeFileThreshold must be greater than or equal to zero",
+		},
+		{
+			name: "undoHistoryMaxSize negative is invalid",
+			updateFunc: func(c *Config) {
+				c.UndoHistoryMaxSize = -1
+			},
+			expectErrMsg: "UndoHistoryMaxSize must be greater than or equal to zero",
+		},
+		{
+			name: "undoHistoryMaxAge negative is invalid",
+			updateFunc: func(c *Config) {
+				c.UndoHistoryMaxAge = -1
+			},
+			expectErrMsg: "UndoHistoryMaxAge must be greater than or equal to zero",
+		},
+		{
+			name: "autoPairs entry is invalid",
+			updateFunc: func(c *Config) {
+				c.AutoPairs = []string{"()", "<<>>"}
+			},
+			expectErrMsg: `AutoPairs entry "<<>>" must have exactly two characters`,
+		},
+		{
+			name: "status bar template is valid",
+			updateFunc: func(c *Config) {
+				c.StatusBar.Left = "{mode} | {path}{modified}"
+				c.StatusBar.Right = "{shellCmd} {lineCol}"
+			},
+			expectErrMsg: "",
+		},
+		{
+			name: "status bar template segment is invalid",
+			updateFunc: func(c *Config) {
+				c.StatusBar.Right = "{lineCol} {column}"
+			},
+			expectErrMsg: `StatusBar template "{lineCol} {column}" has unrecognized segment "column"`,
+		},
+		{
+			name: "status bar shellCmdInterval zero is invalid",
+			updateFunc: func(c *Config) {
+				c.StatusBar.ShellCmdInterval = 0
+			},
+			expectErrMsg: "StatusBar shellCmdInterval must be greater than zero",
+		},
 		{
 			name: "menu mode is invalid",
 			updateFunc: func(c *Config) {
@@ -20,10 +20,12 @@ type Rule struct {
 type RuleSet []Rule
 
 // Rules that match the file path are applied in order to produce the configuration.
+// For compressed files like "data.json.gz", rules also match the path without the compression extension.
 func (rs RuleSet) ConfigForPath(path string) Config {
 	c := make(map[string]any, 0)
+	uncompressedPath := file.UncompressedPath(path)
 	for _, rule := range rs {
-		if file.GlobMatch(rule.Pattern, path) {
+		if file.GlobMatch(rule.Pattern, path) || file.GlobMatch(rule.Pattern, uncompressedPath) {
 			log.Printf("Applying config rule '%s' with pattern '%s' for path '%s'\n", rule.Name, rule.Pattern, path)
 			c = MergeRecursive(c, rule.Config).(map[string]any)
 		}
@@ -18,13 +18,21 @@ func TestConfigForPath(t *testing.T) {
 			ruleSet: nil,
 			path:    "test.go",
 			expectedConfig: Config{
-				SyntaxLanguage: DefaultSyntaxLanguage,
-				TabSize:        DefaultTabSize,
-				TabExpand:      DefaultTabExpand,
-				AutoIndent:     DefaultAutoIndent,
-				LineWrap:       DefaultLineWrap,
-				MenuCommands:   []MenuCommandConfig{},
-				Styles:         map[string]StyleConfig{},
+				SyntaxLanguage:     DefaultSyntaxLanguage,
+				TabSize:            DefaultTabSize,
+				TabExpand:          DefaultTabExpand,
+				AutoIndent:         DefaultAutoIndent,
+				LineNumberMode:     DefaultLineNumberMode,
+				LineWrap:           DefaultLineWrap,
+				FinalNewline:       DefaultFinalNewline,
+				FallbackEncoding:   DefaultFallbackEncoding,
+				LargeFileThreshold: DefaultLargeFileThreshold,
+				UndoHistoryMaxSize: DefaultUndoHistoryMaxSize,
+				UndoHistoryMaxAge:  DefaultUndoHistoryMaxAge,
+				MenuCommands:       []MenuCommandConfig{},
+				StatusBar:          StatusBarConfig{ShellCmdInterval: 5},
+				Theme:              "default",
+				Styles:             map[string]StyleConfig{},
 			},
 		},
 		{
@@ -47,13 +55,58 @@ func TestConfigForPath(t *testing.T) {
 			},
 			path: "test.json",
 			expectedConfig: Config{
-				SyntaxLanguage: "json",
-				TabSize:        DefaultTabSize,
-				TabExpand:      DefaultTabExpand,
-				LineWrap:       DefaultLineWrap,
-				AutoIndent:     DefaultAutoIndent,
-				MenuCommands:   []MenuCommandConfig{},
-				Styles:         map[string]StyleConfig{},
+				SyntaxLanguage:     "json",
+				TabSize:            DefaultTabSize,
+				TabExpand:          DefaultTabExpand,
+				LineNumberMode:     DefaultLineNumberMode,
+				LineWrap:           DefaultLineWrap,
+				FinalNewline:       DefaultFinalNewline,
+				FallbackEncoding:   DefaultFallbackEncoding,
+				LargeFileThreshold: DefaultLargeFileThreshold,
+				UndoHistoryMaxSize: DefaultUndoHistoryMaxSize,
+				UndoHistoryMaxAge:  DefaultUndoHistoryMaxAge,
+				AutoIndent:         DefaultAutoIndent,
+				MenuCommands:       []MenuCommandConfig{},
+				StatusBar:          StatusBarConfig{ShellCmdInterval: 5},
+				Theme:              "default",
+				Styles:             map[string]StyleConfig{},
+			},
+		},
+		{
+			name: "rule matches path without compression extension",
+			ruleSet: []Rule{
+				{
+					Name:    "json",
+					Pattern: "**/*.json",
+					Config: map[string]any{
+						"syntaxLanguage": "json",
+					},
+				},
+				{
+					Name:    "gzip",
+					Pattern: "**/*.gz",
+					Config: map[string]any{
+						"tabSize": 2,
+					},
+				},
+			},
+			path: "test.json.gz",
+			expectedConfig: Config{
+				SyntaxLanguage:     "json",
+				TabSize:            2,
+				TabExpand:          DefaultTabExpand,
+				LineNumberMode:     DefaultLineNumberMode,
+				LineWrap:           DefaultLineWrap,
+				FinalNewline:       DefaultFinalNewline,
+				FallbackEncoding:   DefaultFallbackEncoding,
+				LargeFileThreshold: DefaultLargeFileThreshold,
+				UndoHistoryMaxSize: DefaultUndoHistoryMaxSize,
+				UndoHistoryMaxAge:  DefaultUndoHistoryMaxAge,
+				AutoIndent:         DefaultAutoIndent,
+				MenuCommands:       []MenuCommandConfig{},
+				StatusBar:          StatusBarConfig{ShellCmdInterval: 5},
+				Theme:              "default",
+				Styles:             map[string]StyleConfig{},
 			},
 		},
 	}
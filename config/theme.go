@@ -0,0 +1,17 @@
+package config
+
+// Theme is a named set of styles.
+// The styles in a theme replace styles with the same name from configuration.
+type Theme struct {
+	Name   string
+	Styles map[string]StyleConfig
+}
+
+// ThemeFromUntypedMap constructs a theme from an untyped map of style names to style objects.
+// The map is usually loaded from a YAML document in the themes directory.
+func ThemeFromUntypedMap(name string, m map[string]any) Theme {
+	return Theme{
+		Name:   name,
+		Styles: stylesFromMap(m),
+	}
+}
@@ -1,6 +1,7 @@
 package display
 
 import (
+	"fmt"
 	"io"
 	"log"
 	"strconv"
@@ -24,15 +25,21 @@ func DrawBuffer(screen tcell.Screen, palette *Palette, buffer *state.BufferState
 	pos := viewTextOrigin
 	showTabs := buffer.ShowTabs()
 	showSpaces := buffer.ShowSpaces()
-	lineNumMargin := buffer.LineNumMarginWidth() // Zero if line numbers disabled.
+	lineNumMargin := buffer.LineNumMarginWidth()     // Zero if line numbers disabled.
+	gitChangeMargin := buffer.GitChangeMarginWidth() // Zero if the file isn't tracked by git.
 	wrapConfig := buffer.LineWrapConfig()
 	wrappedLineIter := segment.NewWrappedLineIter(wrapConfig, textTree, pos)
 	wrappedLine := segment.Empty()
 	searchMatch := buffer.SearchMatch()
+	folds := buffer.FoldedRanges()
+	cursorLineNum := textTree.LineNumForPosition(cursorPos)
+	var cursorLineRows []int
+	var lineStartRows []indentGuideRow
 
 	sr.HideCursor()
 
-	for row := 0; row < height; row++ {
+	var row int
+	for row = 0; row < height; row++ {
 		err := wrappedLineIter.NextSegment(wrappedLine)
 		if err == io.EOF {
 			break
@@ -41,8 +48,25 @@ func DrawBuffer(screen tcell.Screen, palette *Palette, buffer *state.BufferState
 		}
 		lineNum := textTree.LineNumForPosition(pos)
 		lineStartPos := textTree.LineStartPosition(lineNum)
+		if lineNum == cursorLineNum {
+			cursorLineRows = append(cursorLineRows, row)
+		}
+		if pos == lineStartPos {
+			lineStartRows = append(lineStartRows, indentGuideRow{row, lineNum})
+		}
 		wrappedLineRunes := wrappedLine.Runes()
 		syntaxTokens := buffer.SyntaxTokensIntersectingRange(pos, pos+uint64(len(wrappedLineRunes)))
+
+		// If a closed fold starts after this line, summarize the hidden lines.
+		nextPos := pos + wrappedLine.NumRunes()
+		for len(folds) > 0 && folds[0].StartPos < nextPos {
+			folds = folds[1:]
+		}
+		var numFoldedLines uint64
+		if len(folds) > 0 && folds[0].StartPos == nextPos && wrappedLine.HasNewline() {
+			numFoldedLines = folds[0].NumLines
+		}
+
 		drawLineAndSetCursor(
 			sr,
 			palette,
@@ -51,6 +75,9 @@ func DrawBuffer(screen tcell.Screen, palette *Palette, buffer *state.BufferState
 			int(wrapConfig.MaxLineWidth),
 			lineNum,
 			lineNumMargin,
+			gitChangeMargin,
+			buffer.LineNumForDisplay,
+			buffer.GitChangeForLine,
 			lineStartPos,
 			wrappedLineRunes,
 			syntaxTokens,
@@ -60,17 +87,99 @@ func DrawBuffer(screen tcell.Screen, palette *Palette, buffer *state.BufferState
 			wrapConfig.WidthFunc,
 			showTabs,
 			showSpaces,
+			numFoldedLines,
 		)
-		pos += wrappedLine.NumRunes()
+		pos = nextPos
+
+		if numFoldedLines > 0 {
+			// Skip the hidden lines.
+			pos = folds[0].EndPos
+			wrappedLineIter = segment.NewWrappedLineIter(wrapConfig, textTree, pos)
+
+			// The hidden lines end with a newline, so there is an empty last line after the fold.
+			lastLineNum := textTree.NumLines() - 1
+			if pos == textTree.NumChars() && textTree.LineStartPosition(lastLineNum) == pos && row+1 < height {
+				drawLineNumIfNecessary(sr, palette, row+1, buffer.LineNumForDisplay(lastLineNum), lineNumMargin)
+				drawGitChangeIfNecessary(sr, palette, row+1, buffer.GitChangeForLine(lastLineNum), lineNumMargin, gitChangeMargin)
+				if pos == cursorPos {
+					sr.ShowCursor(int(lineNumMargin+gitChangeMargin), row+1)
+				}
+			}
+		}
 	}
 
 	// Text view is empty, with cursor positioned in the first cell.
 	if pos-viewTextOrigin == 0 && pos == cursorPos {
-		sr.ShowCursor(int(lineNumMargin), 0)
-		drawLineNumIfNecessary(sr, palette, 0, 0, lineNumMargin)
+		sr.ShowCursor(int(lineNumMargin+gitChangeMargin), 0)
+		drawLineNumIfNecessary(sr, palette, 0, buffer.LineNumForDisplay(0), lineNumMargin)
+		drawGitChangeIfNecessary(sr, palette, 0, buffer.GitChangeForLine(0), lineNumMargin, gitChangeMargin)
+	}
+
+	// The cursor is on an empty last line, which has no wrapped line segment.
+	if cursorPos == textTree.NumChars() && textTree.LineStartPosition(cursorLineNum) == cursorPos && row < height {
+		if len(cursorLineRows) == 0 || cursorLineRows[len(cursorLineRows)-1] != row {
+			cursorLineRows = append(cursorLineRows, row)
+		}
+	}
+
+	textStartCol := int(lineNumMargin + gitChangeMargin)
+	if buffer.ShowIndentGuides() {
+		drawIndentGuides(sr, palette, textTree, buffer.TabSize(), lineStartRows, textStartCol, int(wrapConfig.MaxLineWidth))
+	}
+	drawRulers(sr, palette, buffer.Rulers(), textStartCol)
+	if buffer.HighlightCursorLine() {
+		drawCursorLine(sr, palette, cursorLineRows, textStartCol)
+	}
+}
+
+// drawRulers highlights the background of columns in every row of the text area.
+func drawRulers(sr *ScreenRegion, palette *Palette, rulers []uint64, textStartCol int) {
+	width, height := sr.Size()
+	_, bg, _ := palette.StyleForRuler().Decompose()
+	for _, ruler := range rulers {
+		col := textStartCol + int(ruler) - 1
+		if col >= width {
+			continue
+		}
+		for row := 0; row < height; row++ {
+			setBackgroundIfDefault(sr, col, row, bg)
+		}
 	}
 }
 
+// drawCursorLine highlights the background of the rows that display the cursor line.
+func drawCursorLine(sr *ScreenRegion, palette *Palette, rows []int, textStartCol int) {
+	width, _ := sr.Size()
+	_, bg, _ := palette.StyleForCursorLine().Decompose()
+	for _, row := range rows {
+		for col := textStartCol; col < width; {
+			cellWidth := setBackgroundIfDefault(sr, col, row, bg)
+			col += cellWidth
+		}
+	}
+}
+
+// setBackgroundIfDefault sets the background color of a cell, unless the cell already has
+// a background from another style (such as a selection or search match).
+// It returns the number of cells occupied by the cell's content, so wide characters can be skipped.
+func setBackgroundIfDefault(sr *ScreenRegion, col, row int, bg tcell.Color) int {
+	mainc, combc, style, width := sr.GetContent(col, row)
+	if width < 1 {
+		width = 1
+	}
+
+	_, cellBg, attrs := style.Decompose()
+	if cellBg != tcell.ColorDefault || attrs&tcell.AttrReverse != 0 {
+		return width
+	}
+
+	if mainc == 0 {
+		mainc = ' '
+	}
+	sr.SetContent(col, row, mainc, combc, style.Background(bg))
+	return width
+}
+
 func viewDimensions(buffer *state.BufferState) (int, int, int, int) {
 	x, y := buffer.ViewOrigin()
 	width, height := buffer.ViewSize()
@@ -85,6 +194,9 @@ func drawLineAndSetCursor(
 	maxLineWidth int,
 	lineNum uint64,
 	lineNumMargin uint64,
+	gitChangeMargin uint64,
+	lineNumForDisplay func(uint64) uint64,
+	gitChangeForLine func(uint64) state.GitChange,
 	lineStartPos uint64,
 	wrappedLineRunes []rune,
 	syntaxTokens []parser.Token,
@@ -94,6 +206,7 @@ func drawLineAndSetCursor(
 	gcWidthFunc segment.GraphemeClusterWidthFunc,
 	showTabs bool,
 	showSpaces bool,
+	numFoldedLines uint64,
 ) {
 	startPos := pos
 	gcRunes := []rune{'\x00', '\x00', '\x00', '\x00'}[:0] // Stack-allocate runes for the last grapheme cluster.
@@ -103,9 +216,10 @@ func drawLineAndSetCursor(
 	var lastGcWasNewline bool
 
 	if startPos == lineStartPos {
-		drawLineNumIfNecessary(sr, palette, row, lineNum, lineNumMargin)
+		drawLineNumIfNecessary(sr, palette, row, lineNumForDisplay(lineNum), lineNumMargin)
+		drawGitChangeIfNecessary(sr, palette, row, gitChangeForLine(lineNum), lineNumMargin, gitChangeMargin)
 	}
-	col += int(lineNumMargin)
+	col += int(lineNumMargin + gitChangeMargin)
 
 	var i int
 	for i < len(wrappedLineRunes) || len(gcRunes) > 0 {
@@ -160,15 +274,18 @@ func drawLineAndSetCursor(
 		gcRunes = gcRunes[:0]
 	}
 
-	if lastGcWasNewline {
+	if lastGcWasNewline && numFoldedLines > 0 {
+		drawFoldSummary(sr, palette, col, row, numFoldedLines)
+	} else if lastGcWasNewline {
 		// Draw line number for an empty final line.
-		drawLineNumIfNecessary(sr, palette, row+1, lineNum+1, lineNumMargin)
+		drawLineNumIfNecessary(sr, palette, row+1, lineNumForDisplay(lineNum+1), lineNumMargin)
+		drawGitChangeIfNecessary(sr, palette, row+1, gitChangeForLine(lineNum+1), lineNumMargin, gitChangeMargin)
 	}
 
 	if pos == cursorPos {
 		if lastGcWasNewline || (pos-startPos) == uint64(maxLineWidth) {
 			// If the line ended on a newline or soft-wrapped line, show the cursor at the start of the next line.
-			sr.ShowCursor(int(lineNumMargin), row+1)
+			sr.ShowCursor(int(lineNumMargin+gitChangeMargin), row+1)
 		} else if pos == cursorPos {
 			// Otherwise, show the cursor at the end of the current line.
 			sr.ShowCursor(col, row)
@@ -176,13 +293,28 @@ func drawLineAndSetCursor(
 	}
 }
 
-func drawLineNumIfNecessary(sr *ScreenRegion, palette *Palette, row int, lineNum uint64, lineNumMargin uint64) {
+// drawFoldSummary draws the number of lines hidden by a closed fold after the end of the line before the fold.
+func drawFoldSummary(sr *ScreenRegion, palette *Palette, col int, row int, numFoldedLines uint64) {
+	summary := fmt.Sprintf(" ... %d lines", numFoldedLines)
+	if numFoldedLines == 1 {
+		summary = " ... 1 line"
+	}
+
+	style := palette.StyleForFold()
+	for _, r := range summary {
+		sr.SetContent(col, row, r, nil, style)
+		col++
+	}
+}
+
+// drawLineNumIfNecessary draws the displayed line number, which is absolute or relative to the cursor line.
+func drawLineNumIfNecessary(sr *ScreenRegion, palette *Palette, row int, displayLineNum uint64, lineNumMargin uint64) {
 	if lineNumMargin == 0 {
 		return
 	}
 
 	style := palette.StyleForLineNum()
-	lineNumStr := strconv.FormatUint(lineNum+1, 10)
+	lineNumStr := strconv.FormatUint(displayLineNum, 10)
 
 	// Right-aligned in the margin, with one space of padding on the right.
 	col := int(lineNumMargin) - 1 - len(lineNumStr)
@@ -191,3 +323,23 @@ func drawLineNumIfNecessary(sr *ScreenRegion, palette *Palette, row int, lineNum
 		col++
 	}
 }
+
+func drawGitChangeIfNecessary(sr *ScreenRegion, palette *Palette, row int, change state.GitChange, lineNumMargin uint64, gitChangeMargin uint64) {
+	if gitChangeMargin == 0 {
+		return
+	}
+
+	// Drawn in the column between the line numbers and the document text.
+	var r rune
+	switch change {
+	case state.GitChangeAdded:
+		r = '+'
+	case state.GitChangeModified:
+		r = '~'
+	case state.GitChangeRemoved:
+		r = '_'
+	default:
+		return
+	}
+	sr.SetContent(int(lineNumMargin), row, r, nil, palette.StyleForGitChange(change))
+}
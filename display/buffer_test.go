@@ -1,11 +1,15 @@
 package display
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/aretext/aretext/config"
 	"github.com/aretext/aretext/selection"
 	"github.com/aretext/aretext/state"
 	"github.com/aretext/aretext/syntax"
@@ -505,6 +509,96 @@ func TestSyntaxHighlighting(t *testing.T) {
 	})
 }
 
+func TestCursorLineAndRulers(t *testing.T) {
+	def := tcell.StyleDefault
+	gray := def.Background(tcell.ColorGray)
+	maroon := def.Background(tcell.ColorMaroon)
+	lineNum := def.Foreground(tcell.ColorOlive)
+
+	testCases := []struct {
+		name          string
+		config        map[string]any
+		text          string
+		cursorPos     uint64
+		expectedStyle [][]tcell.Style
+	}{
+		{
+			name:   "cursor line, soft-wrapped",
+			config: map[string]any{"highlightCursorLine": true},
+			text:   "abcdefg\nh",
+			expectedStyle: [][]tcell.Style{
+				{gray, gray, gray, gray, gray},
+				{gray, gray, gray, gray, gray},
+				{def, def, def, def, def},
+				{def, def, def, def, def},
+			},
+		},
+		{
+			name:      "cursor line, empty last line",
+			config:    map[string]any{"highlightCursorLine": true},
+			text:      "abc\n\n", // The file ends with a line feed, so the document is "abc\n".
+			cursorPos: 4,
+			expectedStyle: [][]tcell.Style{
+				{def, def, def, def, def},
+				{gray, gray, gray, gray, gray},
+				{def, def, def, def, def},
+				{def, def, def, def, def},
+			},
+		},
+		{
+			name:   "rulers",
+			config: map[string]any{"rulers": []any{2, 4, 100}},
+			text:   "abc\nd",
+			expectedStyle: [][]tcell.Style{
+				{def, maroon, def, maroon, def},
+				{def, maroon, def, maroon, def},
+				{def, maroon, def, maroon, def},
+				{def, maroon, def, maroon, def},
+			},
+		},
+		{
+			name:   "rulers and cursor line",
+			config: map[string]any{"rulers": []any{2}, "highlightCursorLine": true},
+			text:   "abc\nd",
+			expectedStyle: [][]tcell.Style{
+				{gray, maroon, gray, gray, gray},
+				{def, maroon, def, def, def},
+				{def, maroon, def, def, def},
+				{def, maroon, def, def, def},
+			},
+		},
+		{
+			name:   "rulers after line numbers",
+			config: map[string]any{"rulers": []any{1}, "showLineNumbers": true},
+			text:   "abc",
+			expectedStyle: [][]tcell.Style{
+				{def, lineNum, def, maroon, def},
+				{def, def, def, maroon, def},
+				{def, def, def, maroon, def},
+				{def, def, def, maroon, def},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "test.txt")
+			require.NoError(t, os.WriteFile(path, []byte(tc.text), 0644))
+			configRuleSet := config.RuleSet{{Name: "test", Pattern: "**", Config: tc.config}}
+
+			withSimScreen(t, func(s tcell.SimulationScreen) {
+				s.SetSize(5, 4)
+				editorState := state.NewEditorState(5, 5, configRuleSet, nil)
+				defer state.Quit(editorState)
+				state.LoadDocument(editorState, path, true, func(state.LocatorParams) uint64 { return tc.cursorPos })
+				DrawBuffer(s, NewPalette(), editorState.DocumentBuffer())
+				s.Sync()
+				assertCellStyles(t, s, tc.expectedStyle)
+			})
+		})
+	}
+}
+
 func TestSearchMatch(t *testing.T) {
 	withSimScreen(t, func(s tcell.SimulationScreen) {
 		s.SetSize(12, 1)
@@ -773,6 +867,239 @@ func TestShowLineNumbers(t *testing.T) {
 	}
 }
 
+func TestShowRelativeLineNumbers(t *testing.T) {
+	testCases := []struct {
+		name             string
+		width, height    int
+		inputString      string
+		cursorPos        uint64
+		expectedContents [][]rune
+	}{
+		{
+			name:        "cursor on last line",
+			width:       5,
+			height:      4,
+			inputString: "ab\nc\nde",
+			cursorPos:   5,
+			expectedContents: [][]rune{
+				{' ', '2', ' ', 'a', 'b'},
+				{' ', '1', ' ', 'c', ' '},
+				{' ', '3', ' ', 'd', 'e'},
+				{' ', ' ', ' ', ' ', ' '},
+			},
+		},
+		{
+			name:        "cursor on middle line",
+			width:       5,
+			height:      4,
+			inputString: "ab\nc\nde",
+			cursorPos:   3,
+			expectedContents: [][]rune{
+				{' ', '1', ' ', 'a', 'b'},
+				{' ', '2', ' ', 'c', ' '},
+				{' ', '1', ' ', 'd', 'e'},
+				{' ', ' ', ' ', ' ', ' '},
+			},
+		},
+		{
+			name:        "soft-wrapped lines numbered only on first row",
+			width:       5,
+			height:      4,
+			inputString: "abcde\nf",
+			cursorPos:   6,
+			expectedContents: [][]rune{
+				{' ', '1', ' ', 'a', 'b'},
+				{' ', ' ', ' ', 'c', 'd'},
+				{' ', ' ', ' ', 'e', ' '},
+				{' ', '2', ' ', 'f', ' '},
+			},
+		},
+		{
+			name:        "last line empty",
+			width:       5,
+			height:      4,
+			inputString: "ab\nc\n",
+			cursorPos:   0,
+			expectedContents: [][]rune{
+				{' ', '1', ' ', 'a', 'b'},
+				{' ', '1', ' ', 'c', ' '},
+				{' ', '2', ' ', ' ', ' '},
+				{' ', ' ', ' ', ' ', ' '},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			withSimScreen(t, func(s tcell.SimulationScreen) {
+				s.SetSize(tc.width, tc.height)
+				drawBuffer(t, s, func(editorState *state.EditorState) {
+					for _, r := range tc.inputString {
+						state.InsertRune(editorState, r)
+					}
+					state.MoveCursor(editorState, func(state.LocatorParams) uint64 { return tc.cursorPos })
+					state.ToggleRelativeLineNumbers(editorState)
+				})
+				assertCellContents(t, s, tc.expectedContents)
+			})
+		})
+	}
+}
+
+func TestShowIndentGuides(t *testing.T) {
+	testCases := []struct {
+		name             string
+		inputString      string
+		showTabs         bool
+		showSpaces       bool
+		expectedContents [][]rune
+	}{
+		{
+			name:        "spaces",
+			inputString: "a\n    b\n        c",
+			expectedContents: [][]rune{
+				{'a', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+				{'│', ' ', ' ', ' ', 'b', ' ', ' ', ' ', ' ', ' '},
+				{'│', ' ', ' ', ' ', '│', ' ', ' ', ' ', 'c', ' '},
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+			},
+		},
+		{
+			name:        "tabs",
+			inputString: "\tb\n\t\tc",
+			expectedContents: [][]rune{
+				{'│', ' ', ' ', ' ', 'b', ' ', ' ', ' ', ' ', ' '},
+				{'│', ' ', ' ', ' ', '│', ' ', ' ', ' ', 'c', ' '},
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+			},
+		},
+		{
+			name:        "partial indentation",
+			inputString: "  a\n      b",
+			expectedContents: [][]rune{
+				{'│', ' ', 'a', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+				{'│', ' ', ' ', ' ', '│', ' ', 'b', ' ', ' ', ' '},
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+			},
+		},
+		{
+			name:        "blank lines inside indented block",
+			inputString: "a\n        b\n\n    c\n\nd",
+			expectedContents: [][]rune{
+				{'a', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+				{'│', ' ', ' ', ' ', '│', ' ', ' ', ' ', 'b', ' '},
+				{'│', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+				{'│', ' ', ' ', ' ', 'c', ' ', ' ', ' ', ' ', ' '},
+			},
+		},
+		{
+			name:        "show tabs",
+			inputString: "\t\tb",
+			showTabs:    true,
+			expectedContents: [][]rune{
+				{'│', ' ', ' ', ' ', '│', ' ', ' ', ' ', 'b', ' '},
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+			},
+		},
+		{
+			name:        "show spaces",
+			inputString: "      b",
+			showSpaces:  true,
+			expectedContents: [][]rune{
+				{'│', '·', '·', '·', '│', '·', 'b', ' ', ' ', ' '},
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			withSimScreen(t, func(s tcell.SimulationScreen) {
+				s.SetSize(10, 4)
+				drawBuffer(t, s, func(editorState *state.EditorState) {
+					for _, r := range tc.inputString {
+						state.InsertRune(editorState, r)
+					}
+					state.ToggleShowIndentGuides(editorState)
+					if tc.showTabs {
+						state.ToggleShowTabs(editorState)
+					}
+					if tc.showSpaces {
+						state.ToggleShowSpaces(editorState)
+					}
+				})
+				assertCellContents(t, s, tc.expectedContents)
+			})
+		})
+	}
+}
+
+func TestDrawFolds(t *testing.T) {
+	testCases := []struct {
+		name              string
+		inputString       string
+		cursorPos         uint64
+		expectedContents  [][]rune
+		expectedCursorCol int
+		expectedCursorRow int
+	}{
+		{
+			name:        "fold summary",
+			inputString: "a\n b\n c\nd",
+			cursorPos:   8,
+			expectedContents: [][]rune{
+				{' ', '1', ' ', 'a', ' ', '.', '.', '.', ' ', '2', ' ', 'l', 'i', 'n', 'e', 's'},
+				{' ', '4', ' ', 'd', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+			},
+			expectedCursorCol: 3,
+			expectedCursorRow: 1,
+		},
+		{
+			name:        "empty last line after fold",
+			inputString: "a\n b\n",
+			cursorPos:   5,
+			expectedContents: [][]rune{
+				{' ', '1', ' ', 'a', ' ', '.', '.', '.', ' ', '1', ' ', 'l', 'i', 'n', 'e', ' '},
+				{' ', '3', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+			},
+			expectedCursorCol: 3,
+			expectedCursorRow: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			withSimScreen(t, func(s tcell.SimulationScreen) {
+				s.SetSize(16, 4)
+				drawBuffer(t, s, func(editorState *state.EditorState) {
+					for _, r := range tc.inputString {
+						state.InsertRune(editorState, r)
+					}
+					state.ToggleShowLineNumbers(editorState)
+					state.MoveCursor(editorState, func(state.LocatorParams) uint64 { return 0 })
+					state.FoldBlockAtCursor(editorState)
+					state.MoveCursor(editorState, func(state.LocatorParams) uint64 { return tc.cursorPos })
+				})
+				assertCellContents(t, s, tc.expectedContents)
+				cursorCol, cursorRow, cursorVisible := s.GetCursor()
+				assert.True(t, cursorVisible)
+				assert.Equal(t, tc.expectedCursorCol, cursorCol)
+				assert.Equal(t, tc.expectedCursorRow, cursorRow)
+			})
+		})
+	}
+}
+
 func TestShowTabs(t *testing.T) {
 	testCases := []struct {
 		name             string
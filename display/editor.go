@@ -9,7 +9,13 @@ import (
 // DrawEditor draws the editor in the screen.
 func DrawEditor(screen tcell.Screen, palette *Palette, editorState *state.EditorState, inputBufferString string) {
 	screen.Fill(' ', tcell.StyleDefault)
+	inactivePanes := editorState.InactivePanes()
+	for _, buffer := range inactivePanes {
+		DrawBuffer(screen, palette, buffer)
+	}
+	// Draw the active pane last, so it controls the cursor position.
 	DrawBuffer(screen, palette, editorState.DocumentBuffer())
+	DrawPaneBorders(screen, palette, append(inactivePanes, editorState.DocumentBuffer()))
 	DrawMenu(screen, palette, editorState.Menu())
 	DrawStatusBar(
 		screen,
@@ -19,6 +25,12 @@ func DrawEditor(screen tcell.Screen, palette *Palette, editorState *state.Editor
 		inputBufferString,
 		editorState.IsRecordingUserMacro(),
 		editorState.FileWatcher().Path(),
+		editorState.DocumentBuffer().FileFormat(),
+		editorState.DocumentBuffer().ReadOnly(),
+		editorState.DocumentBuffer().LargeFileLoadedPercent(),
+		editorState.DocumentBuffer().BlameForCursorLine(),
+		editorState.StatusBarConfig(),
+		editorState.StatusBarInfo(),
 	)
 	searchQuery, searchDirection := editorState.DocumentBuffer().SearchQueryAndDirection()
 	DrawSearchQuery(
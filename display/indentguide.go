@@ -0,0 +1,105 @@
+package display
+
+import (
+	"io"
+
+	"github.com/gdamore/tcell/v2"
+
+	"github.com/aretext/aretext/text"
+)
+
+// indentGuideMaxSearchLines limits how far to search for a non-blank line
+// when drawing guides through blank lines, so a long run of blank lines doesn't slow down drawing.
+const indentGuideMaxSearchLines = 1000
+
+// indentGuideRow is a row displaying the start of a line.
+type indentGuideRow struct {
+	row     int
+	lineNum uint64
+}
+
+// drawIndentGuides draws a vertical guide at each indentation level in the leading whitespace of each line.
+// Blank lines use the indentation of the surrounding lines, so guides continue through blank lines in an indented block.
+// Guides are drawn only in cells displaying whitespace, replacing any tab or space markers.
+func drawIndentGuides(sr *ScreenRegion, palette *Palette, textTree *text.Tree, tabSize uint64, rows []indentGuideRow, textStartCol int, maxLineWidth int) {
+	if tabSize == 0 {
+		return
+	}
+
+	style := palette.StyleForIndentGuide()
+	for _, r := range rows {
+		indent := indentGuideWidth(textTree, r.lineNum, tabSize)
+		for col := uint64(0); col < indent && col < uint64(maxLineWidth); col += tabSize {
+			x := textStartCol + int(col)
+			mainc, _, cellStyle, _ := sr.GetContent(x, r.row)
+			if mainc != 0 && mainc != ' ' && mainc != tcell.RuneRArrow && mainc != tcell.RuneBullet {
+				continue
+			}
+
+			_, bg, attrs := cellStyle.Decompose()
+			if bg != tcell.ColorDefault || attrs&tcell.AttrReverse != 0 {
+				// Don't hide the selection or search match.
+				continue
+			}
+
+			sr.SetContent(x, r.row, tcell.RuneVLine, nil, style)
+		}
+	}
+}
+
+// indentGuideWidth returns the number of cells of indentation covered by guides for a line.
+func indentGuideWidth(textTree *text.Tree, lineNum uint64, tabSize uint64) uint64 {
+	indent, blank := lineIndentWidth(textTree, lineNum, tabSize)
+	if !blank {
+		return indent
+	}
+
+	// Use the smaller indentation of the closest non-blank lines before and after.
+	var prevIndent, nextIndent uint64
+	for n, i := lineNum, 0; n > 0 && i < indentGuideMaxSearchLines; n, i = n-1, i+1 {
+		if indent, blank := lineIndentWidth(textTree, n-1, tabSize); !blank {
+			prevIndent = indent
+			break
+		}
+	}
+	numLines := textTree.NumLines()
+	for n, i := lineNum+1, 0; n < numLines && i < indentGuideMaxSearchLines; n, i = n+1, i+1 {
+		if indent, blank := lineIndentWidth(textTree, n, tabSize); !blank {
+			nextIndent = indent
+			break
+		}
+	}
+
+	if prevIndent < nextIndent {
+		return prevIndent
+	}
+	return nextIndent
+}
+
+// lineIndentWidth returns the number of cells occupied by the leading spaces and tabs of a line,
+// and whether the line contains only whitespace.
+func lineIndentWidth(textTree *text.Tree, lineNum uint64, tabSize uint64) (uint64, bool) {
+	reader := textTree.ReaderAtPosition(textTree.LineStartPosition(lineNum))
+	var width uint64
+	for {
+		r, _, err := reader.ReadRune()
+		if err == io.EOF {
+			return width, true
+		} else if err != nil {
+			return width, false
+		}
+
+		switch r {
+		case ' ':
+			width++
+		case '\t':
+			width += tabSize - (width % tabSize)
+		case '\r':
+			continue
+		case '\n':
+			return width, true
+		default:
+			return width, false
+		}
+	}
+}
@@ -91,6 +91,14 @@ func menuIconForStyle(style state.MenuStyle) string {
 		return "./"
 	case state.MenuStyleFileLocation:
 		return "@"
+	case state.MenuStyleUndoHistory:
+		return "~"
+	case state.MenuStyleRecovery:
+		return "!"
+	case state.MenuStyleBuffer:
+		return "#"
+	case state.MenuStyleTheme:
+		return "*"
 	default:
 		panic("Unrecognized menu style")
 	}
@@ -104,6 +112,14 @@ func menuPromptForStyle(style state.MenuStyle) string {
 		return "file path"
 	case state.MenuStyleFileLocation:
 		return ""
+	case state.MenuStyleUndoHistory:
+		return "undo history"
+	case state.MenuStyleRecovery:
+		return "unsaved changes"
+	case state.MenuStyleBuffer:
+		return "buffer"
+	case state.MenuStyleTheme:
+		return "theme"
 	default:
 		panic("Unrecognized menu style")
 	}
@@ -22,6 +22,7 @@ type Palette struct {
 	statusRecordingMacroStyle tcell.Style
 	statusFilePathStyle       tcell.Style
 	menuBorderStyle           tcell.Style
+	paneBorderStyle           tcell.Style
 	menuIconStyle             tcell.Style
 	menuPromptStyle           tcell.Style
 	menuQueryStyle            tcell.Style
@@ -43,6 +44,13 @@ type Palette struct {
 	tokenCustom6Style         tcell.Style
 	tokenCustom7Style         tcell.Style
 	tokenCustom8Style         tcell.Style
+	diffAddedStyle            tcell.Style
+	diffRemovedStyle          tcell.Style
+	diffModifiedStyle         tcell.Style
+	cursorLineStyle           tcell.Style
+	rulerStyle                tcell.Style
+	indentGuideStyle          tcell.Style
+	foldStyle                 tcell.Style
 }
 
 func NewPalette() *Palette {
@@ -58,6 +66,7 @@ func NewPalette() *Palette {
 		statusRecordingMacroStyle: s.Bold(true),
 		statusFilePathStyle:       s.Bold(true),
 		menuBorderStyle:           s.Dim(true),
+		paneBorderStyle:           s.Dim(true),
 		menuIconStyle:             s,
 		menuPromptStyle:           s.Dim(true),
 		menuQueryStyle:            s,
@@ -79,6 +88,13 @@ func NewPalette() *Palette {
 		tokenCustom6Style:         s.Foreground(tcell.ColorAqua),
 		tokenCustom7Style:         s.Foreground(tcell.ColorDarkGreen),
 		tokenCustom8Style:         s.Foreground(tcell.ColorDarkCyan),
+		diffAddedStyle:            s.Foreground(tcell.ColorGreen),
+		diffRemovedStyle:          s.Foreground(tcell.ColorRed),
+		diffModifiedStyle:         s.Foreground(tcell.ColorYellow),
+		cursorLineStyle:           s.Background(tcell.ColorGray),
+		rulerStyle:                s.Background(tcell.ColorMaroon),
+		indentGuideStyle:          s.Dim(true),
+		foldStyle:                 s.Foreground(tcell.ColorTeal),
 	}
 }
 
@@ -88,6 +104,42 @@ func NewPaletteFromConfigStyles(styles map[string]config.StyleConfig) *Palette {
 		switch k {
 		case config.StyleLineNum:
 			p.lineNumStyle = styleFromConfig(v)
+		case config.StyleSelection:
+			p.selectionStyle = styleFromConfig(v)
+		case config.StyleSearchMatch:
+			p.searchMatchStyle = styleFromConfig(v)
+		case config.StyleStatusMsgSuccess:
+			p.statusMsgSuccessStyle = styleFromConfig(v)
+		case config.StyleStatusMsgError:
+			p.statusMsgErrorStyle = styleFromConfig(v)
+		case config.StyleStatusInputMode:
+			p.statusInputModeStyle = styleFromConfig(v)
+		case config.StyleStatusInputBuffer:
+			p.statusInputBufferStyle = styleFromConfig(v)
+		case config.StyleStatusRecordingMacro:
+			p.statusRecordingMacroStyle = styleFromConfig(v)
+		case config.StyleStatusFilePath:
+			p.statusFilePathStyle = styleFromConfig(v)
+		case config.StyleMenuBorder:
+			p.menuBorderStyle = styleFromConfig(v)
+		case config.StylePaneBorder:
+			p.paneBorderStyle = styleFromConfig(v)
+		case config.StyleMenuIcon:
+			p.menuIconStyle = styleFromConfig(v)
+		case config.StyleMenuPrompt:
+			p.menuPromptStyle = styleFromConfig(v)
+		case config.StyleMenuQuery:
+			p.menuQueryStyle = styleFromConfig(v)
+		case config.StyleMenuCursor:
+			p.menuCursorStyle = styleFromConfig(v)
+		case config.StyleMenuItemSelected:
+			p.menuItemSelectedStyle = styleFromConfig(v)
+		case config.StyleMenuItemUnselected:
+			p.menuItemUnselectedStyle = styleFromConfig(v)
+		case config.StyleSearchPrefix:
+			p.searchPrefixStyle = styleFromConfig(v)
+		case config.StyleSearchQuery:
+			p.searchQueryStyle = styleFromConfig(v)
 		case config.StyleTokenOperator:
 			p.tokenOperatorStyle = styleFromConfig(v)
 		case config.StyleTokenKeyword:
@@ -114,6 +166,20 @@ func NewPaletteFromConfigStyles(styles map[string]config.StyleConfig) *Palette {
 			p.tokenCustom7Style = styleFromConfig(v)
 		case config.StyleTokenCustom8:
 			p.tokenCustom8Style = styleFromConfig(v)
+		case config.StyleDiffAdded:
+			p.diffAddedStyle = styleFromConfig(v)
+		case config.StyleDiffRemoved:
+			p.diffRemovedStyle = styleFromConfig(v)
+		case config.StyleDiffModified:
+			p.diffModifiedStyle = styleFromConfig(v)
+		case config.StyleCursorLine:
+			p.cursorLineStyle = styleFromConfig(v)
+		case config.StyleRuler:
+			p.rulerStyle = styleFromConfig(v)
+		case config.StyleIndentGuide:
+			p.indentGuideStyle = styleFromConfig(v)
+		case config.StyleFold:
+			p.foldStyle = styleFromConfig(v)
 		default:
 			log.Printf("Unrecognized style key: %s\n", k)
 		}
@@ -125,6 +191,35 @@ func (p *Palette) StyleForLineNum() tcell.Style {
 	return p.lineNumStyle
 }
 
+func (p *Palette) StyleForGitChange(change state.GitChange) tcell.Style {
+	switch change {
+	case state.GitChangeAdded:
+		return p.diffAddedStyle
+	case state.GitChangeModified:
+		return p.diffModifiedStyle
+	case state.GitChangeRemoved:
+		return p.diffRemovedStyle
+	default:
+		return tcell.StyleDefault
+	}
+}
+
+func (p *Palette) StyleForCursorLine() tcell.Style {
+	return p.cursorLineStyle
+}
+
+func (p *Palette) StyleForRuler() tcell.Style {
+	return p.rulerStyle
+}
+
+func (p *Palette) StyleForIndentGuide() tcell.Style {
+	return p.indentGuideStyle
+}
+
+func (p *Palette) StyleForFold() tcell.Style {
+	return p.foldStyle
+}
+
 func (p *Palette) StyleForSelection() tcell.Style {
 	return p.selectionStyle
 }
@@ -164,6 +259,10 @@ func (p *Palette) StyleForMenuBorder() tcell.Style {
 	return p.menuBorderStyle
 }
 
+func (p *Palette) StyleForPaneBorder() tcell.Style {
+	return p.paneBorderStyle
+}
+
 func (p *Palette) StyleForMenuIcon() tcell.Style {
 	return p.menuIconStyle
 }
@@ -224,6 +323,10 @@ func (p *Palette) StyleForTokenRole(tokenRole parser.TokenRole) tcell.Style {
 		return p.tokenCustom7Style
 	case parser.TokenRoleCustom8:
 		return p.tokenCustom8Style
+	case parser.TokenRoleDiffAdded:
+		return p.diffAddedStyle
+	case parser.TokenRoleDiffRemoved:
+		return p.diffRemovedStyle
 	default:
 		return tcell.StyleDefault
 	}
@@ -254,5 +357,13 @@ func styleFromConfig(s config.StyleConfig) tcell.Style {
 		style = style.StrikeThrough(true)
 	}
 
+	if s.Reverse {
+		style = style.Reverse(true)
+	}
+
+	if s.Dim {
+		style = style.Dim(true)
+	}
+
 	return style
 }
@@ -27,6 +27,17 @@ func TestPaletteFromConfigStyles(t *testing.T) {
 		config.StyleTokenCustom4: {
 			BackgroundColor: "yellow",
 		},
+		config.StyleSelection: {
+			Reverse: true,
+		},
+		config.StyleStatusMsgError: {
+			Color:           "white",
+			BackgroundColor: "red",
+		},
+		config.StyleMenuItemSelected: {
+			Color: "teal",
+			Dim:   true,
+		},
 	}
 
 	palette := NewPaletteFromConfigStyles(configStyles)
@@ -34,20 +45,21 @@ func TestPaletteFromConfigStyles(t *testing.T) {
 	s := tcell.StyleDefault
 	expected := &Palette{
 		lineNumStyle:              s.Foreground(tcell.ColorOlive),
-		selectionStyle:            s.Reverse(true).Dim(true),
+		selectionStyle:            s.Reverse(true),
 		searchMatchStyle:          s.Reverse(true),
 		statusMsgSuccessStyle:     s.Foreground(tcell.ColorGreen).Bold(true),
-		statusMsgErrorStyle:       s.Background(tcell.ColorMaroon).Foreground(tcell.ColorWhite).Bold(true),
+		statusMsgErrorStyle:       s.Foreground(tcell.ColorWhite).Background(tcell.ColorRed),
 		statusInputModeStyle:      s.Bold(true),
 		statusInputBufferStyle:    s.Bold(true),
 		statusRecordingMacroStyle: s.Bold(true),
 		statusFilePathStyle:       s.Bold(true),
 		menuBorderStyle:           s.Dim(true),
+		paneBorderStyle:           s.Dim(true),
 		menuIconStyle:             s,
 		menuPromptStyle:           s.Dim(true),
 		menuQueryStyle:            s,
 		menuCursorStyle:           s.Bold(true),
-		menuItemSelectedStyle:     s.Underline(true),
+		menuItemSelectedStyle:     s.Foreground(tcell.ColorTeal).Dim(true),
 		menuItemUnselectedStyle:   s,
 		searchPrefixStyle:         s,
 		searchQueryStyle:          s,
@@ -64,6 +76,13 @@ func TestPaletteFromConfigStyles(t *testing.T) {
 		tokenCustom6Style:         s.Foreground(tcell.ColorAqua),
 		tokenCustom7Style:         s.Foreground(tcell.ColorDarkGreen),
 		tokenCustom8Style:         s.Foreground(tcell.ColorDarkCyan),
+		diffAddedStyle:            s.Foreground(tcell.ColorGreen),
+		diffRemovedStyle:          s.Foreground(tcell.ColorRed),
+		diffModifiedStyle:         s.Foreground(tcell.ColorYellow),
+		cursorLineStyle:           s.Background(tcell.ColorGray),
+		rulerStyle:                s.Background(tcell.ColorMaroon),
+		indentGuideStyle:          s.Dim(true),
+		foldStyle:                 s.Foreground(tcell.ColorTeal),
 	}
 
 	assert.Equal(t, expected, palette)
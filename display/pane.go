@@ -0,0 +1,32 @@
+package display
+
+import (
+	"github.com/gdamore/tcell/v2"
+
+	"github.com/aretext/aretext/state"
+)
+
+// DrawPaneBorders draws lines between panes.
+// Every pane that doesn't start at the top or left edge of the screen has a border above or to the left of it.
+func DrawPaneBorders(screen tcell.Screen, palette *Palette, buffers []*state.BufferState) {
+	style := palette.StyleForPaneBorder()
+	for _, buffer := range buffers {
+		x, y, width, height := viewDimensions(buffer)
+		if y > 0 {
+			for col := x; col < x+width; col++ {
+				screen.SetContent(col, y-1, tcell.RuneHLine, nil, style)
+			}
+		}
+
+		if x > 0 {
+			startRow := y
+			if y > 0 {
+				// Fill the gap where this border meets the border above.
+				startRow = y - 1
+			}
+			for row := startRow; row < y+height; row++ {
+				screen.SetContent(x-1, row, tcell.RuneVLine, nil, style)
+			}
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package display
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+
+	"github.com/aretext/aretext/state"
+)
+
+func TestDrawPaneBorders(t *testing.T) {
+	withSimScreen(t, func(s tcell.SimulationScreen) {
+		s.SetSize(5, 5)
+		editorState := state.NewEditorState(5, 6, nil, nil)
+		state.SplitPane(editorState, state.SplitVertical)
+		state.SplitPane(editorState, state.SplitHorizontal)
+		buffers := append(editorState.InactivePanes(), editorState.DocumentBuffer())
+		DrawPaneBorders(s, NewPalette(), buffers)
+		s.Sync()
+		assertCellContents(t, s, [][]rune{
+			{' ', ' ', '│', ' ', ' '},
+			{' ', ' ', '│', ' ', ' '},
+			{'─', '─', '│', ' ', ' '},
+			{' ', ' ', '│', ' ', ' '},
+			{' ', ' ', '│', ' ', ' '},
+		})
+	})
+}
@@ -1,10 +1,16 @@
 package display
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/gdamore/tcell/v2"
 
+	"github.com/aretext/aretext/cellwidth"
+	"github.com/aretext/aretext/config"
 	"github.com/aretext/aretext/file"
 	"github.com/aretext/aretext/state"
+	"github.com/aretext/aretext/text/segment"
 )
 
 // DrawStatusBar draws a status bar on the last line of the screen.
@@ -16,6 +22,12 @@ func DrawStatusBar(
 	inputBufferString string,
 	isRecordingUserMacro bool,
 	filePath string,
+	fileFormat file.Format,
+	readOnly bool,
+	largeFileLoadedPercent int,
+	blame string,
+	statusBar config.StatusBarConfig,
+	statusBarInfo state.StatusBarInfo,
 ) {
 	screenWidth, screenHeight := screen.Size()
 	if screenHeight == 0 {
@@ -25,13 +37,36 @@ func DrawStatusBar(
 	row := screenHeight - 1
 	sr := NewScreenRegion(screen, 0, row, screenWidth, 1)
 	sr.Fill(' ', tcell.StyleDefault)
+
+	// Input, status messages, and running tasks take the entire status bar, even with a template.
+	hasTemplate := statusBar.Left != "" || statusBar.Right != ""
+	if hasTemplate && len(inputBufferString) == 0 && len(statusMsg.Text) == 0 && inputMode != state.InputModeTask {
+		segments := statusBarSegments(
+			inputMode,
+			isRecordingUserMacro,
+			filePath,
+			fileFormat,
+			readOnly,
+			largeFileLoadedPercent,
+			blame,
+			statusBarInfo)
+		left := expandStatusBarTemplate(statusBar.Left, segments)
+		right := expandStatusBarTemplate(statusBar.Right, segments)
+		drawStatusBarGroups(sr, left, right, palette.StyleForStatusFilePath())
+		return
+	}
+
 	text, style := statusBarContent(
 		palette,
 		statusMsg,
 		inputMode,
 		inputBufferString,
 		isRecordingUserMacro,
-		filePath)
+		filePath,
+		fileFormat,
+		readOnly,
+		largeFileLoadedPercent,
+		blame)
 	drawStringNoWrap(sr, text, 0, 0, style)
 }
 
@@ -42,6 +77,10 @@ func statusBarContent(
 	inputBufferString string,
 	isRecordingUserMacro bool,
 	filePath string,
+	fileFormat file.Format,
+	readOnly bool,
+	largeFileLoadedPercent int,
+	blame string,
 ) (string, tcell.Style) {
 	if len(inputBufferString) > 0 {
 		return inputBufferString, palette.StyleForStatusInputBuffer()
@@ -63,7 +102,204 @@ func statusBarContent(
 	case state.InputModeTask:
 		return "Running... press ESC to abort", palette.StyleForStatusInputMode()
 	default:
-		relPath := file.RelativePathCwd(filePath)
-		return relPath, palette.StyleForStatusFilePath()
+		text := file.RelativePathCwd(filePath)
+		for _, flag := range fileFlags(fileFormat, readOnly, largeFileLoadedPercent) {
+			text += " " + flag
+		}
+		if blame != "" {
+			text += " | " + blame
+		}
+		return text, palette.StyleForStatusFilePath()
+	}
+}
+
+// fileFlags returns annotations for the file, such as whether it is read-only or will be saved in a non-default format.
+func fileFlags(fileFormat file.Format, readOnly bool, largeFileLoadedPercent int) []string {
+	var flags []string
+	if readOnly {
+		flags = append(flags, "[read-only]")
+	}
+	if largeFileLoadedPercent > 0 {
+		// Large files are loaded incrementally, so show how much of the file has been loaded.
+		flags = append(flags, fmt.Sprintf("[loaded %d%%]", largeFileLoadedPercent))
+	}
+	if fileFormat.Hex {
+		// The line ending and encoding don't apply to hex dumps.
+		return append(flags, "[hex]")
+	}
+	if !fileFormat.EndsWithNewline {
+		// Warn the user that the file will be saved without a final newline.
+		flags = append(flags, "[noeol]")
+	}
+	if fileFormat.Compression != file.CompressionNone {
+		flags = append(flags, "["+string(fileFormat.Compression)+"]")
+	}
+	if fileFormat.BOM || fileFormat.Encoding != file.EncodingUTF8 {
+		flags = append(flags, "["+fileFormat.EncodingName()+"]")
+	}
+	if fileFormat.LineEnding == file.LineEndingCRLF {
+		flags = append(flags, "[CRLF]")
+	}
+	if fileFormat.MixedLineEndings {
+		// Warn the user that the line endings will be normalized when the file is saved.
+		flags = append(flags, "[mixed]")
+	}
+	return flags
+}
+
+// statusBarSegments returns the value of each segment that can be displayed by a status bar template.
+func statusBarSegments(
+	inputMode state.InputMode,
+	isRecordingUserMacro bool,
+	filePath string,
+	fileFormat file.Format,
+	readOnly bool,
+	largeFileLoadedPercent int,
+	blame string,
+	info state.StatusBarInfo,
+) map[string]string {
+	segments := map[string]string{
+		config.StatusBarSegmentMode:     strings.ToUpper(inputMode.String()),
+		config.StatusBarSegmentPath:     file.RelativePathCwd(filePath),
+		config.StatusBarSegmentAbsPath:  filePath,
+		config.StatusBarSegmentFlags:    strings.Join(fileFlags(fileFormat, readOnly, largeFileLoadedPercent), " "),
+		config.StatusBarSegmentLanguage: info.Language,
+		config.StatusBarSegmentBlame:    blame,
+		config.StatusBarSegmentShellCmd: info.ShellCmdOutput,
+	}
+
+	if info.Modified {
+		segments[config.StatusBarSegmentModified] = "[+]"
+	}
+
+	if info.LineNum > 0 {
+		segments[config.StatusBarSegmentLineCol] = fmt.Sprintf("%d:%d", info.LineNum, info.Col)
+	}
+
+	if info.NumLines > 0 {
+		segments[config.StatusBarSegmentPercent] = fmt.Sprintf("%d%%", info.LineNum*100/info.NumLines)
+	}
+
+	if !fileFormat.Hex {
+		// The line ending and encoding don't apply to hex dumps.
+		segments[config.StatusBarSegmentLineEnding] = "LF"
+		if fileFormat.LineEnding == file.LineEndingCRLF {
+			segments[config.StatusBarSegmentLineEnding] = "CRLF"
+		}
+		segments[config.StatusBarSegmentEncoding] = fileFormat.EncodingName()
+	}
+
+	if info.SelectedChars > 0 {
+		segments[config.StatusBarSegmentSelection] = fmt.Sprintf(
+			"%s, %s",
+			pluralize(info.SelectedChars, "char"),
+			pluralize(info.SelectedLines, "line"))
+	}
+
+	if isRecordingUserMacro {
+		segments[config.StatusBarSegmentMacro] = "recording"
+	}
+
+	return segments
+}
+
+func pluralize(n uint64, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// expandStatusBarTemplate replaces segment names in the template with their values.
+// Words in the template are separated by whitespace. If every segment in a word is empty,
+// the word is omitted, so "[{encoding}]" disappears when there is no encoding.
+func expandStatusBarTemplate(template string, segments map[string]string) string {
+	var words []string
+	for _, word := range strings.Fields(template) {
+		hasSegment, hasValue := false, false
+		expanded := config.StatusBarTemplateSegmentRegexp.ReplaceAllStringFunc(word, func(match string) string {
+			hasSegment = true
+			value := segments[match[1:len(match)-1]]
+			if value != "" {
+				hasValue = true
+			}
+			return value
+		})
+		if hasSegment && !hasValue {
+			continue
+		}
+		words = append(words, expanded)
+	}
+	return strings.Join(words, " ")
+}
+
+// drawStatusBarGroups draws the left group aligned to the left and the right group aligned to the right.
+// If the groups don't fit, the right group is truncated to the larger of half the width or the width
+// not needed by the left group, then the left group is truncated to the remaining width.
+func drawStatusBarGroups(sr *ScreenRegion, left, right string, style tcell.Style) {
+	width, _ := sr.Size()
+	leftWidth, rightWidth := stringWidth(left), stringWidth(right)
+	if leftWidth > 0 && rightWidth > 0 {
+		maxRightWidth := width - leftWidth - 1
+		if maxRightWidth < width/2 {
+			maxRightWidth = width / 2
+		}
+		right, rightWidth = truncateString(right, maxRightWidth)
+		maxLeftWidth := width
+		if rightWidth > 0 {
+			maxLeftWidth -= rightWidth + 1
+		}
+		left, _ = truncateString(left, maxLeftWidth)
+	} else {
+		left, _ = truncateString(left, width)
+		right, rightWidth = truncateString(right, width)
+	}
+
+	drawStringNoWrap(sr, left, 0, 0, style)
+	if rightWidth > 0 {
+		drawStringNoWrap(sr, right, width-rightWidth, 0, style)
+	}
+}
+
+// stringWidth returns the number of cells needed to display a string.
+func stringWidth(s string) int {
+	_, width := truncateString(s, -1)
+	return width
+}
+
+// truncateString shortens a string to fit within a maximum number of cells,
+// replacing the end of the string with an ellipsis if necessary.
+// If maxWidth is negative, the string is never truncated.
+// This returns the truncated string and its width in cells.
+func truncateString(s string, maxWidth int) (string, int) {
+	// Find the byte offset and width in cells at the end of each grapheme cluster.
+	var gcBreaker segment.GraphemeClusterBreaker
+	var gcRunes []rune
+	var gcEndOffsets, gcEndWidths []int
+	var width int
+	for i, r := range s + "\x00" { // The null terminator ends the last grapheme cluster.
+		if gcBreaker.ProcessRune(r) && len(gcRunes) > 0 {
+			width += int(cellwidth.GraphemeClusterWidth(gcRunes, uint64(width), config.DefaultTabSize))
+			gcEndOffsets = append(gcEndOffsets, i)
+			gcEndWidths = append(gcEndWidths, width)
+			gcRunes = gcRunes[:0]
+		}
+		gcRunes = append(gcRunes, r)
+	}
+
+	if maxWidth < 0 || width <= maxWidth {
+		return s, width
+	} else if maxWidth < 1 {
+		return "", 0
+	}
+
+	// Keep the longest prefix that leaves one cell for the ellipsis.
+	var prefixEnd, prefixWidth int
+	for j, w := range gcEndWidths {
+		if w > maxWidth-1 {
+			break
+		}
+		prefixEnd, prefixWidth = gcEndOffsets[j], w
 	}
+	return s[:prefixEnd] + "…", prefixWidth + 1
 }
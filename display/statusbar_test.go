@@ -5,28 +5,128 @@ import (
 
 	"github.com/gdamore/tcell/v2"
 
+	"github.com/aretext/aretext/config"
+	"github.com/aretext/aretext/file"
 	"github.com/aretext/aretext/state"
 )
 
 func TestDrawStatusBar(t *testing.T) {
 	testCases := []struct {
-		name                 string
-		statusMsg            state.StatusMsg
-		inputMode            state.InputMode
-		inputBufferString    string
-		isRecordingUserMacro bool
-		filePath             string
-		expectedContents     [][]rune
+		name                   string
+		statusMsg              state.StatusMsg
+		inputMode              state.InputMode
+		inputBufferString      string
+		isRecordingUserMacro   bool
+		filePath               string
+		fileFormat             file.Format
+		readOnly               bool
+		largeFileLoadedPercent int
+		blame                  string
+		expectedContents       [][]rune
 	}{
 		{
-			name:      "normal mode shows file path",
-			inputMode: state.InputModeNormal,
-			filePath:  "./foo/bar",
+			name:       "normal mode shows file path",
+			inputMode:  state.InputModeNormal,
+			filePath:   "./foo/bar",
+			fileFormat: file.DefaultFormat,
 			expectedContents: [][]rune{
 				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
 				{'.', '/', 'f', 'o', 'o', '/', 'b', 'a', 'r', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
 			},
 		},
+		{
+			name:       "normal mode shows blame",
+			inputMode:  state.InputModeNormal,
+			filePath:   "./foo",
+			fileFormat: file.DefaultFormat,
+			blame:      "a1b2c3d",
+			expectedContents: [][]rune{
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+				{'.', '/', 'f', 'o', 'o', ' ', '|', ' ', 'a', '1', 'b', '2', 'c', '3', 'd', ' '},
+			},
+		},
+		{
+			name:       "normal mode shows file without final newline",
+			inputMode:  state.InputModeNormal,
+			filePath:   "./foo",
+			fileFormat: file.Format{EndsWithNewline: false, LineEnding: file.LineEndingLF, Encoding: file.EncodingUTF8},
+			expectedContents: [][]rune{
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+				{'.', '/', 'f', 'o', 'o', ' ', '[', 'n', 'o', 'e', 'o', 'l', ']', ' ', ' ', ' '},
+			},
+		},
+		{
+			name:       "normal mode shows CRLF line endings",
+			inputMode:  state.InputModeNormal,
+			filePath:   "./foo",
+			fileFormat: file.Format{EndsWithNewline: true, LineEnding: file.LineEndingCRLF, Encoding: file.EncodingUTF8},
+			expectedContents: [][]rune{
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+				{'.', '/', 'f', 'o', 'o', ' ', '[', 'C', 'R', 'L', 'F', ']', ' ', ' ', ' ', ' '},
+			},
+		},
+		{
+			name:       "normal mode shows mixed line endings",
+			inputMode:  state.InputModeNormal,
+			filePath:   "./foo",
+			fileFormat: file.Format{EndsWithNewline: true, LineEnding: file.LineEndingLF, MixedLineEndings: true, Encoding: file.EncodingUTF8},
+			expectedContents: [][]rune{
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+				{'.', '/', 'f', 'o', 'o', ' ', '[', 'm', 'i', 'x', 'e', 'd', ']', ' ', ' ', ' '},
+			},
+		},
+		{
+			name:       "normal mode shows encoding",
+			inputMode:  state.InputModeNormal,
+			filePath:   "./foo",
+			fileFormat: file.Format{EndsWithNewline: true, LineEnding: file.LineEndingLF, Encoding: file.EncodingLatin1},
+			expectedContents: [][]rune{
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+				{'.', '/', 'f', 'o', 'o', ' ', '[', 'L', 'a', 't', 'i', 'n', '-', '1', ']', ' '},
+			},
+		},
+		{
+			name:       "normal mode shows compression",
+			inputMode:  state.InputModeNormal,
+			filePath:   "./foo",
+			fileFormat: file.Format{EndsWithNewline: true, LineEnding: file.LineEndingLF, Encoding: file.EncodingUTF8, Compression: file.CompressionGzip},
+			expectedContents: [][]rune{
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+				{'.', '/', 'f', 'o', 'o', ' ', '[', 'g', 'z', 'i', 'p', ']', ' ', ' ', ' ', ' '},
+			},
+		},
+		{
+			name:       "normal mode shows hex mode",
+			inputMode:  state.InputModeNormal,
+			filePath:   "./foo",
+			fileFormat: file.HexFormat,
+			expectedContents: [][]rune{
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+				{'.', '/', 'f', 'o', 'o', ' ', '[', 'h', 'e', 'x', ']', ' ', ' ', ' ', ' ', ' '},
+			},
+		},
+		{
+			name:       "normal mode shows read-only",
+			inputMode:  state.InputModeNormal,
+			filePath:   "./foo",
+			fileFormat: file.DefaultFormat,
+			readOnly:   true,
+			expectedContents: [][]rune{
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+				{'.', '/', 'f', 'o', 'o', ' ', '[', 'r', 'e', 'a', 'd', '-', 'o', 'n', 'l', 'y'},
+			},
+		},
+		{
+			name:                   "normal mode shows loaded percent",
+			inputMode:              state.InputModeNormal,
+			filePath:               "./foo",
+			fileFormat:             file.DefaultFormat,
+			largeFileLoadedPercent: 25,
+			expectedContents: [][]rune{
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+				{'.', '/', 'f', 'o', 'o', ' ', '[', 'l', 'o', 'a', 'd', 'e', 'd', ' ', '2', '5'},
+			},
+		},
 		{
 			name:      "insert mode shows INSERT",
 			inputMode: state.InputModeInsert,
@@ -46,9 +146,10 @@ func TestDrawStatusBar(t *testing.T) {
 			},
 		},
 		{
-			name:      "menu mode shows file path",
-			inputMode: state.InputModeMenu,
-			filePath:  "./foo/bar",
+			name:       "menu mode shows file path",
+			inputMode:  state.InputModeMenu,
+			filePath:   "./foo/bar",
+			fileFormat: file.DefaultFormat,
 			expectedContents: [][]rune{
 				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
 				{'.', '/', 'f', 'o', 'o', '/', 'b', 'a', 'r', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
@@ -108,6 +209,146 @@ func TestDrawStatusBar(t *testing.T) {
 					tc.inputBufferString,
 					tc.isRecordingUserMacro,
 					tc.filePath,
+					tc.fileFormat,
+					tc.readOnly,
+					tc.largeFileLoadedPercent,
+					tc.blame,
+					config.StatusBarConfig{},
+					state.StatusBarInfo{},
+				)
+				s.Sync()
+				assertCellContents(t, s, tc.expectedContents)
+			})
+		})
+	}
+}
+
+func TestDrawStatusBarTemplate(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		statusBar            config.StatusBarConfig
+		statusBarInfo        state.StatusBarInfo
+		statusMsg            state.StatusMsg
+		inputMode            state.InputMode
+		isRecordingUserMacro bool
+		filePath             string
+		fileFormat           file.Format
+		expectedContents     [][]rune
+	}{
+		{
+			name:          "left and right groups",
+			statusBar:     config.StatusBarConfig{Left: "{mode}", Right: "{lineCol} {percent}"},
+			statusBarInfo: state.StatusBarInfo{LineNum: 2, Col: 3, NumLines: 4},
+			inputMode:     state.InputModeInsert,
+			filePath:      "./foo",
+			fileFormat:    file.DefaultFormat,
+			expectedContents: [][]rune{
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+				{'I', 'N', 'S', 'E', 'R', 'T', ' ', ' ', ' ', '2', ':', '3', ' ', '5', '0', '%'},
+			},
+		},
+		{
+			name:          "empty segments are omitted",
+			statusBar:     config.StatusBarConfig{Left: "{path}{modified} [{macro}] {flags} {language}"},
+			statusBarInfo: state.StatusBarInfo{Modified: true},
+			filePath:      "./foo",
+			fileFormat:    file.DefaultFormat,
+			expectedContents: [][]rune{
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+				{'.', '/', 'f', 'o', 'o', '[', '+', ']', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+			},
+		},
+		{
+			name:                 "file format and macro",
+			statusBar:            config.StatusBarConfig{Left: "{macro}", Right: "{encoding} {lineEnding}"},
+			isRecordingUserMacro: true,
+			filePath:             "./foo",
+			fileFormat:           file.Format{EndsWithNewline: true, LineEnding: file.LineEndingCRLF, Encoding: file.EncodingUTF8},
+			expectedContents: [][]rune{
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+				{'r', 'e', 'c', 'o', 'r', 'd', '…', ' ', 'U', 'T', 'F', '-', '8', ' ', 'C', '…'},
+			},
+		},
+		{
+			name:          "selection",
+			statusBar:     config.StatusBarConfig{Right: "{selection}"},
+			statusBarInfo: state.StatusBarInfo{SelectedChars: 12, SelectedLines: 1},
+			inputMode:     state.InputModeVisual,
+			filePath:      "./foo",
+			fileFormat:    file.DefaultFormat,
+			expectedContents: [][]rune{
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+				{'1', '2', ' ', 'c', 'h', 'a', 'r', 's', ',', ' ', '1', ' ', 'l', 'i', 'n', 'e'},
+			},
+		},
+		{
+			name:          "shell command output",
+			statusBar:     config.StatusBarConfig{Left: "{path}", Right: "<{shellCmd}>"},
+			statusBarInfo: state.StatusBarInfo{ShellCmdOutput: "main"},
+			filePath:      "./foo",
+			fileFormat:    file.DefaultFormat,
+			expectedContents: [][]rune{
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+				{'.', '/', 'f', 'o', 'o', ' ', ' ', ' ', ' ', ' ', '<', 'm', 'a', 'i', 'n', '>'},
+			},
+		},
+		{
+			name:          "truncate left group",
+			statusBar:     config.StatusBarConfig{Left: "{path}", Right: "{lineCol}"},
+			statusBarInfo: state.StatusBarInfo{LineNum: 10, Col: 1, NumLines: 20},
+			filePath:      "./foo/bar/baz/qux",
+			fileFormat:    file.DefaultFormat,
+			expectedContents: [][]rune{
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+				{'.', '/', 'f', 'o', 'o', '/', 'b', 'a', 'r', '/', '…', ' ', '1', '0', ':', '1'},
+			},
+		},
+		{
+			name:          "truncate both groups",
+			statusBar:     config.StatusBarConfig{Left: "{path}", Right: "{blame}{shellCmd}"},
+			statusBarInfo: state.StatusBarInfo{ShellCmdOutput: "abcdefghijklmnop"},
+			filePath:      "./foo/bar/baz/qux",
+			fileFormat:    file.DefaultFormat,
+			expectedContents: [][]rune{
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+				{'.', '/', 'f', 'o', 'o', '/', '…', ' ', 'a', 'b', 'c', 'd', 'e', 'f', 'g', '…'},
+			},
+		},
+		{
+			name:      "status message replaces template",
+			statusBar: config.StatusBarConfig{Left: "{path}"},
+			statusMsg: state.StatusMsg{
+				Text:  "success",
+				Style: state.StatusMsgStyleSuccess,
+			},
+			filePath:   "./foo",
+			fileFormat: file.DefaultFormat,
+			expectedContents: [][]rune{
+				{' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+				{'s', 'u', 'c', 'c', 'e', 's', 's', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' ', ' '},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			withSimScreen(t, func(s tcell.SimulationScreen) {
+				s.SetSize(16, 2)
+				palette := NewPalette()
+				DrawStatusBar(
+					s,
+					palette,
+					tc.statusMsg,
+					tc.inputMode,
+					"",
+					tc.isRecordingUserMacro,
+					tc.filePath,
+					tc.fileFormat,
+					false,
+					0,
+					"",
+					tc.statusBar,
+					tc.statusBarInfo,
 				)
 				s.Sync()
 				assertCellContents(t, s, tc.expectedContents)
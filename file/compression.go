@@ -0,0 +1,84 @@
+package file
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Compression is the compression format of a file.
+type Compression string
+
+const (
+	CompressionNone = Compression("")
+	CompressionGzip = Compression("gzip")
+)
+
+// gzipMagic is the header at the start of every gzip file.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// gzipExt is the file extension for gzip-compressed files.
+const gzipExt = ".gz"
+
+// detectCompression checks whether the reader starts with the magic bytes for a compression format.
+// It does not consume any bytes from the reader.
+func detectCompression(r *bufio.Reader) Compression {
+	// Peek may return fewer bytes than requested if the file is short, so check whatever we got.
+	prefix, _ := r.Peek(len(gzipMagic))
+	if bytes.Equal(prefix, gzipMagic) {
+		return CompressionGzip
+	}
+	return CompressionNone
+}
+
+// DetectCompression checks whether the file at path is compressed.
+func DetectCompression(path string) (Compression, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return CompressionNone, errors.Wrap(err, "os.Open")
+	}
+	defer f.Close()
+	return detectCompression(bufio.NewReader(f)), nil
+}
+
+// newDecompressingReader returns a reader that decompresses the bytes from r.
+func newDecompressingReader(r io.Reader, c Compression) (io.Reader, error) {
+	if c == CompressionNone {
+		return r, nil
+	}
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "gzip.NewReader")
+	}
+	return gz, nil
+}
+
+// newCompressingWriter returns a writer that compresses bytes written to w.
+// The caller must close the returned writer to flush the compressed output.
+func newCompressingWriter(w io.Writer, c Compression) io.WriteCloser {
+	if c == CompressionNone {
+		return nopWriteCloser{w}
+	}
+	return gzip.NewWriter(w)
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// UncompressedPath returns the path without the extension for a compressed file.
+// For example, "data.json.gz" becomes "data.json".
+// This allows configuration rules to match the file type of the compressed contents.
+func UncompressedPath(path string) string {
+	return strings.TrimSuffix(path, gzipExt)
+}
@@ -0,0 +1,131 @@
+package file
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/text"
+)
+
+func gzipString(t *testing.T, s string) string {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := io.WriteString(w, s)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.String()
+}
+
+func gunzipFile(t *testing.T, path string) string {
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	r, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestLoadGzip(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		fileContents         string
+		expectedTreeContents string
+		expectedFormat       Format
+	}{
+		{
+			name:                 "gzip",
+			fileContents:         gzipString(t, "ab\ncd\n"),
+			expectedTreeContents: "ab\ncd",
+			expectedFormat:       Format{EndsWithNewline: true, LineEnding: LineEndingLF, Encoding: EncodingUTF8, Compression: CompressionGzip},
+		},
+		{
+			name:                 "gzip with CRLF and BOM",
+			fileContents:         gzipString(t, "\xef\xbb\xbfab\r\ncd"),
+			expectedTreeContents: "ab\ncd",
+			expectedFormat:       Format{EndsWithNewline: false, LineEnding: LineEndingCRLF, Encoding: EncodingUTF8, BOM: true, Compression: CompressionGzip},
+		},
+		{
+			name:                 "gzip magic byte only",
+			fileContents:         "\x1f",
+			expectedTreeContents: "\x1f",
+			expectedFormat:       Format{EndsWithNewline: false, LineEnding: LineEndingLF, Encoding: EncodingUTF8},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			filePath := createTestFile(t, tc.fileContents)
+			tree, watcher, format, err := Load(filePath, "", testWatcherPollInterval)
+			require.NoError(t, err)
+			defer watcher.Stop()
+
+			assert.Equal(t, tc.expectedTreeContents, tree.String())
+			assert.Equal(t, tc.expectedFormat, format)
+
+			// The watcher checksum should match the compressed file on disk.
+			changed, err := watcher.CheckFileContentsChanged()
+			require.NoError(t, err)
+			assert.False(t, changed)
+		})
+	}
+}
+
+func TestLoadGzipInvalid(t *testing.T) {
+	// The gzip magic bytes followed by an invalid header.
+	filePath := createTestFile(t, "\x1f\x8b\x00\x00")
+	_, _, _, err := Load(filePath, "", testWatcherPollInterval)
+	assert.Error(t, err)
+}
+
+func TestSaveGzip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.txt.gz")
+
+	tree, err := text.NewTreeFromString("ab\ncd")
+	require.NoError(t, err)
+
+	format := DefaultFormat
+	format.Compression = CompressionGzip
+	watcher, err := Save(path, tree, format, testWatcherPollInterval)
+	require.NoError(t, err)
+	defer watcher.Stop()
+
+	assert.Equal(t, "ab\ncd\n", gunzipFile(t, path))
+
+	// The watcher checksum should match the compressed file on disk.
+	changed, err := watcher.CheckFileContentsChanged()
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	// Load the file back to check that the format round-trips.
+	loadedTree, loadedWatcher, loadedFormat, err := Load(path, "", testWatcherPollInterval)
+	require.NoError(t, err)
+	defer loadedWatcher.Stop()
+	assert.Equal(t, "ab\ncd", loadedTree.String())
+	assert.Equal(t, format, loadedFormat)
+}
+
+func TestDetectCompression(t *testing.T) {
+	gzipPath := createTestFile(t, gzipString(t, "abc"))
+	c, err := DetectCompression(gzipPath)
+	require.NoError(t, err)
+	assert.Equal(t, CompressionGzip, c)
+
+	textPath := createTestFile(t, "abc")
+	c, err = DetectCompression(textPath)
+	require.NoError(t, err)
+	assert.Equal(t, CompressionNone, c)
+}
+
+func TestUncompressedPath(t *testing.T) {
+	assert.Equal(t, "/foo/data.json", UncompressedPath("/foo/data.json.gz"))
+	assert.Equal(t, "/foo/data.json", UncompressedPath("/foo/data.json"))
+}
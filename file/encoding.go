@@ -0,0 +1,108 @@
+package file
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// Encoding is the character encoding of a file.
+type Encoding string
+
+const (
+	EncodingUTF8        = Encoding("utf-8")
+	EncodingUTF16LE     = Encoding("utf-16le")
+	EncodingUTF16BE     = Encoding("utf-16be")
+	EncodingLatin1      = Encoding("latin-1")
+	EncodingWindows1252 = Encoding("windows-1252")
+)
+
+// String returns a display name for the encoding.
+func (e Encoding) String() string {
+	switch e {
+	case EncodingUTF8:
+		return "UTF-8"
+	case EncodingUTF16LE:
+		return "UTF-16LE"
+	case EncodingUTF16BE:
+		return "UTF-16BE"
+	case EncodingLatin1:
+		return "Latin-1"
+	case EncodingWindows1252:
+		return "Windows-1252"
+	default:
+		panic("invalid encoding")
+	}
+}
+
+// bom returns the byte order mark for the encoding, if the encoding has one.
+func (e Encoding) bom() []byte {
+	switch e {
+	case EncodingUTF8:
+		return []byte{0xEF, 0xBB, 0xBF}
+	case EncodingUTF16LE:
+		return []byte{0xFF, 0xFE}
+	case EncodingUTF16BE:
+		return []byte{0xFE, 0xFF}
+	default:
+		return nil
+	}
+}
+
+// xEncoding returns the transformer for converting between the encoding and UTF-8.
+// This returns nil for UTF-8, since no conversion is necessary.
+func (e Encoding) xEncoding() encoding.Encoding {
+	switch e {
+	case EncodingUTF16LE:
+		return unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM)
+	case EncodingUTF16BE:
+		return unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM)
+	case EncodingLatin1:
+		return charmap.ISO8859_1
+	case EncodingWindows1252:
+		return charmap.Windows1252
+	default:
+		return nil
+	}
+}
+
+// detectBOM checks whether the reader starts with a byte order mark.
+// If so, it consumes the BOM and returns the encoding it indicates.
+func detectBOM(r *bufio.Reader) (Encoding, bool) {
+	// Peek may return fewer bytes than requested if the file is short, so check whatever we got.
+	prefix, _ := r.Peek(3)
+	for _, e := range []Encoding{EncodingUTF8, EncodingUTF16LE, EncodingUTF16BE} {
+		bom := e.bom()
+		if bytes.HasPrefix(prefix, bom) {
+			r.Discard(len(bom))
+			return e, true
+		}
+	}
+	return EncodingUTF8, false
+}
+
+// newDecodingReader returns a reader that converts text from the encoding to UTF-8.
+func newDecodingReader(r io.Reader, e Encoding) io.Reader {
+	if xe := e.xEncoding(); xe != nil {
+		return transform.NewReader(r, xe.NewDecoder())
+	}
+	return r
+}
+
+// newEncodingReader returns a reader that converts UTF-8 text to the encoding,
+// prefixed by the byte order mark if bom is true.
+// Reading fails if the text contains a character that the encoding cannot represent.
+func newEncodingReader(r io.Reader, e Encoding, bom bool) io.Reader {
+	if xe := e.xEncoding(); xe != nil {
+		r = transform.NewReader(r, xe.NewEncoder())
+	}
+	if bom {
+		r = io.MultiReader(bytes.NewReader(e.bom()), r)
+	}
+	return r
+}
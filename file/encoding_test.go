@@ -0,0 +1,185 @@
+package file
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/text"
+)
+
+func TestLoadEncoding(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		fileContents         string
+		fallbackEncoding     Encoding
+		expectedTreeContents string
+		expectedEncoding     Encoding
+		expectedBOM          bool
+		expectedErr          error
+	}{
+		{
+			name:                 "UTF-8 without BOM",
+			fileContents:         "héllo\n",
+			expectedTreeContents: "héllo",
+			expectedEncoding:     EncodingUTF8,
+		},
+		{
+			name:                 "UTF-8 with BOM",
+			fileContents:         "\xef\xbb\xbfhéllo\n",
+			expectedTreeContents: "héllo",
+			expectedEncoding:     EncodingUTF8,
+			expectedBOM:          true,
+		},
+		{
+			name:                 "UTF-16LE with BOM",
+			fileContents:         "\xff\xfeh\x00\xe9\x00\n\x00",
+			expectedTreeContents: "hé",
+			expectedEncoding:     EncodingUTF16LE,
+			expectedBOM:          true,
+		},
+		{
+			name:                 "UTF-16BE with BOM",
+			fileContents:         "\xfe\xff\x00h\x00\xe9\x00\n",
+			expectedTreeContents: "hé",
+			expectedEncoding:     EncodingUTF16BE,
+			expectedBOM:          true,
+		},
+		{
+			name:         "invalid UTF-8 without fallback",
+			fileContents: "h\xe9llo\n",
+			expectedErr:  text.InvalidUtf8Error,
+		},
+		{
+			name:                 "invalid UTF-8 with Latin-1 fallback",
+			fileContents:         "h\xe9llo\n",
+			fallbackEncoding:     EncodingLatin1,
+			expectedTreeContents: "héllo",
+			expectedEncoding:     EncodingLatin1,
+		},
+		{
+			name:                 "invalid UTF-8 with Windows-1252 fallback",
+			fileContents:         "\x93quoted\x94\n",
+			fallbackEncoding:     EncodingWindows1252,
+			expectedTreeContents: "“quoted”",
+			expectedEncoding:     EncodingWindows1252,
+		},
+		{
+			name:                 "valid UTF-8 with fallback",
+			fileContents:         "héllo\n",
+			fallbackEncoding:     EncodingLatin1,
+			expectedTreeContents: "héllo",
+			expectedEncoding:     EncodingUTF8,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			filePath := createTestFile(t, tc.fileContents)
+
+			tree, watcher, format, err := Load(filePath, tc.fallbackEncoding, time.Second)
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			defer watcher.Stop()
+
+			assert.Equal(t, tc.expectedTreeContents, tree.String())
+			assert.Equal(t, tc.expectedEncoding, format.Encoding)
+			assert.Equal(t, tc.expectedBOM, format.BOM)
+
+			// The watcher checksum should match the undecoded file contents on disk.
+			changed, err := watcher.CheckFileContentsChanged()
+			require.NoError(t, err)
+			assert.False(t, changed)
+		})
+	}
+}
+
+func TestSaveEncoding(t *testing.T) {
+	testCases := []struct {
+		name             string
+		treeContents     string
+		encoding         Encoding
+		bom              bool
+		expectedContents string
+		expectErr        bool
+	}{
+		{
+			name:             "UTF-8 without BOM",
+			treeContents:     "hé",
+			encoding:         EncodingUTF8,
+			expectedContents: "hé\n",
+		},
+		{
+			name:             "UTF-8 with BOM",
+			treeContents:     "hé",
+			encoding:         EncodingUTF8,
+			bom:              true,
+			expectedContents: "\xef\xbb\xbfhé\n",
+		},
+		{
+			name:             "UTF-16LE with BOM",
+			treeContents:     "hé",
+			encoding:         EncodingUTF16LE,
+			bom:              true,
+			expectedContents: "\xff\xfeh\x00\xe9\x00\n\x00",
+		},
+		{
+			name:             "UTF-16BE with BOM",
+			treeContents:     "hé",
+			encoding:         EncodingUTF16BE,
+			bom:              true,
+			expectedContents: "\xfe\xff\x00h\x00\xe9\x00\n",
+		},
+		{
+			name:             "Latin-1",
+			treeContents:     "hé",
+			encoding:         EncodingLatin1,
+			expectedContents: "h\xe9\n",
+		},
+		{
+			name:         "Latin-1 with unsupported character",
+			treeContents: "世",
+			encoding:     EncodingLatin1,
+			expectErr:    true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			path := path.Join(tmpDir, "test.txt")
+
+			tree, err := text.NewTreeFromString(tc.treeContents)
+			require.NoError(t, err)
+
+			format := Format{
+				EndsWithNewline: true,
+				LineEnding:      LineEndingLF,
+				Encoding:        tc.encoding,
+				BOM:             tc.bom,
+			}
+			watcher, err := Save(path, tree, format, testWatcherPollInterval)
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			defer watcher.Stop()
+
+			fileBytes, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedContents, string(fileBytes))
+
+			changed, err := watcher.CheckFileContentsChanged()
+			require.NoError(t, err)
+			assert.False(t, changed)
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package file
+
+// Format describes how the text of a document is stored in a file.
+type Format struct {
+	// EndsWithNewline indicates whether the file ends with a line feed (the POSIX end-of-file indicator).
+	// The line feed is not included in the loaded document text.
+	EndsWithNewline bool
+
+	// LineEnding is the line ending written for each line feed in the document.
+	// The document text always uses line feeds, regardless of the line ending in the file.
+	LineEnding LineEnding
+
+	// MixedLineEndings indicates that the loaded file contained both LF and CRLF line endings.
+	// When the file is saved, every line will use LineEnding.
+	MixedLineEndings bool
+
+	// Encoding is the character encoding of the file.
+	// The document text is always UTF-8, regardless of the encoding in the file.
+	Encoding Encoding
+
+	// BOM indicates whether the file starts with a byte order mark.
+	// The byte order mark is not included in the loaded document text.
+	BOM bool
+
+	// Compression is the compression format of the file.
+	// The document text is always decompressed, and the file is compressed again when saved.
+	Compression Compression
+
+	// Hex indicates that the document is a hex dump of the file contents.
+	// When saving, the hex dump is converted back to bytes, ignoring the line ending and encoding.
+	Hex bool
+}
+
+// EncodingName returns a display name for the encoding, including whether it has a byte order mark.
+func (f Format) EncodingName() string {
+	if f.BOM && f.Encoding == EncodingUTF8 {
+		return "UTF-8 with BOM"
+	}
+	return f.Encoding.String()
+}
+
+// DefaultFormat is the format used for new files.
+var DefaultFormat = Format{
+	EndsWithNewline: true,
+	LineEnding:      LineEndingLF,
+	Encoding:        EncodingUTF8,
+}
@@ -0,0 +1,151 @@
+package file
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/aretext/aretext/text"
+)
+
+// gitCommandTimeout limits how long to wait for git, so a slow repository doesn't leave git processes running.
+const gitCommandTimeout = 2 * time.Second
+
+// LoadGitHead reads the version of a file in the HEAD commit of its git repository.
+// The contents are decoded and normalized the same way as Load, including removing the POSIX end-of-file indicator.
+// This returns an error if git isn't installed, the file isn't in a git repository, or the file isn't in the HEAD commit.
+func LoadGitHead(path string, fallbackEncoding Encoding) (*text.Tree, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "filepath.Abs")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), gitCommandTimeout)
+	defer cancel()
+
+	// The "./" prefix resolves the path relative to the directory, instead of the root of the repository.
+	cmd := exec.CommandContext(ctx, "git", "-C", filepath.Dir(path), "show", "HEAD:./"+filepath.Base(path))
+	data, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrap(err, "git show")
+	}
+
+	tree, _, format, err := readContentsAndChecksum(bytes.NewReader(data), "")
+	if errors.Cause(err) == text.InvalidUtf8Error && !format.BOM && fallbackEncoding != "" {
+		tree, _, _, err = readContentsAndChecksum(bytes.NewReader(data), fallbackEncoding)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "readContentsAndChecksum")
+	}
+
+	if tree.NumChars() > 0 {
+		removePosixEof(tree)
+	}
+
+	return tree, nil
+}
+
+// BlameLine describes the commit that last changed a line of a file.
+type BlameLine struct {
+	CommitHash string
+	Author     string
+	AuthorTime time.Time
+	Summary    string
+	Text       string // Contents of the line, without the line ending.
+}
+
+// IsCommitted returns whether the line has been committed.
+// Lines changed in the working tree, but not committed, are blamed on a hash with all zeros.
+func (b BlameLine) IsCommitted() bool {
+	return strings.Trim(b.CommitHash, "0") != ""
+}
+
+// LoadGitBlame runs "git blame" for the file on disk and returns the commit for each line.
+// This returns an error if git isn't installed or the file isn't tracked in a git repository.
+func LoadGitBlame(ctx context.Context, path string) ([]BlameLine, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "filepath.Abs")
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "-C", filepath.Dir(path), "blame", "--porcelain", "--", filepath.Base(path))
+	data, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrap(err, "git blame")
+	}
+
+	lines, err := parseGitBlamePorcelain(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Wrap(err, "parseGitBlamePorcelain")
+	}
+	return lines, nil
+}
+
+// parseGitBlamePorcelain parses the output of "git blame --porcelain".
+// Each line starts with a header containing the commit hash, followed by information about
+// the commit (only the first time the commit appears), followed by the line contents prefixed by a tab.
+func parseGitBlamePorcelain(r io.Reader) ([]BlameLine, error) {
+	var lines []BlameLine
+	commits := make(map[string]*BlameLine)
+	var current *BlameLine
+	reader := bufio.NewReader(r)
+	for {
+		s, err := reader.ReadString('\n')
+		if err == io.EOF && s == "" {
+			break
+		} else if err != nil && err != io.EOF {
+			return nil, errors.Wrap(err, "ReadString")
+		}
+		s = strings.TrimSuffix(s, "\n")
+
+		if current == nil {
+			fields := strings.Fields(s)
+			if len(fields) < 3 {
+				return nil, errors.Errorf("Invalid header line %q", s)
+			}
+			hash := fields[0]
+			current = commits[hash]
+			if current == nil {
+				current = &BlameLine{CommitHash: hash}
+				commits[hash] = current
+			}
+			continue
+		}
+
+		if strings.HasPrefix(s, "\t") {
+			line := *current
+			line.Text = strings.TrimSuffix(s[1:], "\r")
+			lines = append(lines, line)
+			current = nil
+			continue
+		}
+
+		key, value, _ := strings.Cut(s, " ")
+		switch key {
+		case "author":
+			current.Author = value
+		case "author-time":
+			seconds, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, errors.Wrap(err, "strconv.ParseInt")
+			}
+			current.AuthorTime = time.Unix(seconds, 0)
+		case "summary":
+			current.Summary = value
+		}
+	}
+
+	if current != nil {
+		return nil, errors.New("Missing line contents after header")
+	}
+
+	return lines, nil
+}
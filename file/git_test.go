@@ -0,0 +1,149 @@
+package file
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runGit(t *testing.T, dir string, args ...string) {
+	args = append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+	out, err := exec.Command("git", args...).CombinedOutput()
+	require.NoError(t, err, string(out))
+}
+
+func TestLoadGitHead(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q")
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "subdir"), 0755))
+	path := filepath.Join(dir, "subdir", "test.txt")
+	require.NoError(t, os.WriteFile(path, []byte("abc\r\ndef\r\n"), 0644))
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-q", "-m", "initial commit")
+
+	// Changes in the working tree don't affect the version at HEAD.
+	require.NoError(t, os.WriteFile(path, []byte("xyz"), 0644))
+	tree, err := LoadGitHead(path, "")
+	require.NoError(t, err)
+	assert.Equal(t, "abc\ndef", tree.String())
+
+	// Untracked files aren't in the HEAD commit.
+	untrackedPath := filepath.Join(dir, "untracked.txt")
+	require.NoError(t, os.WriteFile(untrackedPath, []byte("abc"), 0644))
+	_, err = LoadGitHead(untrackedPath, "")
+	assert.Error(t, err)
+
+	// Files outside a repository have no HEAD commit.
+	otherPath := filepath.Join(t.TempDir(), "other.txt")
+	require.NoError(t, os.WriteFile(otherPath, []byte("abc"), 0644))
+	_, err = LoadGitHead(otherPath, "")
+	assert.Error(t, err)
+}
+
+func TestLoadGitBlame(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q")
+	path := filepath.Join(dir, "test.txt")
+	require.NoError(t, os.WriteFile(path, []byte("abc\ndef\n"), 0644))
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "-q", "-m", "first commit", "--date", "1600000000 +0000")
+	require.NoError(t, os.WriteFile(path, []byte("abc\nxyz\ndef\n"), 0644))
+	runGit(t, dir, "commit", "-q", "-a", "-m", "second commit", "--date", "1700000000 +0000")
+
+	// Lines changed in the working tree aren't committed.
+	require.NoError(t, os.WriteFile(path, []byte("abc\nxyz\ndef\nghi\n"), 0644))
+
+	lines, err := LoadGitBlame(context.Background(), path)
+	require.NoError(t, err)
+	require.Equal(t, 4, len(lines))
+
+	assert.Equal(t, "abc", lines[0].Text)
+	assert.Equal(t, "test", lines[0].Author)
+	assert.Equal(t, "first commit", lines[0].Summary)
+	assert.Equal(t, int64(1600000000), lines[0].AuthorTime.Unix())
+	assert.True(t, lines[0].IsCommitted())
+
+	assert.Equal(t, "xyz", lines[1].Text)
+	assert.Equal(t, "second commit", lines[1].Summary)
+	assert.Equal(t, int64(1700000000), lines[1].AuthorTime.Unix())
+
+	assert.Equal(t, "def", lines[2].Text)
+	assert.Equal(t, lines[0].CommitHash, lines[2].CommitHash)
+	assert.Equal(t, "first commit", lines[2].Summary)
+
+	assert.Equal(t, "ghi", lines[3].Text)
+	assert.False(t, lines[3].IsCommitted())
+
+	// Untracked files can't be blamed.
+	untrackedPath := filepath.Join(dir, "untracked.txt")
+	require.NoError(t, os.WriteFile(untrackedPath, []byte("abc"), 0644))
+	_, err = LoadGitBlame(context.Background(), untrackedPath)
+	assert.Error(t, err)
+}
+
+func TestParseGitBlamePorcelain(t *testing.T) {
+	testCases := []struct {
+		name          string
+		input         string
+		expectedLines []BlameLine
+		expectErr     bool
+	}{
+		{
+			name:          "empty",
+			input:         "",
+			expectedLines: nil,
+		},
+		{
+			name: "commit info only for first line",
+			input: "1111111111111111111111111111111111111111 1 1 2\n" +
+				"author Alice\n" +
+				"author-time 1600000000\n" +
+				"summary Add lines\n" +
+				"filename test.txt\n" +
+				"\tabc\r\n" +
+				"1111111111111111111111111111111111111111 2 2\n" +
+				"\t\tdef\n",
+			expectedLines: []BlameLine{
+				{CommitHash: "1111111111111111111111111111111111111111", Author: "Alice", AuthorTime: time.Unix(1600000000, 0), Summary: "Add lines", Text: "abc"},
+				{CommitHash: "1111111111111111111111111111111111111111", Author: "Alice", AuthorTime: time.Unix(1600000000, 0), Summary: "Add lines", Text: "\tdef"},
+			},
+		},
+		{
+			name:      "invalid header",
+			input:     "abc\n",
+			expectErr: true,
+		},
+		{
+			name:      "missing line contents",
+			input:     "1111111111111111111111111111111111111111 1 1 1\nauthor Alice\n",
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			lines, err := parseGitBlamePorcelain(strings.NewReader(tc.input))
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedLines, lines)
+		})
+	}
+}
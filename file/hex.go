@@ -0,0 +1,233 @@
+package file
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/aretext/aretext/text"
+)
+
+// HexBytesPerLine is the number of bytes displayed on each line of a hex dump.
+const HexBytesPerLine = 16
+
+// hexOffsetWidth is the number of columns for the offset at the start of each hex dump line.
+const hexOffsetWidth = 8
+
+// HexFormat is the format for a file loaded as a hex dump.
+var HexFormat = Format{
+	EndsWithNewline: true,
+	LineEnding:      LineEndingLF,
+	Encoding:        EncodingUTF8,
+	Hex:             true,
+}
+
+// HexByteCol returns the column of the first hex digit for the i-th byte in a hex dump line.
+//
+// Each line of the hex dump looks like this:
+//
+//	00000000  48 65 6c 6c 6f 2c 20 77  6f 72 6c 64 21 0a 00 ff  |Hello, world!...|
+//
+// The first column is the offset of the first byte in the line,
+// followed by the hex value of each byte, then the printable ASCII characters.
+func HexByteCol(i int) uint64 {
+	col := hexOffsetWidth + 2 + 3*i
+	if i >= HexBytesPerLine/2 {
+		col++ // extra space between the two groups of bytes.
+	}
+	return uint64(col)
+}
+
+// HexASCIICol returns the column of the ASCII character for the i-th byte in a hex dump line.
+func HexASCIICol(i int) uint64 {
+	return HexByteCol(HexBytesPerLine-1) + 5 + uint64(i)
+}
+
+// HexASCIIRune returns the character displayed in the ASCII column for a byte.
+// Bytes outside the printable ASCII range are displayed as '.'.
+func HexASCIIRune(b byte) rune {
+	if b >= 0x20 && b <= 0x7e {
+		return rune(b)
+	}
+	return '.'
+}
+
+// LoadHex reads a file from disk as a hex dump and starts a watcher to detect changes.
+// The hex dump is converted back to the original bytes when the file is saved with HexFormat.
+func LoadHex(path string, watcherPollInterval time.Duration) (*text.Tree, *Watcher, Format, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, nil, Format{}, errors.Wrap(err, "filepath.Abs")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, Format{}, errors.Wrap(err, "os.Open")
+	}
+	defer f.Close()
+
+	lastModifiedTime, size, err := lastModifiedTimeAndSize(f)
+	if err != nil {
+		return nil, nil, Format{}, errors.Wrap(err, "lastModifiedTime")
+	}
+
+	checksummer := NewChecksummer()
+	tree, err := text.NewTreeFromReader(newHexDumpReader(io.TeeReader(f, checksummer)))
+	if err != nil {
+		return nil, nil, Format{}, errors.Wrap(err, "text.NewTreeFromReader")
+	}
+
+	// Every line in the hex dump ends with a line feed, so remove it like the POSIX end-of-file indicator.
+	removePosixEof(tree)
+
+	watcher := NewWatcher(watcherPollInterval, path, lastModifiedTime, size, checksummer.Checksum())
+
+	return tree, watcher, HexFormat, nil
+}
+
+// hexDumpReader is a reader that converts bytes to a hex dump.
+type hexDumpReader struct {
+	r      io.Reader
+	offset int
+	buf    bytes.Buffer
+	err    error
+}
+
+func newHexDumpReader(r io.Reader) *hexDumpReader {
+	return &hexDumpReader{r: r}
+}
+
+// Read implements io.Reader.
+func (h *hexDumpReader) Read(p []byte) (int, error) {
+	for h.buf.Len() == 0 {
+		if h.err != nil {
+			return 0, h.err
+		}
+
+		var chunk [HexBytesPerLine]byte
+		n, err := io.ReadFull(h.r, chunk[:])
+		if n > 0 {
+			writeHexDumpLine(&h.buf, h.offset, chunk[:n])
+			h.offset += n
+		}
+
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			h.err = io.EOF
+		} else if err != nil {
+			h.err = err
+		}
+	}
+	return h.buf.Read(p)
+}
+
+func writeHexDumpLine(buf *bytes.Buffer, offset int, data []byte) {
+	fmt.Fprintf(buf, "%0*x  ", hexOffsetWidth, offset)
+	for i := 0; i < HexBytesPerLine; i++ {
+		if i == HexBytesPerLine/2 {
+			buf.WriteByte(' ')
+		}
+		if i < len(data) {
+			fmt.Fprintf(buf, "%02x ", data[i])
+		} else {
+			buf.WriteString("   ")
+		}
+	}
+	buf.WriteString(" |")
+	for _, b := range data {
+		buf.WriteRune(HexASCIIRune(b))
+	}
+	buf.WriteString("|\n")
+}
+
+// ParseHexDumpLine returns the bytes in a line of a hex dump and the column of each byte in the line.
+// The offset at the start of the line and the ASCII characters at the end are ignored,
+// so a line remains valid even if the user inserts or deletes bytes.
+func ParseHexDumpLine(line string) ([]byte, []uint64, error) {
+	// Skip the offset, which is everything before the first whitespace.
+	col := strings.IndexAny(line, " \t")
+	if col < 0 {
+		if strings.TrimSpace(line) == "" {
+			return nil, nil, nil
+		}
+		return nil, nil, errors.Errorf("missing hex bytes after offset %q", line)
+	}
+
+	// The ASCII column starts after the first "|", which can't appear in the hex bytes.
+	end := strings.IndexByte(line, '|')
+	if end < 0 {
+		end = len(line)
+	}
+
+	var data []byte
+	var cols []uint64
+	for col < end {
+		if line[col] == ' ' || line[col] == '\t' || line[col] == '\n' {
+			col++
+			continue
+		}
+
+		fieldEnd := col
+		for fieldEnd < end && line[fieldEnd] != ' ' && line[fieldEnd] != '\t' && line[fieldEnd] != '\n' {
+			fieldEnd++
+		}
+
+		field := line[col:fieldEnd]
+		b, err := strconv.ParseUint(field, 16, 8)
+		if err != nil || len(field) != 2 {
+			return nil, nil, errors.Errorf("invalid hex byte %q", field)
+		}
+		data = append(data, byte(b))
+		cols = append(cols, uint64(col))
+		col = fieldEnd
+	}
+
+	return data, cols, nil
+}
+
+// hexParseReader is a reader that converts a hex dump back to bytes.
+type hexParseReader struct {
+	r       *bufio.Reader
+	lineNum int
+	buf     bytes.Buffer
+	err     error
+}
+
+func newHexParseReader(r io.Reader) *hexParseReader {
+	return &hexParseReader{r: bufio.NewReader(r)}
+}
+
+// Read implements io.Reader.
+func (h *hexParseReader) Read(p []byte) (int, error) {
+	for h.buf.Len() == 0 {
+		if h.err != nil {
+			return 0, h.err
+		}
+
+		line, err := h.r.ReadString('\n')
+		if err != nil && err != io.EOF {
+			h.err = err
+			continue
+		}
+
+		h.lineNum++
+		data, _, parseErr := ParseHexDumpLine(line)
+		if parseErr != nil {
+			h.err = errors.Wrapf(parseErr, "line %d", h.lineNum)
+			continue
+		}
+		h.buf.Write(data)
+
+		if err == io.EOF {
+			h.err = io.EOF
+		}
+	}
+	return h.buf.Read(p)
+}
@@ -0,0 +1,211 @@
+package file
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/text"
+)
+
+func TestLoadHex(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		fileContents         string
+		expectedTreeContents string
+	}{
+		{
+			name:                 "empty",
+			fileContents:         "",
+			expectedTreeContents: "",
+		},
+		{
+			name:                 "partial line",
+			fileContents:         "Hi\x00\xff",
+			expectedTreeContents: "00000000  48 69 00 ff                                       |Hi..|",
+		},
+		{
+			name:                 "full line",
+			fileContents:         "Hello, world!\n\x00\xff",
+			expectedTreeContents: "00000000  48 65 6c 6c 6f 2c 20 77  6f 72 6c 64 21 0a 00 ff  |Hello, world!...|",
+		},
+		{
+			name:         "multiple lines",
+			fileContents: "0123456789abcdefXYZ",
+			expectedTreeContents: "00000000  30 31 32 33 34 35 36 37  38 39 61 62 63 64 65 66  |0123456789abcdef|\n" +
+				"00000010  58 59 5a                                          |XYZ|",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			filePath := createTestFile(t, tc.fileContents)
+
+			tree, watcher, format, err := LoadHex(filePath, time.Second)
+			require.NoError(t, err)
+			defer watcher.Stop()
+
+			assert.Equal(t, tc.expectedTreeContents, tree.String())
+			assert.Equal(t, HexFormat, format)
+
+			changed, err := watcher.CheckFileContentsChanged()
+			require.NoError(t, err)
+			assert.False(t, changed)
+		})
+	}
+}
+
+func TestHexLayout(t *testing.T) {
+	line := "00000000  48 65 6c 6c 6f 2c 20 77  6f 72 6c 64 21 0a 00 ff  |Hello, world!...|"
+	assert.Equal(t, "48", line[HexByteCol(0):HexByteCol(0)+2])
+	assert.Equal(t, "6f", line[HexByteCol(8):HexByteCol(8)+2])
+	assert.Equal(t, "ff", line[HexByteCol(15):HexByteCol(15)+2])
+	assert.Equal(t, byte('H'), line[HexASCIICol(0)])
+	assert.Equal(t, byte('.'), line[HexASCIICol(15)])
+}
+
+func TestParseHexDumpLine(t *testing.T) {
+	testCases := []struct {
+		name         string
+		line         string
+		expectedData []byte
+		expectedCols []uint64
+		expectErr    bool
+	}{
+		{
+			name: "empty",
+			line: "",
+		},
+		{
+			name: "whitespace only",
+			line: "  \n",
+		},
+		{
+			name:         "bytes with ASCII column",
+			line:         "00000000  48 69 00 ff                                       |Hi..|\n",
+			expectedData: []byte{0x48, 0x69, 0x00, 0xff},
+			expectedCols: []uint64{10, 13, 16, 19},
+		},
+		{
+			name:         "ASCII column contains separator",
+			line:         "00000000  7c 7c  |||\n",
+			expectedData: []byte{0x7c, 0x7c},
+			expectedCols: []uint64{10, 13},
+		},
+		{
+			name:         "inserted bytes without ASCII column",
+			line:         "00000000 ab cd EF",
+			expectedData: []byte{0xab, 0xcd, 0xef},
+			expectedCols: []uint64{9, 12, 15},
+		},
+		{
+			name:      "invalid hex digit",
+			line:      "00000000  4g  |.|",
+			expectErr: true,
+		},
+		{
+			name:      "too many digits",
+			line:      "00000000  486  |.|",
+			expectErr: true,
+		},
+		{
+			name:      "offset without bytes",
+			line:      "00000000",
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, cols, err := ParseHexDumpLine(tc.line)
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedData, data)
+			assert.Equal(t, tc.expectedCols, cols)
+		})
+	}
+}
+
+func TestSaveHex(t *testing.T) {
+	testCases := []struct {
+		name             string
+		treeContents     string
+		expectedContents string
+		expectErr        bool
+	}{
+		{
+			name:             "empty",
+			treeContents:     "",
+			expectedContents: "",
+		},
+		{
+			name: "multiple lines",
+			treeContents: "00000000  30 31 32 33 34 35 36 37  38 39 61 62 63 64 65 66  |0123456789abcdef|\n" +
+				"00000010  58 59 5a                                          |XYZ|",
+			expectedContents: "0123456789abcdefXYZ",
+		},
+		{
+			name:             "edited bytes with stale ASCII column",
+			treeContents:     "00000000  00 ff 0d 0a                                       |Hi..|",
+			expectedContents: "\x00\xff\r\n",
+		},
+		{
+			name:         "invalid byte",
+			treeContents: "00000000  00 zz  |..|",
+			expectErr:    true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			path := path.Join(tmpDir, "test.bin")
+
+			tree, err := text.NewTreeFromString(tc.treeContents)
+			require.NoError(t, err)
+
+			watcher, err := Save(path, tree, HexFormat, testWatcherPollInterval)
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			defer watcher.Stop()
+
+			fileBytes, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedContents, string(fileBytes))
+
+			changed, err := watcher.CheckFileContentsChanged()
+			require.NoError(t, err)
+			assert.False(t, changed)
+		})
+	}
+}
+
+func TestLoadAndSaveHexRoundTrip(t *testing.T) {
+	var contents []byte
+	for i := 0; i < 1000; i++ {
+		contents = append(contents, byte(i*7))
+	}
+	filePath := createTestFile(t, string(contents))
+
+	tree, watcher, format, err := LoadHex(filePath, time.Second)
+	require.NoError(t, err)
+	watcher.Stop()
+
+	watcher, err = Save(filePath, tree, format, testWatcherPollInterval)
+	require.NoError(t, err)
+	defer watcher.Stop()
+
+	fileBytes, err := os.ReadFile(filePath)
+	require.NoError(t, err)
+	assert.Equal(t, contents, fileBytes)
+}
@@ -0,0 +1,135 @@
+package file
+
+import (
+	"bufio"
+	"io"
+)
+
+// LineEnding is the sequence of characters used to end a line in a file.
+type LineEnding string
+
+const (
+	LineEndingLF   = LineEnding("lf")   // Line feed, used by POSIX systems.
+	LineEndingCRLF = LineEnding("crlf") // Carriage return followed by line feed, used by Windows.
+)
+
+// String returns a display name for the line ending.
+func (le LineEnding) String() string {
+	switch le {
+	case LineEndingLF:
+		return "LF"
+	case LineEndingCRLF:
+		return "CRLF"
+	default:
+		panic("invalid line ending")
+	}
+}
+
+// lineEndingNormalizer is a reader that replaces CRLF line endings with LF.
+// It counts the line endings of each type so we can detect the dominant line ending in the file.
+type lineEndingNormalizer struct {
+	r        *bufio.Reader
+	numLF    int
+	numCRLF  int
+	finalErr error
+}
+
+func newLineEndingNormalizer(r io.Reader) *lineEndingNormalizer {
+	return &lineEndingNormalizer{r: bufio.NewReader(r)}
+}
+
+// Read implements io.Reader.
+func (n *lineEndingNormalizer) Read(p []byte) (int, error) {
+	if n.finalErr != nil {
+		return 0, n.finalErr
+	}
+
+	var i int
+	for i < len(p) {
+		b, err := n.r.ReadByte()
+		if err != nil {
+			n.finalErr = err
+			if i > 0 {
+				// Return the bytes we've already read, then the error on the next call.
+				return i, nil
+			}
+			return 0, err
+		}
+
+		if b == '\r' {
+			if next, err := n.r.Peek(1); err == nil && next[0] == '\n' {
+				n.r.ReadByte() // Consume the line feed.
+				n.numCRLF++
+				b = '\n'
+			}
+		} else if b == '\n' {
+			n.numLF++
+		}
+
+		p[i] = b
+		i++
+	}
+
+	return i, nil
+}
+
+// LineEnding returns the dominant line ending in the text read so far.
+// If there are no line endings, this defaults to LF.
+func (n *lineEndingNormalizer) LineEnding() LineEnding {
+	if n.numCRLF > n.numLF {
+		return LineEndingCRLF
+	}
+	return LineEndingLF
+}
+
+// MixedLineEndings returns whether the text read so far contains both LF and CRLF line endings.
+func (n *lineEndingNormalizer) MixedLineEndings() bool {
+	return n.numLF > 0 && n.numCRLF > 0
+}
+
+// crlfReader is a reader that replaces LF line endings with CRLF.
+type crlfReader struct {
+	r         *bufio.Reader
+	pendingLF bool
+	finalErr  error
+}
+
+func newCRLFReader(r io.Reader) *crlfReader {
+	return &crlfReader{r: bufio.NewReader(r)}
+}
+
+// Read implements io.Reader.
+func (c *crlfReader) Read(p []byte) (int, error) {
+	var i int
+	for i < len(p) {
+		if c.pendingLF {
+			p[i] = '\n'
+			i++
+			c.pendingLF = false
+			continue
+		}
+
+		if c.finalErr != nil {
+			break
+		}
+
+		b, err := c.r.ReadByte()
+		if err != nil {
+			c.finalErr = err
+			break
+		}
+
+		if b == '\n' {
+			b = '\r'
+			c.pendingLF = true
+		}
+
+		p[i] = b
+		i++
+	}
+
+	if i == 0 && c.finalErr != nil {
+		return 0, c.finalErr
+	}
+	return i, nil
+}
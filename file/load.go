@@ -1,6 +1,7 @@
 package file
 
 import (
+	"bufio"
 	"io"
 	"os"
 	"path/filepath"
@@ -12,46 +13,92 @@ import (
 )
 
 // Load reads a file from disk and starts a watcher to detect changes.
-// This will remove the POSIX end-of-file indicator (line feed at end of file).
-func Load(path string, watcherPollInterval time.Duration) (*text.Tree, *Watcher, error) {
+// This will remove the POSIX end-of-file indicator (line feed at end of file),
+// CRLF line endings are normalized to LF, and the returned format records
+// the original line ending and whether the file ended with a line feed.
+//
+// Gzip-compressed files are detected from the magic bytes at the start of the file and decompressed.
+// The encoding is detected from the byte order mark, if present; otherwise, the file
+// is decoded as UTF-8. If the file is not valid UTF-8 and fallbackEncoding is not empty,
+// the file is decoded using fallbackEncoding instead.
+func Load(path string, fallbackEncoding Encoding, watcherPollInterval time.Duration) (*text.Tree, *Watcher, Format, error) {
 	path, err := filepath.Abs(path)
 	if err != nil {
-		return nil, nil, errors.Wrap(err, "filepath.Abs")
+		return nil, nil, Format{}, errors.Wrap(err, "filepath.Abs")
 	}
 
 	f, err := os.Open(path)
 	if err != nil {
-		return nil, nil, errors.Wrap(err, "os.Open")
+		return nil, nil, Format{}, errors.Wrap(err, "os.Open")
 	}
 	defer f.Close()
 
 	lastModifiedTime, size, err := lastModifiedTimeAndSize(f)
 	if err != nil {
-		return nil, nil, errors.Wrap(err, "lastModifiedTime")
+		return nil, nil, Format{}, errors.Wrap(err, "lastModifiedTime")
 	}
 
-	tree, checksum, err := readContentsAndChecksum(f)
+	tree, checksum, format, err := readContentsAndChecksum(f, "")
+	if errors.Cause(err) == text.InvalidUtf8Error && !format.BOM && fallbackEncoding != "" {
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return nil, nil, Format{}, errors.Wrap(err, "f.Seek")
+		}
+		tree, checksum, format, err = readContentsAndChecksum(f, fallbackEncoding)
+	}
 	if err != nil {
-		return nil, nil, errors.Wrap(err, "readContentsAndChecksum")
+		return nil, nil, Format{}, errors.Wrap(err, "readContentsAndChecksum")
 	}
 
 	// POSIX files end with a single line feed to indicate the end of the file.
 	// We remove it from the tree to simplify editor operations; we'll add it back when saving the file.
-	removePosixEof(tree)
+	// An empty file has no final line without a line feed, so treat it like a new file.
+	if tree.NumChars() > 0 {
+		format.EndsWithNewline = removePosixEof(tree)
+	}
 
 	watcher := NewWatcher(watcherPollInterval, path, lastModifiedTime, size, checksum)
 
-	return tree, watcher, nil
+	return tree, watcher, format, nil
 }
 
-func readContentsAndChecksum(f *os.File) (*text.Tree, string, error) {
+// readContentsAndChecksum decodes the file contents into a text tree.
+// If encoding is empty, the encoding is detected from the byte order mark, defaulting to UTF-8.
+// The returned format is valid even on error, so the caller can decide whether to retry with another encoding.
+func readContentsAndChecksum(f io.Reader, encoding Encoding) (*text.Tree, string, Format, error) {
+	// The checksum is calculated from the file contents before decoding and normalizing line endings,
+	// so the watcher can compare it to the checksum of the file on disk.
+	// For compressed files, this is the checksum of the compressed bytes.
 	checksummer := NewChecksummer()
-	r := io.TeeReader(f, checksummer)
-	tree, err := text.NewTreeFromReader(r)
+	fileReader := bufio.NewReader(io.TeeReader(f, checksummer))
+
+	format := DefaultFormat
+	format.Compression = detectCompression(fileReader)
+	decompressedReader, err := newDecompressingReader(fileReader, format.Compression)
 	if err != nil {
-		return nil, "", errors.Wrap(err, "text.NewTreeFromReader")
+		return nil, "", format, errors.Wrap(err, "newDecompressingReader")
 	}
-	return tree, checksummer.Checksum(), nil
+
+	r := bufio.NewReader(decompressedReader)
+	if encoding == "" {
+		format.Encoding, format.BOM = detectBOM(r)
+	} else {
+		format.Encoding = encoding
+	}
+
+	normalizer := newLineEndingNormalizer(newDecodingReader(r, format.Encoding))
+	tree, err := text.NewTreeFromReader(normalizer)
+	if err != nil {
+		return nil, "", format, errors.Wrap(err, "text.NewTreeFromReader")
+	}
+
+	// Read any bytes after the end of the compressed data, so they're included in the checksum.
+	if _, err := io.Copy(io.Discard, fileReader); err != nil {
+		return nil, "", format, errors.Wrap(err, "io.Copy")
+	}
+
+	format.LineEnding = normalizer.LineEnding()
+	format.MixedLineEndings = normalizer.MixedLineEndings()
+	return tree, checksummer.Checksum(), format, nil
 }
 
 func lastModifiedTimeAndSize(f *os.File) (time.Time, int64, error) {
@@ -63,11 +110,13 @@ func lastModifiedTimeAndSize(f *os.File) (time.Time, int64, error) {
 	return fileInfo.ModTime(), fileInfo.Size(), nil
 }
 
-func removePosixEof(tree *text.Tree) {
+func removePosixEof(tree *text.Tree) bool {
 	if endsWithLineFeed(tree) {
 		lastPos := tree.NumChars() - 1
 		tree.DeleteAtPosition(lastPos)
+		return true
 	}
+	return false
 }
 
 func endsWithLineFeed(tree *text.Tree) bool {
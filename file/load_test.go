@@ -13,21 +13,61 @@ func TestLoad(t *testing.T) {
 		name                 string
 		fileContents         string
 		expectedTreeContents string
+		expectedFormat       Format
 	}{
 		{
 			name:                 "empty",
 			fileContents:         "",
 			expectedTreeContents: "",
+			expectedFormat:       Format{EndsWithNewline: true, LineEnding: LineEndingLF, Encoding: EncodingUTF8},
 		},
 		{
 			name:                 "ends with character, no POSIX eof",
 			fileContents:         "ab\ncd",
 			expectedTreeContents: "ab\ncd",
+			expectedFormat:       Format{EndsWithNewline: false, LineEnding: LineEndingLF, Encoding: EncodingUTF8},
 		},
 		{
 			name:                 "POSIX eof",
 			fileContents:         "abcd\n",
 			expectedTreeContents: "abcd",
+			expectedFormat:       Format{EndsWithNewline: true, LineEnding: LineEndingLF, Encoding: EncodingUTF8},
+		},
+		{
+			name:                 "multiple line feeds at end",
+			fileContents:         "abcd\n\n",
+			expectedTreeContents: "abcd\n",
+			expectedFormat:       Format{EndsWithNewline: true, LineEnding: LineEndingLF, Encoding: EncodingUTF8},
+		},
+		{
+			name:                 "CRLF line endings",
+			fileContents:         "ab\r\ncd\r\n",
+			expectedTreeContents: "ab\ncd",
+			expectedFormat:       Format{EndsWithNewline: true, LineEnding: LineEndingCRLF, Encoding: EncodingUTF8},
+		},
+		{
+			name:                 "CRLF line endings, no POSIX eof",
+			fileContents:         "ab\r\ncd",
+			expectedTreeContents: "ab\ncd",
+			expectedFormat:       Format{EndsWithNewline: false, LineEnding: LineEndingCRLF, Encoding: EncodingUTF8},
+		},
+		{
+			name:                 "carriage return without line feed",
+			fileContents:         "ab\rcd\r",
+			expectedTreeContents: "ab\rcd\r",
+			expectedFormat:       Format{EndsWithNewline: false, LineEnding: LineEndingLF, Encoding: EncodingUTF8},
+		},
+		{
+			name:                 "mixed line endings, mostly CRLF",
+			fileContents:         "ab\r\ncd\nef\r\n",
+			expectedTreeContents: "ab\ncd\nef",
+			expectedFormat:       Format{EndsWithNewline: true, LineEnding: LineEndingCRLF, MixedLineEndings: true, Encoding: EncodingUTF8},
+		},
+		{
+			name:                 "mixed line endings, mostly LF",
+			fileContents:         "ab\ncd\r\nef\n",
+			expectedTreeContents: "ab\ncd\nef",
+			expectedFormat:       Format{EndsWithNewline: true, LineEnding: LineEndingLF, MixedLineEndings: true, Encoding: EncodingUTF8},
 		},
 	}
 
@@ -35,11 +75,12 @@ func TestLoad(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			filePath := createTestFile(t, tc.fileContents)
 
-			tree, watcher, err := Load(filePath, time.Second)
+			tree, watcher, format, err := Load(filePath, "", time.Second)
 			require.NoError(t, err)
 			defer watcher.Stop()
 
 			assert.Equal(t, tc.expectedTreeContents, tree.String())
+			assert.Equal(t, tc.expectedFormat, format)
 		})
 	}
 }
@@ -0,0 +1,185 @@
+package file
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// DefaultPageSize is the maximum number of bytes in each page of a large file.
+const DefaultPageSize = 4 << 20 // 4 MiB
+
+// Pager reads a large file incrementally, one page at a time.
+// Each page ends at a line boundary if possible, CRLF line endings are normalized to LF,
+// and invalid UTF-8 is replaced by the Unicode replacement character.
+type Pager struct {
+	path     string
+	f        *os.File
+	size     int64
+	offset   int64
+	pageSize int64
+	numLF    int
+	numCRLF  int
+
+	// remaining normalizes line endings for the reader returned by RemainingReader.
+	remaining *lineEndingNormalizer
+}
+
+// OpenPager opens a file to read in pages.
+func OpenPager(path string, pageSize int64) (*Pager, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "filepath.Abs")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "os.Open")
+	}
+
+	fileInfo, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, errors.Wrap(err, "f.Stat")
+	}
+
+	return &Pager{path: path, f: f, size: fileInfo.Size(), pageSize: pageSize}, nil
+}
+
+// Close closes the file.
+func (p *Pager) Close() error {
+	return p.f.Close()
+}
+
+// Path returns the absolute path to the file.
+func (p *Pager) Path() string {
+	return p.path
+}
+
+// Size returns the size of the file in bytes when it was opened.
+func (p *Pager) Size() int64 {
+	return p.size
+}
+
+// Offset returns the number of bytes read from the file so far.
+func (p *Pager) Offset() int64 {
+	return p.offset
+}
+
+// Done returns whether every page has been read.
+func (p *Pager) Done() bool {
+	return p.offset >= p.size
+}
+
+// LineEnding returns the dominant line ending in the pages read so far.
+func (p *Pager) LineEnding() LineEnding {
+	if p.numCRLF > p.numLF {
+		return LineEndingCRLF
+	}
+	return LineEndingLF
+}
+
+// MixedLineEndings returns whether the pages read so far contain both LF and CRLF line endings.
+func (p *Pager) MixedLineEndings() bool {
+	return p.numLF > 0 && p.numCRLF > 0
+}
+
+// NextPage reads the next page of the file.
+// It returns io.EOF once every page has been read.
+func (p *Pager) NextPage() (string, error) {
+	if p.Done() {
+		return "", io.EOF
+	}
+
+	n := p.pageSize
+	if remaining := p.size - p.offset; n > remaining {
+		n = remaining
+	}
+
+	buf := make([]byte, n)
+	if _, err := p.f.ReadAt(buf, p.offset); err != nil && err != io.EOF {
+		return "", errors.Wrap(err, "f.ReadAt")
+	}
+
+	if p.offset+n < p.size {
+		// End the page after the last line feed, so CRLF line endings and
+		// UTF-8 sequences aren't split across pages.
+		if i := bytes.LastIndexByte(buf, '\n'); i >= 0 {
+			buf = buf[:i+1]
+		} else {
+			// The page is a single long line, so end it at the last complete UTF-8 sequence.
+			// Also avoid ending the page with a carriage return that could start a CRLF.
+			buf = trimIncompleteRune(buf)
+			if len(buf) > 1 && buf[len(buf)-1] == '\r' {
+				buf = buf[:len(buf)-1]
+			}
+		}
+	}
+
+	p.offset += int64(len(buf))
+	return p.decodePage(buf), nil
+}
+
+// RemainingReader returns a reader for every page that hasn't been read yet.
+// The reader fails once the context is cancelled.
+// This does not update the pager; once the reader is consumed, call SkipToEnd.
+func (p *Pager) RemainingReader(ctx context.Context) io.Reader {
+	r := io.NewSectionReader(p.f, p.offset, p.size-p.offset)
+	p.remaining = newLineEndingNormalizer(transform.NewReader(&contextReader{ctx, r}, unicode.UTF8.NewDecoder()))
+	return p.remaining
+}
+
+// SkipToEnd marks every page as read, including line endings read from RemainingReader.
+func (p *Pager) SkipToEnd() {
+	p.offset = p.size
+	if p.remaining != nil {
+		p.numLF += p.remaining.numLF
+		p.numCRLF += p.remaining.numCRLF
+		p.remaining = nil
+	}
+}
+
+func (p *Pager) decodePage(buf []byte) string {
+	numCRLF := bytes.Count(buf, []byte("\r\n"))
+	p.numCRLF += numCRLF
+	p.numLF += bytes.Count(buf, []byte("\n")) - numCRLF
+	buf = bytes.ReplaceAll(buf, []byte("\r\n"), []byte("\n"))
+	s, _, err := transform.Bytes(unicode.UTF8.NewDecoder(), buf)
+	if err != nil {
+		panic(err) // should never happen because the decoder replaces invalid UTF-8.
+	}
+	return string(s)
+}
+
+func trimIncompleteRune(buf []byte) []byte {
+	for i := len(buf) - 1; i >= 0 && i >= len(buf)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(buf[i]) {
+			if !utf8.FullRune(buf[i:]) && i > 0 {
+				return buf[:i]
+			}
+			break
+		}
+	}
+	return buf
+}
+
+// contextReader is a reader that fails once the context is cancelled.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+// Read implements io.Reader.
+func (c *contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
@@ -0,0 +1,134 @@
+package file
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPagerNextPage(t *testing.T) {
+	testCases := []struct {
+		name               string
+		fileContents       string
+		pageSize           int64
+		expectedPages      []string
+		expectedLineEnding LineEnding
+	}{
+		{
+			name:          "empty",
+			fileContents:  "",
+			pageSize:      4,
+			expectedPages: nil,
+		},
+		{
+			name:               "smaller than page",
+			fileContents:       "ab\ncd",
+			pageSize:           16,
+			expectedPages:      []string{"ab\ncd"},
+			expectedLineEnding: LineEndingLF,
+		},
+		{
+			name:               "pages end at line boundaries",
+			fileContents:       "ab\ncd\nef\ngh",
+			pageSize:           7,
+			expectedPages:      []string{"ab\ncd\n", "ef\ngh"},
+			expectedLineEnding: LineEndingLF,
+		},
+		{
+			name:               "line longer than page",
+			fileContents:       "abcdefgh\nij",
+			pageSize:           3,
+			expectedPages:      []string{"abc", "def", "gh\n", "ij"},
+			expectedLineEnding: LineEndingLF,
+		},
+		{
+			name:               "long line with multi-byte characters",
+			fileContents:       "a世界",
+			pageSize:           3,
+			expectedPages:      []string{"a", "世", "界"},
+			expectedLineEnding: LineEndingLF,
+		},
+		{
+			name:               "CRLF line endings",
+			fileContents:       "ab\r\ncd\r\nef\n",
+			pageSize:           5,
+			expectedPages:      []string{"ab\n", "cd\n", "ef\n"},
+			expectedLineEnding: LineEndingCRLF,
+		},
+		{
+			name:               "long line with CRLF at page boundary",
+			fileContents:       "abc\r\nd",
+			pageSize:           4,
+			expectedPages:      []string{"abc", "\nd"},
+			expectedLineEnding: LineEndingCRLF,
+		},
+		{
+			name:               "invalid UTF-8",
+			fileContents:       "a\xffb\n",
+			pageSize:           16,
+			expectedPages:      []string{"a�b\n"},
+			expectedLineEnding: LineEndingLF,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			filePath := createTestFile(t, tc.fileContents)
+			pager, err := OpenPager(filePath, tc.pageSize)
+			require.NoError(t, err)
+			defer pager.Close()
+
+			var pages []string
+			for !pager.Done() {
+				page, err := pager.NextPage()
+				require.NoError(t, err)
+				pages = append(pages, page)
+			}
+			assert.Equal(t, tc.expectedPages, pages)
+			assert.Equal(t, int64(len(tc.fileContents)), pager.Offset())
+
+			_, err = pager.NextPage()
+			assert.ErrorIs(t, err, io.EOF)
+
+			if tc.expectedLineEnding != "" {
+				assert.Equal(t, tc.expectedLineEnding, pager.LineEnding())
+			}
+		})
+	}
+}
+
+func TestPagerRemainingReader(t *testing.T) {
+	filePath := createTestFile(t, "ab\ncd\r\nef\r\ngh")
+	pager, err := OpenPager(filePath, 4)
+	require.NoError(t, err)
+	defer pager.Close()
+
+	page, err := pager.NextPage()
+	require.NoError(t, err)
+	assert.Equal(t, "ab\n", page)
+
+	remaining, err := io.ReadAll(pager.RemainingReader(context.Background()))
+	require.NoError(t, err)
+	assert.Equal(t, "cd\nef\ngh", string(remaining))
+	assert.False(t, pager.Done())
+
+	pager.SkipToEnd()
+	assert.True(t, pager.Done())
+	assert.Equal(t, LineEndingCRLF, pager.LineEnding())
+	assert.True(t, pager.MixedLineEndings())
+}
+
+func TestPagerRemainingReaderCancelled(t *testing.T) {
+	filePath := createTestFile(t, "ab\ncd\n")
+	pager, err := OpenPager(filePath, 4)
+	require.NoError(t, err)
+	defer pager.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = io.ReadAll(pager.RemainingReader(ctx))
+	assert.ErrorIs(t, err, context.Canceled)
+}
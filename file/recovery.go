@@ -0,0 +1,148 @@
+package file
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/aretext/aretext/text"
+)
+
+// RecoveryStore saves snapshots of documents with unsaved changes,
+// so the changes can be recovered if the editor exits unexpectedly.
+//
+// Each snapshot consists of two files named by a hash of the document path:
+// a text file with the contents of the document, and a JSON file with metadata.
+// Keeping the contents in a plain text file allows external tools like diff to read it.
+type RecoveryStore struct {
+	dir string
+}
+
+// RecoverySnapshot describes the contents of a document saved for recovery.
+type RecoverySnapshot struct {
+	// Path is the path of the document.
+	Path string
+
+	// Time is when the snapshot was saved.
+	Time time.Time
+
+	// TextPath is the path of the file containing the document contents.
+	TextPath string
+}
+
+// NewRecoveryStore returns a store that saves snapshots in the specified directory.
+// The directory is created when the first snapshot is saved.
+func NewRecoveryStore(dir string) *RecoveryStore {
+	return &RecoveryStore{dir: dir}
+}
+
+// DefaultRecoveryDir returns the directory for recovery snapshots in the user's cache directory.
+func DefaultRecoveryDir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", errors.Wrap(err, "os.UserCacheDir")
+	}
+	return filepath.Join(cacheDir, "aretext", "recovery"), nil
+}
+
+// Save writes a snapshot of the document at path, replacing any previous snapshot.
+// The contents are written with line feeds and UTF-8 encoding, regardless of the document's file format.
+func (s *RecoveryStore) Save(path string, tree *text.Tree) error {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return errors.Wrap(err, "os.MkdirAll")
+	}
+
+	textPath, metaPath := s.snapshotPaths(path)
+	treeReader := tree.ReaderAtPosition(0)
+	if err := writeFileAtomic(s.dir, textPath, &treeReader); err != nil {
+		return err
+	}
+
+	meta, err := json.Marshal(RecoverySnapshot{Path: path, Time: time.Now()})
+	if err != nil {
+		return errors.Wrap(err, "json.Marshal")
+	}
+
+	// Write the metadata last, since the snapshot is found through its metadata.
+	return writeFileAtomic(s.dir, metaPath, bytes.NewReader(meta))
+}
+
+// Load returns the snapshot for the document at path, if one exists.
+// If there is no snapshot, it returns nil.
+func (s *RecoveryStore) Load(path string) (*RecoverySnapshot, error) {
+	textPath, metaPath := s.snapshotPaths(path)
+	meta, err := os.ReadFile(metaPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "os.ReadFile")
+	}
+
+	var snapshot RecoverySnapshot
+	if err := json.Unmarshal(meta, &snapshot); err != nil {
+		return nil, errors.Wrap(err, "json.Unmarshal")
+	}
+
+	if snapshot.Path != path {
+		return nil, nil
+	}
+
+	if _, err := os.Stat(textPath); err != nil {
+		return nil, errors.Wrap(err, "os.Stat")
+	}
+
+	snapshot.TextPath = textPath
+	return &snapshot, nil
+}
+
+// Remove deletes the snapshot for the document at path, if one exists.
+func (s *RecoveryStore) Remove(path string) error {
+	textPath, metaPath := s.snapshotPaths(path)
+	for _, p := range []string{metaPath, textPath} {
+		if err := os.Remove(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return errors.Wrap(err, "os.Remove")
+		}
+	}
+	return nil
+}
+
+// snapshotPaths returns the paths of the files for a document's snapshot.
+// The names are a hash of the document path, so they are unique and safe to use in any filesystem.
+func (s *RecoveryStore) snapshotPaths(path string) (textPath string, metaPath string) {
+	hash := sha256.Sum256([]byte(path))
+	name := hex.EncodeToString(hash[:])
+	return filepath.Join(s.dir, name+".txt"), filepath.Join(s.dir, name+".json")
+}
+
+// writeFileAtomic writes to a temporary file in dir, then renames it to path.
+// This ensures that a crash can't leave a partially written file.
+func writeFileAtomic(dir string, path string, r io.Reader) error {
+	f, err := os.CreateTemp(dir, "tmp-*")
+	if err != nil {
+		return errors.Wrap(err, "os.CreateTemp")
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return errors.Wrap(err, "io.Copy")
+	}
+
+	if err := f.Close(); err != nil {
+		return errors.Wrap(err, "f.Close")
+	}
+
+	if err := os.Rename(f.Name(), path); err != nil {
+		return errors.Wrap(err, "os.Rename")
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/text"
+)
+
+func TestRecoveryStoreSaveLoadRemove(t *testing.T) {
+	store := NewRecoveryStore(filepath.Join(t.TempDir(), "recovery"))
+
+	// No snapshot yet.
+	snapshot, err := store.Load("/test/file.txt")
+	require.NoError(t, err)
+	assert.Nil(t, snapshot)
+
+	tree, err := text.NewTreeFromString("abc\ndef")
+	require.NoError(t, err)
+	err = store.Save("/test/file.txt", tree)
+	require.NoError(t, err)
+
+	snapshot, err = store.Load("/test/file.txt")
+	require.NoError(t, err)
+	require.NotNil(t, snapshot)
+	assert.Equal(t, "/test/file.txt", snapshot.Path)
+	assert.False(t, snapshot.Time.IsZero())
+	data, err := os.ReadFile(snapshot.TextPath)
+	require.NoError(t, err)
+	assert.Equal(t, "abc\ndef", string(data))
+
+	// Snapshots for other paths are independent.
+	snapshot, err = store.Load("/test/other.txt")
+	require.NoError(t, err)
+	assert.Nil(t, snapshot)
+
+	err = store.Remove("/test/file.txt")
+	require.NoError(t, err)
+	snapshot, err = store.Load("/test/file.txt")
+	require.NoError(t, err)
+	assert.Nil(t, snapshot)
+
+	// Removing a snapshot that doesn't exist is not an error.
+	err = store.Remove("/test/file.txt")
+	assert.NoError(t, err)
+}
@@ -13,8 +13,11 @@ import (
 )
 
 // Save writes the text to disk and starts a new watcher to detect subsequent changes.
-// This adds the POSIX end-of-file indicator (line feed at the end of the file).
-func Save(path string, tree *text.Tree, watcherPollInterval time.Duration) (*Watcher, error) {
+// If the format ends with a newline, this adds the POSIX end-of-file indicator (line feed at the end of the file).
+// The text is converted to the line ending and encoding of the format,
+// or from a hex dump back to bytes if the format is hex.
+// If the format is compressed, the file is compressed after encoding.
+func Save(path string, tree *text.Tree, format Format, watcherPollInterval time.Duration) (*Watcher, error) {
 	// Use renameio to write the file to a temporary directory, then rename it to the target file.
 	// This should reduce the risk of data corruption if the editor crashes mid-write,
 	// but is probably not 100% reliable (see http://danluu.com/deconstruct-files/).
@@ -26,18 +29,37 @@ func Save(path string, tree *text.Tree, watcherPollInterval time.Duration) (*Wat
 	}
 	defer pf.Cleanup()
 
-	// Compose a reader that calculates the checksum and appends the POSIX EOF indicator.
+	// Compose a reader that appends the POSIX EOF indicator,
+	// converts line feeds to the line ending for the file format, and encodes the text.
 	checksummer := NewChecksummer()
 	textReader := tree.ReaderAtPosition(0)
-	posixEofReader := strings.NewReader("\n")
-	r := io.TeeReader(io.MultiReader(&textReader, posixEofReader), checksummer)
+	var posixEofReader io.Reader = strings.NewReader("")
+	if format.EndsWithNewline {
+		posixEofReader = strings.NewReader("\n")
+	}
+	var contentReader io.Reader = io.MultiReader(&textReader, posixEofReader)
+	if format.Hex {
+		contentReader = newHexParseReader(contentReader)
+	} else {
+		if format.LineEnding == LineEndingCRLF {
+			contentReader = newCRLFReader(contentReader)
+		}
+		contentReader = newEncodingReader(contentReader, format.Encoding, format.BOM)
+	}
 
 	// Write to the file and calculate the checksum.
-	_, err = io.Copy(pf, r)
+	// For compressed files, the checksum is calculated from the compressed bytes written to disk.
+	w := newCompressingWriter(io.MultiWriter(pf, checksummer), format.Compression)
+	_, err = io.Copy(w, contentReader)
 	if err != nil {
 		return nil, errors.Wrap(err, "io.Copy")
 	}
 
+	err = w.Close()
+	if err != nil {
+		return nil, errors.Wrap(err, "Close")
+	}
+
 	// Sync the file to disk so the watcher calculates the checksum correctly later.
 	err = pf.CloseAtomicallyReplace()
 	if err != nil {
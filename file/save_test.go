@@ -15,12 +15,41 @@ func TestSaveNewFile(t *testing.T) {
 	tmpDir := t.TempDir()
 
 	path := path.Join(tmpDir, "test.txt")
-	saveAndAssertContents(t, path, "abcd1234", 0644)
+	saveAndAssertContents(t, path, "abcd1234", true, 0644)
+}
+
+func TestSaveWithoutFinalNewline(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	path := path.Join(tmpDir, "test.txt")
+	saveAndAssertContents(t, path, "abcd1234", false, 0644)
+}
+
+func TestSaveCRLF(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := path.Join(tmpDir, "test.txt")
+
+	tree, err := text.NewTreeFromString("ab\ncd\n\nef")
+	require.NoError(t, err)
+
+	format := Format{EndsWithNewline: true, LineEnding: LineEndingCRLF}
+	watcher, err := Save(path, tree, format, testWatcherPollInterval)
+	require.NoError(t, err)
+	defer watcher.Stop()
+
+	fileBytes, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "ab\r\ncd\r\n\r\nef\r\n", string(fileBytes))
+
+	// The watcher checksum should match the file contents on disk.
+	changed, err := watcher.CheckFileContentsChanged()
+	require.NoError(t, err)
+	assert.False(t, changed)
 }
 
 func TestSaveModifyExistingFile(t *testing.T) {
 	path := createTestFile(t, "old contents")
-	saveAndAssertContents(t, path, "new contents", 0644)
+	saveAndAssertContents(t, path, "new contents", true, 0644)
 }
 
 func TestSaveModifyExistingFilePreservePermissions(t *testing.T) {
@@ -28,14 +57,14 @@ func TestSaveModifyExistingFilePreservePermissions(t *testing.T) {
 
 	err := os.Chmod(path, 0600)
 	require.NoError(t, err)
-	saveAndAssertContents(t, path, "new contents", 0600)
+	saveAndAssertContents(t, path, "new contents", true, 0600)
 }
 
-func saveAndAssertContents(t *testing.T, path string, contents string, perms os.FileMode) {
+func saveAndAssertContents(t *testing.T, path string, contents string, endsWithNewline bool, perms os.FileMode) {
 	tree, err := text.NewTreeFromString(contents)
 	require.NoError(t, err)
 
-	watcher, err := Save(path, tree, testWatcherPollInterval)
+	watcher, err := Save(path, tree, Format{EndsWithNewline: endsWithNewline, LineEnding: LineEndingLF}, testWatcherPollInterval)
 	require.NoError(t, err)
 	assert.Equal(t, path, watcher.Path())
 	defer watcher.Stop()
@@ -43,7 +72,10 @@ func saveAndAssertContents(t *testing.T, path string, contents string, perms os.
 	fileBytes, err := os.ReadFile(path)
 	require.NoError(t, err)
 
-	expectedContents := contents + "\n" // Append POSIX EOF
+	expectedContents := contents
+	if endsWithNewline {
+		expectedContents += "\n"
+	}
 	assert.Equal(t, expectedContents, string(fileBytes))
 
 	fileInfo, err := os.Stat(path)
@@ -18,6 +18,7 @@ type Watcher struct {
 	lastModified time.Time
 	size         int64
 	checksum     string
+	skipChecksum bool
 	changedChan  chan struct{}
 	quitChan     chan struct{}
 }
@@ -44,11 +45,23 @@ func NewEmptyWatcher() *Watcher {
 	return &Watcher{changedChan: make(chan struct{})}
 }
 
+// NewWatcherWithoutChecksum returns a watcher for a file that never checks whether the file changed.
+// This avoids reading the entire file to calculate a checksum, which would be slow for large files.
+func NewWatcherWithoutChecksum(path string) *Watcher {
+	return &Watcher{path: path, skipChecksum: true, changedChan: make(chan struct{})}
+}
+
 // Path returns the path to the file being watched.
 func (w *Watcher) Path() string {
 	return w.path
 }
 
+// Checksum returns the checksum of the file's contents when it was loaded or saved.
+// This is empty if the file did not exist or the watcher does not calculate checksums.
+func (w *Watcher) Checksum() string {
+	return w.checksum
+}
+
 // Stop stops the watcher from checking for changes.
 func (w *Watcher) Stop() {
 	if w.quitChan == nil {
@@ -61,7 +74,12 @@ func (w *Watcher) Stop() {
 
 // CheckFileContentsChanged checks whether the file's checksum has changed.
 // If the file no longer exists, this will return an error.
+// A watcher without a checksum always reports that the contents are unchanged.
 func (w *Watcher) CheckFileContentsChanged() (bool, error) {
+	if w.skipChecksum {
+		return false, nil
+	}
+
 	checksum, err := w.calculateChecksum()
 	if err != nil {
 		return false, err
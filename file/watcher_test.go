@@ -65,7 +65,7 @@ func TestWatcher(t *testing.T) {
 	filePath := createTestFile(t, "abcd")
 
 	// Load the file and start a watcher.
-	_, watcher, err := Load(filePath, testWatcherPollInterval)
+	_, watcher, _, err := Load(filePath, "", testWatcherPollInterval)
 	require.NoError(t, err)
 	defer watcher.Stop()
 
@@ -78,3 +78,13 @@ func TestWatcher(t *testing.T) {
 	// Wait for changes to be detected (or time out and fail the test).
 	waitForChangeOrTimeout(t, watcher)
 }
+
+func TestWatcherWithoutChecksum(t *testing.T) {
+	filePath := createTestFile(t, "abcd")
+	watcher := NewWatcherWithoutChecksum(filePath)
+	defer watcher.Stop()
+
+	assert.Equal(t, filePath, watcher.Path())
+	appendToTestFile(t, filePath, "efgh")
+	expectNoChange(t, watcher)
+}
@@ -108,9 +108,7 @@ func ScrollUp(ctx Context) Action {
 	return func(s *state.EditorState) {
 		// Move the cursor to the start of a line above, then scroll up.
 		// (We don't scroll the view, because that happens automatically after every action.)
-		state.MoveCursor(s, func(params state.LocatorParams) uint64 {
-			return locate.StartOfLineAbove(params.TextTree, scrollLines, params.CursorPos)
-		})
+		state.MoveCursorToStartOfLineAbove(s, scrollLines)
 		state.ScrollViewByNumLines(s, state.ScrollDirectionBackward, scrollLines)
 	}
 }
@@ -124,9 +122,7 @@ func ScrollDown(ctx Context) Action {
 	return func(s *state.EditorState) {
 		// Move the cursor to the start of a line below, then scroll down.
 		// (We don't scroll the view, because that happens automatically after every action.)
-		state.MoveCursor(s, func(params state.LocatorParams) uint64 {
-			return locate.StartOfLineBelow(params.TextTree, scrollLines, params.CursorPos)
-		})
+		state.MoveCursorToStartOfLineBelow(s, scrollLines)
 		state.ScrollViewByNumLines(s, state.ScrollDirectionForward, scrollLines)
 	}
 }
@@ -228,6 +224,9 @@ func InsertTab(s *state.EditorState) {
 
 func DeletePrevChar(clipboardPage clipboard.PageId) Action {
 	return func(s *state.EditorState) {
+		if state.BackspaceInHexMode(s) || state.DeleteEmptyAutoPairAtCursor(s) {
+			return
+		}
 		state.DeleteRunes(s, func(params state.LocatorParams) uint64 {
 			prevInLinePos := locate.PrevCharInLine(params.TextTree, 1, true, params.CursorPos)
 			prevAutoIndentPos := locate.PrevAutoIndent(
@@ -479,6 +478,15 @@ func OutdentLine(count uint64) Action {
 	}
 }
 
+func ToggleCommentLine(count uint64) Action {
+	return func(s *state.EditorState) {
+		targetLineLoc := func(p state.LocatorParams) uint64 {
+			return locate.StartOfLineBelow(p.TextTree, count-1, p.CursorPos)
+		}
+		state.ToggleComment(s, targetLineLoc)
+	}
+}
+
 func CopyToStartOfNextWord(clipboardPage clipboard.PageId) Action {
 	return func(s *state.EditorState) {
 		startLoc := func(params state.LocatorParams) uint64 {
@@ -619,6 +627,88 @@ func Redo(s *state.EditorState) {
 	state.Redo(s)
 }
 
+func MoveToOlderUndoState(s *state.EditorState) {
+	state.MoveToOlderUndoState(s)
+}
+
+func MoveToNewerUndoState(s *state.EditorState) {
+	state.MoveToNewerUndoState(s)
+}
+
+func SplitPaneHorizontal(s *state.EditorState) {
+	state.SplitPane(s, state.SplitHorizontal)
+}
+
+func SplitPaneVertical(s *state.EditorState) {
+	state.SplitPane(s, state.SplitVertical)
+}
+
+func ClosePane(s *state.EditorState) {
+	state.ClosePane(s)
+}
+
+func CloseOtherPanes(s *state.EditorState) {
+	state.CloseOtherPanes(s)
+}
+
+func FocusNextPane(s *state.EditorState) {
+	state.FocusNextPane(s)
+}
+
+func FocusPrevPane(s *state.EditorState) {
+	state.FocusPrevPane(s)
+}
+
+func FocusPaneInDirection(direction state.PaneDirection) Action {
+	return func(s *state.EditorState) {
+		state.FocusPaneInDirection(s, direction)
+	}
+}
+
+func ResizePane(direction state.SplitDirection, delta int) Action {
+	return func(s *state.EditorState) {
+		state.ResizePane(s, direction, delta)
+	}
+}
+
+func NextDiffHunk(s *state.EditorState) {
+	state.NextDiffHunk(s)
+}
+
+func PrevDiffHunk(s *state.EditorState) {
+	state.PrevDiffHunk(s)
+}
+
+func NextMergeConflict(s *state.EditorState) {
+	state.NextMergeConflict(s)
+}
+
+func PrevMergeConflict(s *state.EditorState) {
+	state.PrevMergeConflict(s)
+}
+
+func ToggleFoldAtCursor(s *state.EditorState) {
+	state.ToggleFoldAtCursor(s)
+}
+
+func FoldBlockAtCursor(s *state.EditorState) {
+	state.FoldBlockAtCursor(s)
+}
+
+func UnfoldBlockAtCursor(s *state.EditorState) {
+	state.UnfoldBlockAtCursor(s)
+}
+
+func FoldAllToLevel(level uint64) Action {
+	return func(s *state.EditorState) {
+		state.FoldAllToLevel(s, level)
+	}
+}
+
+func UnfoldAll(s *state.EditorState) {
+	state.UnfoldAll(s)
+}
+
 func ToggleVisualModeCharwise(s *state.EditorState) {
 	state.ToggleVisualMode(s, selection.ModeChar)
 }
@@ -670,6 +760,14 @@ func OutdentSelectionAndReturnToNormalMode(selectionEndLoc state.Locator, count
 	}
 }
 
+func ToggleCommentInSelectionAndReturnToNormalMode(selectionEndLoc state.Locator) Action {
+	return func(s *state.EditorState) {
+		state.MoveCursorToStartOfSelection(s)
+		state.ToggleComment(s, selectionEndLoc)
+		ReturnToNormalMode(s)
+	}
+}
+
 func ChangeSelection(clipboardPage clipboard.PageId, selectionMode selection.Mode, selectionEndLoc state.Locator) Action {
 	deleteSelectionAction := DeleteSelection(clipboardPage, selectionMode, selectionEndLoc, true)
 	return func(s *state.EditorState) {
@@ -258,9 +258,11 @@ type addToMacro struct {
 func decorateNormalOrVisual(action Action, addToMacro addToMacro) Action {
 	return func(s *state.EditorState) {
 		wrappedAction := func(s *state.EditorState) {
+			// Clear the status before the action, so the action can report errors
+			// (for example, when the document has no comment syntax).
+			state.SetStatusMsg(s, state.StatusMsg{})
 			action(s)
 			state.ScrollViewToCursor(s)
-			state.SetStatusMsg(s, state.StatusMsg{})
 		}
 
 		state.CheckpointUndoLog(s)
@@ -668,6 +670,18 @@ func NormalModeCommands() []Command {
 					addToMacro{lastAction: true, user: true})
 			},
 		},
+		{
+			Name: "toggle comment (gcc)",
+			BuildExpr: func() vm.Expr {
+				return cmdExpr("gcc", "", captureOpts{count: true})
+			},
+			MaxCount: defaultMaxCount,
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(
+					ToggleCommentLine(p.Count),
+					addToMacro{lastAction: true, user: true})
+			},
+		},
 		{
 			Name: "yank to start of next word (yw)",
 			BuildExpr: func() vm.Expr {
@@ -811,6 +825,286 @@ func NormalModeCommands() []Command {
 					addToMacro{user: true})
 			},
 		},
+		{
+			Name: "older undo state (g-)",
+			BuildExpr: func() vm.Expr {
+				return cmdExpr("g-", "", captureOpts{})
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(
+					MoveToOlderUndoState,
+					addToMacro{user: true})
+			},
+		},
+		{
+			Name: "newer undo state (g+)",
+			BuildExpr: func() vm.Expr {
+				return cmdExpr("g+", "", captureOpts{})
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(
+					MoveToNewerUndoState,
+					addToMacro{user: true})
+			},
+		},
+		{
+			Name: "split pane horizontal (ctrl-w s)",
+			BuildExpr: func() vm.Expr {
+				return paneCmdExpr('s', captureOpts{})
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(
+					SplitPaneHorizontal,
+					addToMacro{user: true})
+			},
+		},
+		{
+			Name: "split pane vertical (ctrl-w v)",
+			BuildExpr: func() vm.Expr {
+				return paneCmdExpr('v', captureOpts{})
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(
+					SplitPaneVertical,
+					addToMacro{user: true})
+			},
+		},
+		{
+			Name: "close pane (ctrl-w q)",
+			BuildExpr: func() vm.Expr {
+				return paneCmdExpr('q', captureOpts{})
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(
+					ClosePane,
+					addToMacro{user: true})
+			},
+		},
+		{
+			Name: "close other panes (ctrl-w o)",
+			BuildExpr: func() vm.Expr {
+				return paneCmdExpr('o', captureOpts{})
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(
+					CloseOtherPanes,
+					addToMacro{user: true})
+			},
+		},
+		{
+			Name: "focus next pane (ctrl-w w)",
+			BuildExpr: func() vm.Expr {
+				return paneCmdExpr('w', captureOpts{})
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(
+					FocusNextPane,
+					addToMacro{user: true})
+			},
+		},
+		{
+			Name: "focus prev pane (ctrl-w W)",
+			BuildExpr: func() vm.Expr {
+				return paneCmdExpr('W', captureOpts{})
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(
+					FocusPrevPane,
+					addToMacro{user: true})
+			},
+		},
+		{
+			Name: "focus pane left (ctrl-w h)",
+			BuildExpr: func() vm.Expr {
+				return paneCmdExpr('h', captureOpts{})
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(
+					FocusPaneInDirection(state.PaneDirectionLeft),
+					addToMacro{user: true})
+			},
+		},
+		{
+			Name: "focus pane down (ctrl-w j)",
+			BuildExpr: func() vm.Expr {
+				return paneCmdExpr('j', captureOpts{})
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(
+					FocusPaneInDirection(state.PaneDirectionDown),
+					addToMacro{user: true})
+			},
+		},
+		{
+			Name: "focus pane up (ctrl-w k)",
+			BuildExpr: func() vm.Expr {
+				return paneCmdExpr('k', captureOpts{})
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(
+					FocusPaneInDirection(state.PaneDirectionUp),
+					addToMacro{user: true})
+			},
+		},
+		{
+			Name: "focus pane right (ctrl-w l)",
+			BuildExpr: func() vm.Expr {
+				return paneCmdExpr('l', captureOpts{})
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(
+					FocusPaneInDirection(state.PaneDirectionRight),
+					addToMacro{user: true})
+			},
+		},
+		{
+			Name: "increase pane height (ctrl-w +)",
+			BuildExpr: func() vm.Expr {
+				return paneCmdExpr('+', captureOpts{count: true})
+			},
+			MaxCount: defaultMaxCount,
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(
+					ResizePane(state.SplitHorizontal, int(p.Count)),
+					addToMacro{user: true})
+			},
+		},
+		{
+			Name: "decrease pane height (ctrl-w -)",
+			BuildExpr: func() vm.Expr {
+				return paneCmdExpr('-', captureOpts{count: true})
+			},
+			MaxCount: defaultMaxCount,
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(
+					ResizePane(state.SplitHorizontal, -int(p.Count)),
+					addToMacro{user: true})
+			},
+		},
+		{
+			Name: "increase pane width (ctrl-w >)",
+			BuildExpr: func() vm.Expr {
+				return paneCmdExpr('>', captureOpts{count: true})
+			},
+			MaxCount: defaultMaxCount,
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(
+					ResizePane(state.SplitVertical, int(p.Count)),
+					addToMacro{user: true})
+			},
+		},
+		{
+			Name: "decrease pane width (ctrl-w <)",
+			BuildExpr: func() vm.Expr {
+				return paneCmdExpr('<', captureOpts{count: true})
+			},
+			MaxCount: defaultMaxCount,
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(
+					ResizePane(state.SplitVertical, -int(p.Count)),
+					addToMacro{user: true})
+			},
+		},
+		{
+			Name: "next diff hunk (]c)",
+			BuildExpr: func() vm.Expr {
+				return cmdExpr("]c", "", captureOpts{})
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(
+					NextDiffHunk,
+					addToMacro{user: true})
+			},
+		},
+		{
+			Name: "prev diff hunk ([c)",
+			BuildExpr: func() vm.Expr {
+				return cmdExpr("[c", "", captureOpts{})
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(
+					PrevDiffHunk,
+					addToMacro{user: true})
+			},
+		},
+		{
+			Name: "next merge conflict (]n)",
+			BuildExpr: func() vm.Expr {
+				return cmdExpr("]n", "", captureOpts{})
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(
+					NextMergeConflict,
+					addToMacro{user: true})
+			},
+		},
+		{
+			Name: "prev merge conflict ([n)",
+			BuildExpr: func() vm.Expr {
+				return cmdExpr("[n", "", captureOpts{})
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(
+					PrevMergeConflict,
+					addToMacro{user: true})
+			},
+		},
+		{
+			Name: "toggle fold (za)",
+			BuildExpr: func() vm.Expr {
+				return cmdExpr("za", "", captureOpts{})
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(
+					ToggleFoldAtCursor,
+					addToMacro{user: true})
+			},
+		},
+		{
+			Name: "fold (zc)",
+			BuildExpr: func() vm.Expr {
+				return cmdExpr("zc", "", captureOpts{})
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(
+					FoldBlockAtCursor,
+					addToMacro{user: true})
+			},
+		},
+		{
+			Name: "unfold (zo)",
+			BuildExpr: func() vm.Expr {
+				return cmdExpr("zo", "", captureOpts{})
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(
+					UnfoldBlockAtCursor,
+					addToMacro{user: true})
+			},
+		},
+		{
+			Name: "fold all to level (zM)",
+			BuildExpr: func() vm.Expr {
+				return cmdExpr("zM", "", captureOpts{count: true})
+			},
+			MaxCount: defaultMaxCount,
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(
+					FoldAllToLevel(p.Count),
+					addToMacro{user: true})
+			},
+		},
+		{
+			Name: "unfold all (zR)",
+			BuildExpr: func() vm.Expr {
+				return cmdExpr("zR", "", captureOpts{})
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(
+					UnfoldAll,
+					addToMacro{user: true})
+			},
+		},
 		{
 			Name: "enter visual mode charwise (v)",
 			BuildExpr: func() vm.Expr {
@@ -936,6 +1230,17 @@ func VisualModeCommands() []Command {
 					addToMacro{lastAction: true, user: true})
 			},
 		},
+		{
+			Name: "toggle comment for selection (gc)",
+			BuildExpr: func() vm.Expr {
+				return cmdExpr("gc", "", captureOpts{})
+			},
+			BuildAction: func(ctx Context, p CommandParams) Action {
+				return decorateNormalOrVisual(
+					ToggleCommentInSelectionAndReturnToNormalMode(ctx.SelectionEndLocator),
+					addToMacro{lastAction: true, user: true})
+			},
+		},
 		{
 			Name: "indent selection (>)",
 			BuildExpr: func() vm.Expr {
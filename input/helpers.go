@@ -138,6 +138,15 @@ func init() {
 	}
 }
 
+// paneCmdExpr matches ctrl-w followed by a rune, optionally preceded by a count.
+func paneCmdExpr(r rune, opts captureOpts) vm.Expr {
+	var expr vm.Expr = vm.ConcatExpr{Children: []vm.Expr{keyExpr(tcell.KeyCtrlW), runeExpr(r)}}
+	if opts.count {
+		expr = vm.ConcatExpr{Children: []vm.Expr{countExpr, expr}}
+	}
+	return expr
+}
+
 func cmdExpr(verb string, object string, opts captureOpts) vm.Expr {
 	expr := vm.ConcatExpr{Children: make([]vm.Expr, 0, len(verb))}
 	for _, r := range verb {
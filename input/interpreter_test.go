@@ -1184,6 +1184,50 @@ func TestInterpreterStateIntegration(t *testing.T) {
 			expectedCursorPos: 16,
 			expectedText:      "Lorem ipsum dolor",
 		},
+		{
+			name:        "older undo state after branching",
+			initialText: "Lorem ipsum dolor",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'u', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'd', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'w', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'g', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '-', tcell.ModNone),
+			},
+			expectedCursorPos: 0,
+			expectedText:      "orem ipsum dolor",
+		},
+		{
+			name:        "newer undo state after older undo state",
+			initialText: "Lorem ipsum dolor",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'u', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'd', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'w', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'g', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '-', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'g', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, '+', tcell.ModNone),
+			},
+			expectedCursorPos: 0,
+			expectedText:      "ipsum dolor",
+		},
+		{
+			name:        "split pane and edit in other pane",
+			initialText: "Lorem ipsum dolor",
+			events: []tcell.Event{
+				tcell.NewEventKey(tcell.KeyRune, 'w', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyCtrlW, '\x17', tcell.ModCtrl),
+				tcell.NewEventKey(tcell.KeyRune, 'v', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyCtrlW, '\x17', tcell.ModCtrl),
+				tcell.NewEventKey(tcell.KeyRune, 'w', tcell.ModNone),
+				tcell.NewEventKey(tcell.KeyRune, 'x', tcell.ModNone),
+			},
+			expectedCursorPos: 6,
+			expectedText:      "Lorem psum dolor",
+		},
 		{
 			name:        "repeat last action delete-a-word",
 			initialText: "Lorem ipsum dolor\nsit amet consectetur\nadipiscing elit",
@@ -1679,6 +1723,36 @@ func TestCountLimits(t *testing.T) {
 	}
 }
 
+func TestNormalAndVisualActionStatusMsg(t *testing.T) {
+	testCases := []string{
+		"gcc",
+		"vgc",
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc, func(t *testing.T) {
+			interpreter := NewInterpreter()
+			editorState := state.NewEditorState(100, 100, nil, nil)
+			state.SetStatusMsg(editorState, state.StatusMsg{
+				Style: state.StatusMsgStyleSuccess,
+				Text:  "previous message",
+			})
+
+			for _, r := range tc {
+				event := tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone)
+				inputCtx := ContextFromEditorState(editorState)
+				action := interpreter.ProcessEvent(event, inputCtx)
+				action(editorState)
+			}
+
+			// The action clears the previous message, but not the error it reports.
+			msg := editorState.StatusMsg()
+			assert.Equal(t, state.StatusMsgStyleError, msg.Style)
+			assert.Contains(t, msg.Text, "No comment syntax")
+		})
+	}
+}
+
 func BenchmarkNewInterpreter(b *testing.B) {
 	for n := 0; n < b.N; n++ {
 		NewInterpreter()
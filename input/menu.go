@@ -3,6 +3,7 @@ package input
 import (
 	"fmt"
 
+	"github.com/aretext/aretext/file"
 	"github.com/aretext/aretext/menu"
 	"github.com/aretext/aretext/state"
 	"github.com/aretext/aretext/syntax"
@@ -15,7 +16,7 @@ func menuItems(ctx Context) []menu.Item {
 			Name:    "quit",
 			Aliases: []string{"q"},
 			Action: func(s *state.EditorState) {
-				state.AbortIfUnsavedChanges(s, state.Quit, true)
+				state.AbortIfAnyUnsavedChanges(s, state.Quit)
 			},
 		},
 		{
@@ -36,7 +37,7 @@ func menuItems(ctx Context) []menu.Item {
 			Action: func(s *state.EditorState) {
 				state.AbortIfFileExistsWithChangedContent(s, func(s *state.EditorState) {
 					state.SaveDocument(s)
-					state.Quit(s)
+					state.AbortIfAnyUnsavedChanges(s, state.Quit)
 				})
 			},
 		},
@@ -61,23 +62,67 @@ func menuItems(ctx Context) []menu.Item {
 		{
 			Name:    "find and open",
 			Aliases: []string{"f"},
-			Action: func(s *state.EditorState) {
-				state.AbortIfUnsavedChanges(s, ShowFileMenu(ctx), true)
-			},
+			Action:  ShowFileMenu(ctx),
 		},
 		{
 			Name:    "open previous document",
 			Aliases: []string{"p"},
-			Action: func(s *state.EditorState) {
-				state.AbortIfUnsavedChanges(s, state.LoadPrevDocument, true)
-			},
+			Action:  state.LoadPrevDocument,
 		},
 		{
 			Name:    "open next document",
 			Aliases: []string{"n"},
-			Action: func(s *state.EditorState) {
-				state.AbortIfUnsavedChanges(s, state.LoadNextDocument, true)
-			},
+			Action:  state.LoadNextDocument,
+		},
+		{
+			Name:    "switch buffer",
+			Aliases: []string{"b"},
+			Action:  state.ShowBufferMenu,
+		},
+		{
+			Name:    "split pane horizontal",
+			Aliases: []string{"split"},
+			Action:  SplitPaneHorizontal,
+		},
+		{
+			Name:    "split pane vertical",
+			Aliases: []string{"vsplit"},
+			Action:  SplitPaneVertical,
+		},
+		{
+			Name:   "close pane",
+			Action: ClosePane,
+		},
+		{
+			Name:    "close other panes",
+			Aliases: []string{"only"},
+			Action:  CloseOtherPanes,
+		},
+		{
+			Name:   "next pane",
+			Action: FocusNextPane,
+		},
+		{
+			Name:   "previous pane",
+			Action: FocusPrevPane,
+		},
+		{
+			Name:    "toggle diff view",
+			Aliases: []string{"diff"},
+			Action:  state.ToggleDiffView,
+		},
+		{
+			Name:   "revert hunk",
+			Action: state.RevertDiffHunk,
+		},
+		{
+			Name:   "blame line",
+			Action: state.ShowBlameForLine,
+		},
+		{
+			Name:    "toggle blame view",
+			Aliases: []string{"blame"},
+			Action:  state.ToggleBlameView,
 		},
 		{
 			Name:    "toggle show tabs",
@@ -89,6 +134,28 @@ func menuItems(ctx Context) []menu.Item {
 			Aliases: []string{"sp"},
 			Action:  state.ToggleShowSpaces,
 		},
+		{
+			Name:    "toggle fold",
+			Aliases: []string{"fold"},
+			Action:  state.ToggleFoldAtCursor,
+		},
+		{
+			Name:    "fold all",
+			Aliases: []string{"foldall"},
+			Action: func(s *state.EditorState) {
+				state.FoldAllToLevel(s, 1)
+			},
+		},
+		{
+			Name:    "unfold all",
+			Aliases: []string{"unfoldall"},
+			Action:  state.UnfoldAll,
+		},
+		{
+			Name:    "toggle indent guides",
+			Aliases: []string{"ig"},
+			Action:  state.ToggleShowIndentGuides,
+		},
 		{
 			Name:    "toggle tab expand",
 			Aliases: []string{"te"},
@@ -99,11 +166,118 @@ func menuItems(ctx Context) []menu.Item {
 			Aliases: []string{"nu"},
 			Action:  state.ToggleShowLineNumbers,
 		},
+		{
+			Name:    "toggle relative line numbers",
+			Aliases: []string{"rnu"},
+			Action:  state.ToggleRelativeLineNumbers,
+		},
+		{
+			Name:    "switch theme",
+			Aliases: []string{"theme"},
+			Action:  state.ShowThemeMenu,
+		},
 		{
 			Name:    "toggle auto-indent",
 			Aliases: []string{"ai"},
 			Action:  state.ToggleAutoIndent,
 		},
+		{
+			Name:    "toggle auto-pair",
+			Aliases: []string{"ap"},
+			Action:  state.ToggleAutoPair,
+		},
+		{
+			Name:   "trim trailing whitespace",
+			Action: state.TrimTrailingWhitespace,
+		},
+		{
+			Name:   "trim trailing blank lines",
+			Action: state.TrimTrailingBlankLines,
+		},
+		{
+			Name:    "toggle hex mode",
+			Aliases: []string{"hex"},
+			Action: func(s *state.EditorState) {
+				state.AbortIfUnsavedChanges(s, state.ToggleHexMode, true)
+			},
+		},
+		{
+			Name:    "undo history",
+			Aliases: []string{"uh"},
+			Action:  state.ShowUndoHistoryMenu,
+		},
+		{
+			// The duration is typed after the name, like "earlier 10m".
+			Name: "earlier",
+			Action: func(s *state.EditorState) {
+				state.MoveUndoStateEarlierByArg(s, "")
+			},
+			ArgAction: state.MoveUndoStateEarlierByArg,
+		},
+		{
+			Name: "later",
+			Action: func(s *state.EditorState) {
+				state.MoveUndoStateLaterByArg(s, "")
+			},
+			ArgAction: state.MoveUndoStateLaterByArg,
+		},
+		{
+			Name:    "toggle read-only",
+			Aliases: []string{"ro"},
+			Action:  state.ToggleReadOnly,
+		},
+		{
+			Name:   "load entire file",
+			Action: state.LoadEntireLargeFile,
+		},
+		{
+			Name: "convert line endings to LF",
+			Action: func(s *state.EditorState) {
+				state.ConvertLineEndings(s, file.LineEndingLF)
+			},
+		},
+		{
+			Name: "convert line endings to CRLF",
+			Action: func(s *state.EditorState) {
+				state.ConvertLineEndings(s, file.LineEndingCRLF)
+			},
+		},
+		{
+			Name: "convert encoding to UTF-8",
+			Action: func(s *state.EditorState) {
+				state.ConvertEncoding(s, file.EncodingUTF8, false)
+			},
+		},
+		{
+			Name: "convert encoding to UTF-8 with BOM",
+			Action: func(s *state.EditorState) {
+				state.ConvertEncoding(s, file.EncodingUTF8, true)
+			},
+		},
+		{
+			Name: "convert encoding to UTF-16LE",
+			Action: func(s *state.EditorState) {
+				state.ConvertEncoding(s, file.EncodingUTF16LE, true)
+			},
+		},
+		{
+			Name: "convert encoding to UTF-16BE",
+			Action: func(s *state.EditorState) {
+				state.ConvertEncoding(s, file.EncodingUTF16BE, true)
+			},
+		},
+		{
+			Name: "convert encoding to Latin-1",
+			Action: func(s *state.EditorState) {
+				state.ConvertEncoding(s, file.EncodingLatin1, false)
+			},
+		},
+		{
+			Name: "convert encoding to Windows-1252",
+			Action: func(s *state.EditorState) {
+				state.ConvertEncoding(s, file.EncodingWindows1252, false)
+			},
+		},
 	}
 
 	for _, language := range syntax.AllLanguages {
@@ -0,0 +1,36 @@
+package locate
+
+import "sort"
+
+// FoldedRange is a range of lines hidden by a closed fold.
+// The line before the range stays visible and summarizes the hidden lines.
+type FoldedRange struct {
+	StartPos uint64 // Start of the first hidden line.
+	EndPos   uint64 // Start of the line after the last hidden line, or the end of the document.
+	NumLines uint64 // Number of hidden lines.
+}
+
+// ContainsPosition returns whether a position is within the hidden lines.
+func (r FoldedRange) ContainsPosition(pos uint64) bool {
+	return pos >= r.StartPos && pos < r.EndPos
+}
+
+// foldedRangeStartingAt returns the folded range that starts at a position.
+// The folded ranges must be sorted and non-overlapping.
+func foldedRangeStartingAt(folds []FoldedRange, pos uint64) (FoldedRange, bool) {
+	i := sort.Search(len(folds), func(i int) bool { return folds[i].StartPos >= pos })
+	if i < len(folds) && folds[i].StartPos == pos {
+		return folds[i], true
+	}
+	return FoldedRange{}, false
+}
+
+// foldedRangeEndingAt returns the folded range that ends at a position.
+// The folded ranges must be sorted and non-overlapping.
+func foldedRangeEndingAt(folds []FoldedRange, pos uint64) (FoldedRange, bool) {
+	i := sort.Search(len(folds), func(i int) bool { return folds[i].EndPos >= pos })
+	if i < len(folds) && folds[i].EndPos == pos {
+		return folds[i], true
+	}
+	return FoldedRange{}, false
+}
@@ -20,14 +20,15 @@ const ScrollMargin = 3
 
 // ViewOriginAfterScroll returns a new view origin such that the cursor is visible.
 // It attempts to display a few lines before/after the cursor to help the user navigate.
-func ViewOriginAfterScroll(cursorPos uint64, tree *text.Tree, wrapConfig segment.LineWrapConfig, viewOrigin, viewHeight uint64) uint64 {
-	rng := visibleRangeWithinMargin(tree, viewOrigin, wrapConfig, viewHeight)
+// Lines hidden by folds are skipped, so the cursor must not be within a folded range.
+func ViewOriginAfterScroll(cursorPos uint64, tree *text.Tree, wrapConfig segment.LineWrapConfig, folds []FoldedRange, viewOrigin, viewHeight uint64) uint64 {
+	rng := visibleRangeWithinMargin(tree, viewOrigin, wrapConfig, folds, viewHeight)
 	if cursorPos < rng.startPos {
 		// scroll backward
-		return scrollToCursor(cursorPos, maxLinesAboveCursorScrollBackward(viewHeight), tree, wrapConfig)
+		return scrollToCursor(cursorPos, maxLinesAboveCursorScrollBackward(viewHeight), tree, wrapConfig, folds)
 	} else if cursorPos >= rng.endPos {
 		// scroll forward
-		return scrollToCursor(cursorPos, maxLinesAboveCursorScrollForward(viewHeight), tree, wrapConfig)
+		return scrollToCursor(cursorPos, maxLinesAboveCursorScrollForward(viewHeight), tree, wrapConfig, folds)
 	} else {
 		// cursor is already visible and within the margins, so don't move the view origin
 		return viewOrigin
@@ -70,8 +71,8 @@ func maxLinesAboveCursorScrollForward(viewHeight uint64) uint64 {
 // visibleRangeWithinMargin returns a range of visible characters, excluding the scroll margin at the top and bottom.
 // Cursor movements within this range will NOT trigger scrolling.
 // This is an important performance optimization because scrolling is computationally expensive.
-func visibleRangeWithinMargin(tree *text.Tree, viewOrigin uint64, wrapConfig segment.LineWrapConfig, viewHeight uint64) posRange {
-	lines := visibleLineRanges(tree, viewOrigin, wrapConfig, viewHeight)
+func visibleRangeWithinMargin(tree *text.Tree, viewOrigin uint64, wrapConfig segment.LineWrapConfig, folds []FoldedRange, viewHeight uint64) posRange {
+	lines := visibleLineRanges(tree, viewOrigin, wrapConfig, folds, viewHeight)
 
 	if len(lines) == 0 {
 		return posRange{}
@@ -102,7 +103,8 @@ func visibleRangeWithinMargin(tree *text.Tree, viewOrigin uint64, wrapConfig seg
 
 // visibleLineRanges returns the range for each soft- or hard-wrapped line visible in the current view.
 // For hard-wrapped lines, the newline character position is included in the line it terminates.
-func visibleLineRanges(tree *text.Tree, viewOrigin uint64, wrapConfig segment.LineWrapConfig, viewHeight uint64) []posRange {
+// Lines hidden by a fold are included in the range of the line before the fold.
+func visibleLineRanges(tree *text.Tree, viewOrigin uint64, wrapConfig segment.LineWrapConfig, folds []FoldedRange, viewHeight uint64) []posRange {
 	wrappedLineIter := segment.NewWrappedLineIter(wrapConfig, tree, viewOrigin)
 	wrappedLine := segment.Empty()
 	pos := viewOrigin
@@ -124,6 +126,12 @@ func visibleLineRanges(tree *text.Tree, viewOrigin uint64, wrapConfig segment.Li
 
 		pos += wrappedLine.NumRunes()
 		prevHadNewline = wrappedLine.HasNewline()
+
+		if fold, ok := foldedRangeStartingAt(folds, pos); ok && prevHadNewline {
+			pos = fold.EndPos
+			lineRanges[len(lineRanges)-1].endPos = pos
+			wrappedLineIter = segment.NewWrappedLineIter(wrapConfig, tree, pos)
+		}
 	}
 
 	// If the last visible line ends with a newline, then a cursor positioned at the end of the line
@@ -139,7 +147,7 @@ func visibleLineRanges(tree *text.Tree, viewOrigin uint64, wrapConfig segment.Li
 // scrollToCursor returns a view origin at the start of a line such that the cursor is visible.
 // It attempts to display maxLinesAboveCursor before the cursor's line unless this would go past the start of the text.
 // The complexity is worst-case O(n) for n runes in the text due to the scan backwards for the start of the cursor's line.
-func scrollToCursor(cursorPos uint64, maxLinesAboveCursor uint64, tree *text.Tree, wrapConfig segment.LineWrapConfig) uint64 {
+func scrollToCursor(cursorPos uint64, maxLinesAboveCursor uint64, tree *text.Tree, wrapConfig segment.LineWrapConfig, folds []FoldedRange) uint64 {
 	lineStartPos := tree.LineStartPosition(tree.LineNumForPosition(cursorPos))
 	wrappedLines := softWrapLineUntil(lineStartPos, tree, wrapConfig, func(rng posRange) bool {
 		return cursorPos >= rng.startPos && cursorPos < rng.endPos
@@ -156,9 +164,13 @@ func scrollToCursor(cursorPos uint64, maxLinesAboveCursor uint64, tree *text.Tre
 	}
 
 	// We still need more lines before the cursor, so recurse.
+	// If the previous lines are hidden by a fold, continue from the line before the fold.
+	if fold, ok := foldedRangeEndingAt(folds, lineStartPos); ok {
+		lineStartPos = fold.StartPos
+	}
 	endOfPrevLine := lineStartPos - 1
 	remainingLines := maxLinesAboveCursor - numWrappedLines
-	return scrollToCursor(endOfPrevLine, remainingLines, tree, wrapConfig)
+	return scrollToCursor(endOfPrevLine, remainingLines, tree, wrapConfig, folds)
 }
 
 // softWrapLineUntil returns ranges for soft-wrapped lines in a line until a given stop condition occurs.
@@ -194,7 +194,58 @@ func TestViewOriginAfterScroll(t *testing.T) {
 					return cellwidth.GraphemeClusterWidth(gc, offsetInLine, 4)
 				},
 			}
-			updatedViewStartPos := ViewOriginAfterScroll(tc.cursorPos, tree, wrapConfig, tc.viewStartPos, tc.viewHeight)
+			updatedViewStartPos := ViewOriginAfterScroll(tc.cursorPos, tree, wrapConfig, nil, tc.viewStartPos, tc.viewHeight)
+			assert.Equal(t, tc.expectedPos, updatedViewStartPos)
+		})
+	}
+}
+
+func TestViewOriginAfterScrollWithFolds(t *testing.T) {
+	// Lines 2-7 are hidden by a fold.
+	inputString := "l0\nl1\nl2\nl3\nl4\nl5\nl6\nl7\nl8\nl9"
+	folds := []FoldedRange{{StartPos: 6, EndPos: 24, NumLines: 6}}
+
+	testCases := []struct {
+		name         string
+		cursorPos    uint64
+		viewStartPos uint64
+		viewHeight   uint64
+		expectedPos  uint64
+	}{
+		{
+			name:         "cursor after fold already visible",
+			cursorPos:    24,
+			viewStartPos: 0,
+			viewHeight:   4,
+			expectedPos:  0,
+		},
+		{
+			name:         "scroll up past fold",
+			cursorPos:    24,
+			viewStartPos: 27,
+			viewHeight:   4,
+			expectedPos:  0,
+		},
+		{
+			name:         "scroll down past fold",
+			cursorPos:    27,
+			viewStartPos: 0,
+			viewHeight:   3,
+			expectedPos:  3,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tree, err := text.NewTreeFromString(inputString)
+			require.NoError(t, err)
+			wrapConfig := segment.LineWrapConfig{
+				MaxLineWidth: 10,
+				WidthFunc: func(gc []rune, offsetInLine uint64) uint64 {
+					return cellwidth.GraphemeClusterWidth(gc, offsetInLine, 4)
+				},
+			}
+			updatedViewStartPos := ViewOriginAfterScroll(tc.cursorPos, tree, wrapConfig, folds, tc.viewStartPos, tc.viewHeight)
 			assert.Equal(t, tc.expectedPos, updatedViewStartPos)
 		})
 	}
@@ -53,6 +53,7 @@ var logpath = flag.String("log", "", "log to file")
 var cpuprofile = flag.String("cpuprofile", "", "write cpu profile to file")
 var editconfig = flag.Bool("editconfig", false, "open the aretext configuration file")
 var noconfig = flag.Bool("noconfig", false, "force default configuration")
+var hexMode = flag.Bool("hex", false, "open the document in hex mode")
 var versionFlag = flag.Bool("version", false, "print version")
 
 func main() {
@@ -101,7 +102,7 @@ func main() {
 		path = configPath
 	}
 
-	err := runEditor(path, lineNum)
+	err := runEditor(path, lineNum, *hexMode)
 	if err != nil {
 		exitWithError(err)
 	}
@@ -113,7 +114,7 @@ func printUsage() {
 	flag.PrintDefaults()
 }
 
-func runEditor(path string, lineNum uint64) error {
+func runEditor(path string, lineNum uint64, hex bool) error {
 	log.Printf("version: %s\n", version)
 	log.Printf("go version: %s\n", goVersion)
 	log.Printf("vcs.revision: %s\n", vcsRevision)
@@ -121,6 +122,7 @@ func runEditor(path string, lineNum uint64) error {
 	log.Printf("vcs.modified: %t\n", vcsModified)
 	log.Printf("path arg: '%s'\n", path)
 	log.Printf("lineNum: %d\n", lineNum)
+	log.Printf("hex: %t\n", hex)
 	log.Printf("$TERM env var: '%s'\n", os.Getenv("TERM"))
 
 	configRuleSet, err := app.LoadOrCreateConfig(*noconfig)
@@ -138,7 +140,7 @@ func runEditor(path string, lineNum uint64) error {
 	}
 	defer screen.Fini()
 
-	editor := app.NewEditor(screen, path, uint64(lineNum), configRuleSet)
+	editor := app.NewEditor(screen, path, uint64(lineNum), hex, configRuleSet)
 	editor.RunEventLoop()
 	return nil
 }
@@ -1,5 +1,7 @@
 package menu
 
+import "strings"
+
 // Item represents an item in the editor's menu.
 type Item struct {
 	// Name is the displayed name of the item.
@@ -12,4 +14,20 @@ type Item struct {
 	// Action is the action to perform when the user selects the menu item.
 	// This should be a function that accepts a single *EditorState arg.
 	Action any
+
+	// ArgAction, if set, is the action to perform when the query starts with the item's name
+	// followed by an argument, like "earlier 10m".
+	// This should be a function that accepts an *EditorState arg and a string arg.
+	ArgAction any
+}
+
+// ArgFromQuery returns the argument after the item's name in the query.
+// This returns false if the item doesn't accept an argument or the query doesn't include one.
+func (item Item) ArgFromQuery(query string) (string, bool) {
+	prefix := strings.ToLower(item.Name) + " "
+	if item.ArgAction == nil || !strings.HasPrefix(strings.ToLower(query), prefix) {
+		return "", false
+	}
+	arg := strings.TrimSpace(query[len(prefix):])
+	return arg, arg != ""
 }
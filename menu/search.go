@@ -80,6 +80,10 @@ func (s *Search) SetQuery(q string) {
 	if itemId, ok := s.aliasIndex[strings.ToLower(truncatedQuery)]; ok {
 		itemIdMatchingAlias = itemId
 		results = append(results, s.items[itemId])
+	} else if itemId, ok := s.itemIdWithArgInQuery(truncatedQuery); ok {
+		// The query includes an argument for the item, which fuzzy search might not match.
+		itemIdMatchingAlias = itemId
+		results = append(results, s.items[itemId])
 	}
 	for _, itemId := range resultItemIds {
 		if itemId != itemIdMatchingAlias {
@@ -89,6 +93,15 @@ func (s *Search) SetQuery(q string) {
 	s.results = results
 }
 
+func (s *Search) itemIdWithArgInQuery(q string) (int, bool) {
+	for itemId, item := range s.items {
+		if _, ok := item.ArgFromQuery(q); ok {
+			return itemId, true
+		}
+	}
+	return 0, false
+}
+
 // Results returns the menu items matching the current query.
 // Items are sorted descending by relevance to the query,
 // with ties broken by lexicographic ordering.
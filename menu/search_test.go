@@ -145,6 +145,47 @@ func TestSearch(t *testing.T) {
 	}
 }
 
+func TestSearchItemWithArg(t *testing.T) {
+	testCases := []struct {
+		name          string
+		query         string
+		expectedNames []string
+	}{
+		{
+			name:          "name without arg",
+			query:         "earlier",
+			expectedNames: []string{"earlier"},
+		},
+		{
+			name:          "name with arg",
+			query:         "earlier 10m",
+			expectedNames: []string{"earlier"},
+		},
+		{
+			name:          "name with arg for item without arg action",
+			query:         "clear 10m",
+			expectedNames: []string{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			items := []Item{
+				{Name: "earlier", ArgAction: func(string) {}},
+				{Name: "clear"},
+				{Name: "quit"},
+			}
+			s := NewSearch(items, false)
+			s.SetQuery(tc.query)
+			names := make([]string, 0, len(s.Results()))
+			for _, item := range s.Results() {
+				names = append(names, item.Name)
+			}
+			assert.Equal(t, tc.expectedNames, names)
+		})
+	}
+}
+
 func BenchmarkSearch(b *testing.B) {
 	s := NewSearch(fakeItems(1000, "foo/bar/baz/bat/test"), false)
 	for i := 0; i < b.N; i++ {
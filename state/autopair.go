@@ -0,0 +1,138 @@
+package state
+
+import (
+	"io"
+	"unicode"
+
+	"github.com/aretext/aretext/syntax/parser"
+)
+
+// autoPair is a pair of opening and closing characters, like "(" and ")".
+type autoPair struct {
+	open, close rune
+}
+
+func autoPairsFromConfig(pairs []string) []autoPair {
+	result := make([]autoPair, 0, len(pairs))
+	for _, s := range pairs {
+		runes := []rune(s)
+		if len(runes) != 2 {
+			continue // Should never happen because we validated the config.
+		}
+		result = append(result, autoPair{open: runes[0], close: runes[1]})
+	}
+	return result
+}
+
+// ToggleAutoPair enables or disables auto-pairing of brackets and quotes.
+func ToggleAutoPair(s *EditorState) {
+	toggleFlagAndSetStatus(s, &s.documentBuffer.autoPair, "Enabled auto-pair", "Disabled auto-pair")
+}
+
+// skipAutoPairCloser moves the cursor past the next character if it matches
+// the closing character of an auto-pair. It returns true if the cursor moved.
+func skipAutoPairCloser(state *EditorState, r rune) bool {
+	buffer := state.documentBuffer
+	isCloser := false
+	for _, pair := range buffer.autoPairs {
+		if pair.close == r {
+			isCloser = true
+			break
+		}
+	}
+
+	if !isCloser {
+		return false
+	}
+
+	pos := buffer.cursor.position
+	if next, ok := runeAtPosition(buffer, pos); !ok || next != r {
+		return false
+	}
+
+	buffer.cursor = cursorState{position: pos + 1}
+	return true
+}
+
+// insertAutoPairCloser inserts the closing character for an opening character
+// that was just inserted at openPos. The cursor is not moved, so it remains between the pair.
+func insertAutoPairCloser(state *EditorState, r rune, openPos uint64) {
+	buffer := state.documentBuffer
+	for _, pair := range buffer.autoPairs {
+		if pair.open != r {
+			continue
+		}
+
+		if !shouldInsertAutoPairCloser(buffer, pair, openPos) {
+			return
+		}
+
+		mustInsertRuneAtPosition(state, pair.close, openPos+1, true)
+		return
+	}
+}
+
+func shouldInsertAutoPairCloser(buffer *BufferState, pair autoPair, openPos uint64) bool {
+	// Avoid inserting a closer before a word, for example when wrapping an existing expression in parens.
+	if next, ok := runeAtPosition(buffer, openPos+1); ok && isAutoPairWordRune(next) {
+		return false
+	}
+
+	// Quotes are symmetric, so avoid pairing after a word (for example, an apostrophe in "don't").
+	if pair.open == pair.close && openPos > 0 {
+		if prev, ok := runeAtPosition(buffer, openPos-1); ok && isAutoPairWordRune(prev) {
+			return false
+		}
+	}
+
+	// Avoid pairing within strings and comments. Use the tokens after the opener was inserted,
+	// so an opening quote that starts a new string token is still paired.
+	for _, token := range buffer.SyntaxTokensIntersectingRange(openPos, openPos+1) {
+		if token.StartPos < openPos && (token.Role == parser.TokenRoleString || token.Role == parser.TokenRoleComment) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// DeleteEmptyAutoPairAtCursor deletes an opening and closing character around the cursor,
+// if the cursor is between an empty auto-pair. It returns true if the pair was deleted.
+func DeleteEmptyAutoPairAtCursor(state *EditorState) bool {
+	buffer := state.documentBuffer
+	pos := buffer.cursor.position
+	if !buffer.autoPair || pos == 0 {
+		return false
+	}
+
+	prev, prevOk := runeAtPosition(buffer, pos-1)
+	next, nextOk := runeAtPosition(buffer, pos)
+	if !prevOk || !nextOk {
+		return false
+	}
+
+	for _, pair := range buffer.autoPairs {
+		if pair.open == prev && pair.close == next {
+			deleteRunes(state, pos-1, 2, true)
+			buffer.cursor = cursorState{position: pos - 1}
+			return true
+		}
+	}
+
+	return false
+}
+
+func runeAtPosition(buffer *BufferState, pos uint64) (rune, bool) {
+	reader := buffer.textTree.ReaderAtPosition(pos)
+	r, _, err := reader.ReadRune()
+	if err == io.EOF {
+		return '\x00', false
+	} else if err != nil {
+		panic(err) // should never happen because text should be valid UTF-8
+	}
+	return r, true
+}
+
+func isAutoPairWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
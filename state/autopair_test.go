@@ -0,0 +1,234 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/config"
+	"github.com/aretext/aretext/syntax"
+	"github.com/aretext/aretext/text"
+)
+
+func TestInsertRuneWithAutoPair(t *testing.T) {
+	testCases := []struct {
+		name           string
+		language       syntax.Language
+		autoPairs      []string
+		inputString    string
+		cursorPos      uint64
+		insertRunes    string
+		expectedText   string
+		expectedCursor uint64
+	}{
+		{
+			name:           "insert opening paren",
+			inputString:    "",
+			insertRunes:    "(",
+			expectedText:   "()",
+			expectedCursor: 1,
+		},
+		{
+			name:           "insert opening and closing paren",
+			inputString:    "",
+			insertRunes:    "()",
+			expectedText:   "()",
+			expectedCursor: 2,
+		},
+		{
+			name:           "insert nested brackets",
+			inputString:    "",
+			insertRunes:    "([{x}])",
+			expectedText:   "([{x}])",
+			expectedCursor: 7,
+		},
+		{
+			name:           "insert opening paren before word",
+			inputString:    "abc",
+			cursorPos:      0,
+			insertRunes:    "(",
+			expectedText:   "(abc",
+			expectedCursor: 1,
+		},
+		{
+			name:           "insert closing paren without next closer",
+			inputString:    "abc",
+			cursorPos:      3,
+			insertRunes:    ")",
+			expectedText:   "abc)",
+			expectedCursor: 4,
+		},
+		{
+			name:           "insert quotes",
+			inputString:    "",
+			insertRunes:    "\"abc\"",
+			expectedText:   "\"abc\"",
+			expectedCursor: 5,
+		},
+		{
+			name:           "insert quote after word",
+			inputString:    "don",
+			cursorPos:      3,
+			insertRunes:    "'",
+			expectedText:   "don'",
+			expectedCursor: 4,
+		},
+		{
+			name:           "insert paren in string",
+			language:       syntax.LanguageGo,
+			inputString:    "x := \"ab\"",
+			cursorPos:      7,
+			insertRunes:    "(",
+			expectedText:   "x := \"a(b\"",
+			expectedCursor: 8,
+		},
+		{
+			name:           "insert quote to start string",
+			language:       syntax.LanguageGo,
+			inputString:    "x := ",
+			cursorPos:      5,
+			insertRunes:    "\"",
+			expectedText:   "x := \"\"",
+			expectedCursor: 6,
+		},
+		{
+			name:           "insert paren in comment",
+			language:       syntax.LanguageGo,
+			inputString:    "// abc",
+			cursorPos:      6,
+			insertRunes:    "(",
+			expectedText:   "// abc(",
+			expectedCursor: 7,
+		},
+		{
+			name:           "custom pairs",
+			autoPairs:      []string{"()", "<>"},
+			inputString:    "",
+			insertRunes:    "<'",
+			expectedText:   "<'>",
+			expectedCursor: 2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			textTree, err := text.NewTreeFromString(tc.inputString)
+			require.NoError(t, err)
+			state := NewEditorState(100, 100, nil, nil)
+			buffer := state.documentBuffer
+			buffer.textTree = textTree
+			buffer.cursor = cursorState{position: tc.cursorPos}
+			buffer.autoPair = true
+			if len(tc.autoPairs) > 0 {
+				buffer.autoPairs = autoPairsFromConfig(tc.autoPairs)
+			}
+			if tc.language != "" {
+				setSyntaxAndRetokenize(buffer, tc.language)
+			}
+			for _, r := range tc.insertRunes {
+				InsertRune(state, r)
+			}
+			assert.Equal(t, tc.expectedText, textTree.String())
+			assert.Equal(t, tc.expectedCursor, buffer.cursor.position)
+		})
+	}
+}
+
+func TestInsertRuneWithAutoPairDisabled(t *testing.T) {
+	state := NewEditorState(100, 100, nil, nil)
+	InsertRune(state, '(')
+	InsertRune(state, ')')
+	InsertRune(state, ')')
+	assert.Equal(t, "())", state.documentBuffer.textTree.String())
+	assert.Equal(t, uint64(3), state.documentBuffer.cursor.position)
+}
+
+func TestDeleteEmptyAutoPairAtCursor(t *testing.T) {
+	testCases := []struct {
+		name           string
+		autoPair       bool
+		inputString    string
+		cursorPos      uint64
+		expectDeleted  bool
+		expectedText   string
+		expectedCursor uint64
+	}{
+		{
+			name:           "empty document",
+			autoPair:       true,
+			inputString:    "",
+			cursorPos:      0,
+			expectedText:   "",
+			expectedCursor: 0,
+		},
+		{
+			name:           "between empty pair",
+			autoPair:       true,
+			inputString:    "a()b",
+			cursorPos:      2,
+			expectDeleted:  true,
+			expectedText:   "ab",
+			expectedCursor: 1,
+		},
+		{
+			name:           "between empty quotes",
+			autoPair:       true,
+			inputString:    "''",
+			cursorPos:      1,
+			expectDeleted:  true,
+			expectedText:   "",
+			expectedCursor: 0,
+		},
+		{
+			name:           "between mismatched pair",
+			autoPair:       true,
+			inputString:    "(]",
+			cursorPos:      1,
+			expectedText:   "(]",
+			expectedCursor: 1,
+		},
+		{
+			name:           "non-empty pair",
+			autoPair:       true,
+			inputString:    "(a)",
+			cursorPos:      2,
+			expectedText:   "(a)",
+			expectedCursor: 2,
+		},
+		{
+			name:           "auto-pair disabled",
+			autoPair:       false,
+			inputString:    "()",
+			cursorPos:      1,
+			expectedText:   "()",
+			expectedCursor: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			textTree, err := text.NewTreeFromString(tc.inputString)
+			require.NoError(t, err)
+			state := NewEditorState(100, 100, nil, nil)
+			state.documentBuffer.textTree = textTree
+			state.documentBuffer.cursor = cursorState{position: tc.cursorPos}
+			state.documentBuffer.autoPair = tc.autoPair
+			deleted := DeleteEmptyAutoPairAtCursor(state)
+			assert.Equal(t, tc.expectDeleted, deleted)
+			assert.Equal(t, tc.expectedText, textTree.String())
+			assert.Equal(t, tc.expectedCursor, state.documentBuffer.cursor.position)
+		})
+	}
+}
+
+func TestAutoPairsFromConfig(t *testing.T) {
+	pairs := autoPairsFromConfig(config.DefaultAutoPairs)
+	assert.Equal(t, []autoPair{
+		{open: '(', close: ')'},
+		{open: '[', close: ']'},
+		{open: '{', close: '}'},
+		{open: '"', close: '"'},
+		{open: '\'', close: '\''},
+	}, pairs)
+}
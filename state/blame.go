@@ -0,0 +1,327 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/aretext/aretext/file"
+	"github.com/aretext/aretext/syntax"
+	"github.com/aretext/aretext/text"
+)
+
+// blameAuthorMaxWidth is the maximum number of characters of the author displayed in the blame view.
+const blameAuthorMaxWidth = 20
+
+// blameState tracks the commit that last changed each line of a document.
+// The blame is loaded from the file on disk, so lines are mapped through unsaved edits
+// by aligning the document with the blamed text.  Edits mark the mapping stale,
+// and it is recalculated the next time it's needed.
+type blameState struct {
+	lines      []file.BlameLine
+	blamedText string
+	lineIdx    []int // Index in lines for each line in the document, or -1 if the line changed.
+	stale      bool
+}
+
+func newBlameState(lines []file.BlameLine) *blameState {
+	// Every line ends with a line feed when aligning to the document, as for git changes.
+	var sb strings.Builder
+	for _, line := range lines {
+		sb.WriteString(line.Text)
+		sb.WriteString("\n")
+	}
+	return &blameState{
+		lines:      lines,
+		blamedText: sb.String(),
+		stale:      true,
+	}
+}
+
+// markStale indicates that the document was edited, so the lines must be aligned again.
+func (b *blameState) markStale() {
+	if b != nil {
+		b.stale = true
+	}
+}
+
+// update aligns the document with the blamed text if the document was edited since the last update.
+func (b *blameState) update(tree *text.Tree) {
+	if !b.stale {
+		return
+	}
+
+	b.stale = false
+	b.lineIdx = make([]int, tree.NumLines())
+	for i := range b.lineIdx {
+		b.lineIdx[i] = -1
+	}
+
+	treeReader := tree.ReaderAtPosition(0)
+	matches, err := text.Align(strings.NewReader(b.blamedText), io.MultiReader(&treeReader, strings.NewReader("\n")))
+	if err != nil {
+		log.Printf("Error aligning blame: %v\n", err)
+		return
+	}
+
+	for _, m := range matches {
+		if m.RightLineNum < uint64(len(b.lineIdx)) {
+			b.lineIdx[m.RightLineNum] = int(m.LeftLineNum)
+		}
+	}
+}
+
+// lineForLineNum returns the blame for a line in the document.
+// This returns false if the line changed since the blame was loaded.
+func (b *blameState) lineForLineNum(tree *text.Tree, lineNum uint64) (file.BlameLine, bool) {
+	b.update(tree)
+	if lineNum >= uint64(len(b.lineIdx)) || b.lineIdx[lineNum] < 0 {
+		return file.BlameLine{}, false
+	}
+	return b.lines[b.lineIdx[lineNum]], true
+}
+
+// BlameForCursorLine returns a summary of the commit that last changed the line under the cursor.
+// This is empty unless the blame is configured to display in the status bar and has been loaded.
+func (s *BufferState) BlameForCursorLine() string {
+	if !s.showBlame || s.blame == nil {
+		return ""
+	}
+	lineNum := s.textTree.LineNumForPosition(s.cursor.position)
+	line, ok := s.blame.lineForLineNum(s.textTree, lineNum)
+	return formatBlameSummary(line, ok)
+}
+
+func formatBlameSummary(line file.BlameLine, ok bool) string {
+	if !ok || !line.IsCommitted() {
+		return "Not committed yet"
+	}
+	return fmt.Sprintf("%s %s, %s: %s", shortCommitHash(line), line.Author, line.AuthorTime.Format("2006-01-02"), line.Summary)
+}
+
+func shortCommitHash(line file.BlameLine) string {
+	if len(line.CommitHash) > 7 {
+		return line.CommitHash[:7]
+	}
+	return line.CommitHash
+}
+
+// loadBlameIfShown loads the blame for the current document if the blame is configured to display in the status bar.
+// Unlike a task, this runs in a goroutine that doesn't block user input.  Errors are logged, but not displayed,
+// since every file that isn't tracked in a git repository would show an error when opened.
+func loadBlameIfShown(state *EditorState) {
+	buffer := state.documentBuffer
+	if !buffer.showBlame || buffer.blame != nil || checkCanBlame(buffer) != nil {
+		return
+	}
+
+	// The undo log is replaced whenever the document is loaded, so it identifies this load.
+	path := buffer.fileWatcher.Path()
+	undoLog := buffer.undoLog
+	resultChan := state.backgroundResultChan
+	log.Printf("Loading blame for '%s' in the background...\n", path)
+	go func() {
+		lines, err := file.LoadGitBlame(context.Background(), path)
+		resultChan <- func(state *EditorState) {
+			if buffer.undoLog != undoLog {
+				// The document was reloaded while git was running, so discard the result.
+				return
+			}
+
+			if err != nil {
+				log.Printf("Could not load blame for '%s': %v\n", path, err)
+				return
+			}
+
+			log.Printf("Loaded blame for %d lines of '%s'\n", len(lines), path)
+			buffer.blame = newBlameState(lines)
+		}
+	}()
+}
+
+// loadBlameInBackground runs "git blame" for the current document in a task that the user can cancel.
+// Once the blame loads, onLoad is called with the blame set for the document.
+func loadBlameInBackground(state *EditorState, onLoad func(*EditorState)) {
+	buffer := state.documentBuffer
+	path := buffer.fileWatcher.Path()
+	if err := checkCanBlame(buffer); err != nil {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  fmt.Sprintf("Could not load blame: %s", err),
+		})
+		return
+	}
+
+	log.Printf("Scheduling task to load blame for '%s'...\n", path)
+	StartTask(state, func(ctx context.Context) func(*EditorState) {
+		lines, err := file.LoadGitBlame(ctx, path)
+		return func(state *EditorState) {
+			if err != nil {
+				log.Printf("Error loading blame for '%s': %v\n", path, err)
+				SetStatusMsg(state, StatusMsg{
+					Style: StatusMsgStyleError,
+					Text:  fmt.Sprintf("Could not load blame: %s", errors.Cause(err)),
+				})
+				return
+			}
+
+			log.Printf("Loaded blame for %d lines of '%s'\n", len(lines), path)
+			buffer.blame = newBlameState(lines)
+			if state.documentBuffer == buffer {
+				onLoad(state)
+			}
+		}
+	})
+}
+
+func checkCanBlame(buffer *BufferState) error {
+	if buffer.fileWatcher.Path() == "" {
+		return errors.New("Document has no file")
+	} else if buffer.fileFormat.Hex {
+		return errors.New("Cannot blame a document in hex mode")
+	} else if buffer.pager != nil {
+		return errors.New("Cannot blame a large file")
+	}
+	return nil
+}
+
+// ShowBlameForLine shows the author, date, and summary of the commit that last changed the line under the cursor.
+func ShowBlameForLine(state *EditorState) {
+	if state.documentBuffer.blame == nil {
+		loadBlameInBackground(state, ShowBlameForLine)
+		return
+	}
+
+	buffer := state.documentBuffer
+	lineNum := buffer.textTree.LineNumForPosition(buffer.cursor.position)
+	line, ok := buffer.blame.lineForLineNum(buffer.textTree, lineNum)
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  formatBlameSummary(line, ok),
+	})
+}
+
+// blameViewState is set for a buffer that displays the blame for every line of another buffer.
+type blameViewState struct {
+	sourceBuffer *BufferState
+}
+
+// ToggleBlameView shows the current document with each line annotated by the commit that last changed it.
+// If the blame view is already displayed, this returns to the original document.
+func ToggleBlameView(state *EditorState) {
+	if state.documentBuffer.blameView != nil {
+		closeBlameView(state)
+		return
+	}
+
+	if state.documentBuffer.diffView != nil {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  "Could not show blame: Cannot blame a diff view",
+		})
+		return
+	}
+
+	if state.documentBuffer.blame == nil {
+		loadBlameInBackground(state, ToggleBlameView)
+		return
+	}
+
+	showBlameView(state)
+}
+
+// showBlameView replaces the current document with a read-only view of its blame.
+// The blame view has the same lines as the document, so the cursor stays on the same line.
+func showBlameView(state *EditorState) {
+	sourceBuffer := state.documentBuffer
+	blameText := formatBlameView(sourceBuffer)
+	tree, err := text.NewTreeFromString(blameText)
+	if err != nil {
+		// Should never happen because the document text is valid UTF-8.
+		log.Printf("Error showing blame view: %v\n", err)
+		return
+	}
+
+	cursorLineNum := sourceBuffer.textTree.LineNumForPosition(sourceBuffer.cursor.position)
+	buffer := newBufferState(sourceBuffer.view)
+	buffer.textTree = tree
+	buffer.tabSize = sourceBuffer.tabSize
+	buffer.showTabs = sourceBuffer.showTabs
+	buffer.showSpaces = sourceBuffer.showSpaces
+	buffer.highlightCursorLine = sourceBuffer.highlightCursorLine
+	buffer.lineWrapAllowCharBreaks = sourceBuffer.lineWrapAllowCharBreaks
+	buffer.readOnly = true
+	buffer.blameView = &blameViewState{sourceBuffer: sourceBuffer}
+	buffer.view.textOrigin = tree.LineStartPosition(sourceBuffer.textTree.LineNumForPosition(sourceBuffer.view.textOrigin))
+	setSyntaxAndRetokenize(buffer, syntax.LanguagePlaintext)
+	switchToBuffer(state, buffer)
+	moveCursorToLineStart(state, cursorLineNum)
+
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  fmt.Sprintf("Showing blame for %s", file.RelativePathCwd(sourceBuffer.fileWatcher.Path())),
+	})
+}
+
+// formatBlameView annotates each line of the document with the commit hash, author, and date
+// of the commit that last changed it.
+func formatBlameView(buffer *BufferState) string {
+	tree := buffer.textTree
+	numLines := tree.NumLines()
+	lines := make([]file.BlameLine, numLines)
+	committed := make([]bool, numLines)
+	authorWidth := len("Not committed yet")
+	for n := uint64(0); n < numLines; n++ {
+		line, ok := buffer.blame.lineForLineNum(tree, n)
+		if ok && line.IsCommitted() {
+			line.Author = truncateString(line.Author, blameAuthorMaxWidth)
+			if w := len([]rune(line.Author)); w > authorWidth {
+				authorWidth = w
+			}
+			lines[n], committed[n] = line, true
+		}
+	}
+
+	var sb strings.Builder
+	for n, lineText := range strings.Split(tree.String(), "\n") {
+		if committed[n] {
+			line := lines[n]
+			fmt.Fprintf(&sb, "%s %-*s %s | ", shortCommitHash(line), authorWidth, line.Author, line.AuthorTime.Format("2006-01-02"))
+		} else {
+			fmt.Fprintf(&sb, "%s %-*s %s | ", strings.Repeat("0", 7), authorWidth, "Not committed yet", strings.Repeat(" ", 10))
+		}
+		sb.WriteString(lineText)
+		if uint64(n+1) < numLines {
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
+
+func truncateString(s string, maxLen int) string {
+	runes := []rune(s)
+	if len(runes) > maxLen {
+		return string(runes[:maxLen])
+	}
+	return s
+}
+
+// closeBlameView returns from the blame view to its source document, with the cursor on the same line.
+func closeBlameView(state *EditorState) {
+	sourceBuffer := state.documentBuffer.blameView.sourceBuffer
+	cursorLineNum := state.documentBuffer.textTree.LineNumForPosition(state.documentBuffer.cursor.position)
+	if findBackgroundBuffer(state, sourceBuffer.fileWatcher.Path()) != sourceBuffer {
+		// The source document was closed or reloaded while the blame view was displayed.
+		LoadDocument(state, sourceBuffer.fileWatcher.Path(), false, func(LocatorParams) uint64 { return 0 })
+		return
+	}
+
+	switchToBuffer(state, sourceBuffer)
+	if cursorLineNum < sourceBuffer.textTree.NumLines() {
+		moveCursorToLineStart(state, cursorLineNum)
+	}
+}
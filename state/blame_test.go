@@ -0,0 +1,152 @@
+package state
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/config"
+)
+
+func applyTaskResult(t *testing.T, state *EditorState) {
+	select {
+	case action := <-state.TaskResultChan():
+		action(state)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "Timed out")
+	}
+}
+
+func TestShowBlameForLine(t *testing.T) {
+	path := createTestFileInGitRepo(t, "abc\ndef\nghi\n")
+
+	state := NewEditorState(100, 100, nil, nil)
+	defer Quit(state)
+	LoadDocument(state, path, true, startOfDocLocator)
+
+	// The first blame loads in a task.
+	MoveCursor(state, func(LocatorParams) uint64 { return 4 })
+	ShowBlameForLine(state)
+	assert.Equal(t, InputModeTask, state.InputMode())
+	applyTaskResult(t, state)
+	assert.Equal(t, InputModeNormal, state.InputMode())
+	assert.Equal(t, StatusMsgStyleSuccess, state.StatusMsg().Style)
+	assert.Regexp(t, "^[0-9a-f]{7} test, [0-9-]{10}: initial commit$", state.StatusMsg().Text)
+
+	// Lines are mapped through unsaved edits.
+	MoveCursor(state, startOfDocLocator)
+	InsertNewline(state)
+	MoveCursor(state, func(LocatorParams) uint64 { return 5 })
+	ShowBlameForLine(state)
+	assert.Regexp(t, "^[0-9a-f]{7} test, [0-9-]{10}: initial commit$", state.StatusMsg().Text)
+
+	// Changed lines aren't committed.
+	MoveCursor(state, startOfDocLocator)
+	ShowBlameForLine(state)
+	assert.Equal(t, StatusMsg{Style: StatusMsgStyleSuccess, Text: "Not committed yet"}, state.StatusMsg())
+}
+
+func TestShowBlameForLineUntrackedFile(t *testing.T) {
+	path, cleanup := createTestFile(t, "abc\n")
+	defer cleanup()
+
+	state := NewEditorState(100, 100, nil, nil)
+	defer Quit(state)
+	LoadDocument(state, path, true, startOfDocLocator)
+	ShowBlameForLine(state)
+	applyTaskResult(t, state)
+	assert.Equal(t, StatusMsgStyleError, state.StatusMsg().Style)
+	assert.Nil(t, state.documentBuffer.blame)
+}
+
+func TestBlameForCursorLineInStatusBar(t *testing.T) {
+	path := createTestFileInGitRepo(t, "abc\n")
+
+	state := NewEditorState(100, 100, nil, nil)
+	defer Quit(state)
+	LoadDocument(state, path, true, startOfDocLocator)
+	assert.Equal(t, "", state.documentBuffer.BlameForCursorLine())
+
+	// The blame is shown only if configured.
+	state.documentBuffer.showBlame = true
+	ShowBlameForLine(state)
+	applyTaskResult(t, state)
+	assert.Regexp(t, "^[0-9a-f]{7} test, [0-9-]{10}: initial commit$", state.documentBuffer.BlameForCursorLine())
+}
+
+func showBlameConfigRuleSet() config.RuleSet {
+	return config.RuleSet{
+		{
+			Name:    "showBlame",
+			Pattern: "**",
+			Config:  map[string]any{"showBlame": true},
+		},
+	}
+}
+
+func TestLoadBlameIfShown(t *testing.T) {
+	path := createTestFileInGitRepo(t, "abc\n")
+
+	state := NewEditorState(100, 100, showBlameConfigRuleSet(), nil)
+	defer Quit(state)
+	LoadDocument(state, path, true, startOfDocLocator)
+
+	// The blame loads in the background without blocking input, along with the git HEAD version.
+	assert.Equal(t, InputModeNormal, state.InputMode())
+	applyBackgroundResult(t, state)
+	applyBackgroundResult(t, state)
+	assert.Regexp(t, "^[0-9a-f]{7} test, [0-9-]{10}: initial commit$", state.documentBuffer.BlameForCursorLine())
+}
+
+func TestLoadBlameIfShownUntrackedFile(t *testing.T) {
+	path, cleanup := createTestFile(t, "abc\n")
+	defer cleanup()
+
+	state := NewEditorState(100, 100, showBlameConfigRuleSet(), nil)
+	defer Quit(state)
+	LoadDocument(state, path, true, startOfDocLocator)
+	applyBackgroundResult(t, state)
+	applyBackgroundResult(t, state)
+
+	// The error isn't displayed, since many files aren't tracked in git.
+	assert.Equal(t, InputModeNormal, state.InputMode())
+	assert.Nil(t, state.documentBuffer.blame)
+	assert.Equal(t, StatusMsgStyleSuccess, state.StatusMsg().Style)
+	assert.Contains(t, state.StatusMsg().Text, "Opened")
+}
+
+func TestToggleBlameView(t *testing.T) {
+	path := createTestFileInGitRepo(t, "abc\ndef\nghi\n")
+
+	state := NewEditorState(100, 100, nil, nil)
+	defer Quit(state)
+	LoadDocument(state, path, true, startOfDocLocator)
+	sourceBuffer := state.documentBuffer
+	MoveCursor(state, func(LocatorParams) uint64 { return 4 })
+	InsertRune(state, 'x')
+	MoveCursor(state, func(LocatorParams) uint64 { return 9 })
+
+	ToggleBlameView(state)
+	applyTaskResult(t, state)
+	require.NotNil(t, state.documentBuffer.blameView)
+	assert.True(t, state.documentBuffer.readOnly)
+
+	lines := strings.Split(state.documentBuffer.textTree.String(), "\n")
+	require.Equal(t, 3, len(lines))
+	assert.Regexp(t, `^[0-9a-f]{7} test              [0-9-]{10} \| abc$`, lines[0])
+	assert.Equal(t, "0000000 Not committed yet            | xdef", lines[1])
+	assert.Regexp(t, `^[0-9a-f]{7} test              [0-9-]{10} \| ghi$`, lines[2])
+
+	// The cursor stays on the same line.
+	assert.Equal(t, uint64(2), state.documentBuffer.textTree.LineNumForPosition(state.documentBuffer.cursor.position))
+	MoveCursor(state, func(p LocatorParams) uint64 { return p.TextTree.LineStartPosition(1) })
+
+	ToggleBlameView(state)
+	assert.Equal(t, sourceBuffer, state.documentBuffer)
+	assert.Nil(t, state.documentBuffer.blameView)
+	assert.Equal(t, uint64(4), state.documentBuffer.cursor.position)
+	assert.Equal(t, "abc\nxdef\nghi", state.documentBuffer.textTree.String())
+}
@@ -0,0 +1,145 @@
+package state
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/aretext/aretext/file"
+	"github.com/aretext/aretext/menu"
+)
+
+// findBackgroundBuffer returns the background buffer for the document at path, if it is loaded.
+func findBackgroundBuffer(state *EditorState, path string) *BufferState {
+	if path == "" {
+		return nil
+	}
+	for _, buffer := range state.backgroundBuffers {
+		if buffer.fileWatcher.Path() == path {
+			return buffer
+		}
+	}
+	return nil
+}
+
+// moveCurrentBufferToBackground keeps the current buffer loaded so the user can switch back to it later.
+// Buffers without a file or unsaved changes are closed instead, since there's nothing to switch back to,
+// unless another pane displays the buffer.
+func moveCurrentBufferToBackground(state *EditorState) {
+	buffer := state.documentBuffer
+	path := buffer.fileWatcher.Path()
+	fileExists := buffer.pager != nil || buffer.fileWatcher.Checksum() != ""
+	if !buffer.hasUnsavedChanges() && (path == "" || !fileExists) && !isBufferInInactivePane(state, buffer) {
+		closeBuffer(buffer)
+		return
+	}
+
+	// Protect unsaved changes in case the editor exits unexpectedly before the user switches back.
+	writeRecoverySnapshot(state, buffer)
+	buffer.selector.Clear()
+	state.backgroundBuffers = append(state.backgroundBuffers, buffer)
+}
+
+// switchToBuffer makes a background buffer the current document.
+func switchToBuffer(state *EditorState, buffer *BufferState) {
+	CancelTaskIfRunning(state)
+
+	for i, b := range state.backgroundBuffers {
+		if b == buffer {
+			state.backgroundBuffers = append(state.backgroundBuffers[:i], state.backgroundBuffers[i+1:]...)
+			break
+		}
+	}
+
+	// The screen may have been resized since the buffer was last displayed.
+	view := state.documentBuffer.view
+	moveCurrentBufferToBackground(state)
+	buffer.view.x, buffer.view.y = view.x, view.y
+	buffer.view.width, buffer.view.height = view.width, view.height
+	state.documentBuffer = buffer
+	ScrollViewToCursor(state)
+
+	path := buffer.fileWatcher.Path()
+	resetStateForCurrentDocument(state, state.configRuleSet.ConfigForPath(path))
+	log.Printf("Switched to buffer for '%s'\n", path)
+}
+
+// closeBuffer releases resources held by a buffer that is no longer loaded.
+func closeBuffer(buffer *BufferState) {
+	buffer.fileWatcher.Stop()
+	if buffer.pager != nil {
+		if err := buffer.pager.Close(); err != nil {
+			log.Printf("Error closing large file: %v\n", err)
+		}
+		buffer.pager = nil
+	}
+}
+
+// allBuffers returns the current buffer followed by every background buffer.
+func allBuffers(state *EditorState) []*BufferState {
+	buffers := make([]*BufferState, 0, len(state.backgroundBuffers)+1)
+	buffers = append(buffers, state.documentBuffer)
+	buffers = append(buffers, state.backgroundBuffers...)
+	return buffers
+}
+
+// AbortIfAnyUnsavedChanges executes a function only if no loaded buffer has unsaved changes.
+// If a background buffer has unsaved changes, the error message identifies its file.
+func AbortIfAnyUnsavedChanges(state *EditorState, f func(*EditorState)) {
+	for _, buffer := range allBuffers(state) {
+		if !buffer.hasUnsavedChanges() {
+			continue
+		}
+
+		log.Printf("Aborting operation because '%s' has unsaved changes\n", buffer.fileWatcher.Path())
+		name := "Document"
+		if buffer != state.documentBuffer {
+			name = bufferMenuItemName(buffer)
+		}
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  fmt.Sprintf("%s has unsaved changes - either save the changes or force-quit", name),
+		})
+		return
+	}
+
+	f(state)
+}
+
+// ShowBufferMenu displays a menu for switching to another loaded buffer.
+func ShowBufferMenu(state *EditorState) {
+	items := make([]menu.Item, 0, len(state.backgroundBuffers)+1)
+	for _, buffer := range allBuffers(state) {
+		buffer := buffer
+		name := bufferMenuItemName(buffer)
+		if buffer.hasUnsavedChanges() {
+			name += " [modified]"
+		}
+		items = append(items, menu.Item{
+			Name: name,
+			Action: func(state *EditorState) {
+				if buffer == state.documentBuffer {
+					return
+				}
+				timelineState := currentTimelineState(state)
+				switchToBuffer(state, buffer)
+				if !timelineState.Empty() {
+					state.fileTimeline.TransitionFrom(timelineState)
+				}
+				SetStatusMsg(state, StatusMsg{
+					Style: StatusMsgStyleSuccess,
+					Text:  fmt.Sprintf("Switched to %s", bufferMenuItemName(buffer)),
+				})
+			},
+		})
+	}
+
+	ShowMenu(state, MenuStyleBuffer, items)
+}
+
+func bufferMenuItemName(buffer *BufferState) string {
+	path := buffer.fileWatcher.Path()
+	if path == "" {
+		return "[untitled]"
+	}
+	return file.RelativePathCwd(path)
+}
@@ -0,0 +1,130 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/file"
+)
+
+func TestSwitchBufferPreservesUnsavedChanges(t *testing.T) {
+	path, cleanup := createTestFile(t, "abc")
+	defer cleanup()
+	path2, cleanup2 := createTestFile(t, "xyz")
+	defer cleanup2()
+
+	// Make an unsaved change to the first document, then open another document.
+	state := NewEditorState(100, 100, nil, nil)
+	defer Quit(state)
+	LoadDocument(state, path, true, startOfDocLocator)
+	MoveCursor(state, func(LocatorParams) uint64 { return 1 })
+	InsertRune(state, 'x')
+	LoadDocument(state, path2, true, startOfDocLocator)
+	assert.Equal(t, "xyz", state.documentBuffer.textTree.String())
+	assert.Equal(t, 1, len(state.backgroundBuffers))
+
+	// Return to the first document, which should still have the change and cursor position.
+	LoadPrevDocument(state)
+	assert.Equal(t, path, state.documentBuffer.fileWatcher.Path())
+	assert.Equal(t, "axbc", state.documentBuffer.textTree.String())
+	assert.Equal(t, uint64(2), state.documentBuffer.cursor.position)
+	assert.True(t, state.documentBuffer.hasUnsavedChanges())
+
+	// The undo history is preserved as well.
+	Undo(state)
+	assert.Equal(t, "abc", state.documentBuffer.textTree.String())
+
+	// The second document is unchanged, so it remains loaded in the background.
+	require.Equal(t, 1, len(state.backgroundBuffers))
+	assert.Equal(t, path2, state.backgroundBuffers[0].fileWatcher.Path())
+}
+
+func TestShowBufferMenu(t *testing.T) {
+	path, cleanup := createTestFile(t, "abc")
+	defer cleanup()
+	path2, cleanup2 := createTestFile(t, "xyz")
+	defer cleanup2()
+
+	state := NewEditorState(100, 100, nil, nil)
+	defer Quit(state)
+	LoadDocument(state, path, true, startOfDocLocator)
+	InsertRune(state, 'x')
+	LoadDocument(state, path2, true, startOfDocLocator)
+
+	ShowBufferMenu(state)
+	assert.True(t, state.Menu().Visible())
+	assert.Equal(t, MenuStyleBuffer, state.Menu().Style())
+
+	results, _ := state.Menu().SearchResults()
+	names := make([]string, 0, len(results))
+	for _, r := range results {
+		names = append(names, r.Name)
+	}
+	assert.ElementsMatch(t, []string{
+		file.RelativePathCwd(path) + " [modified]",
+		file.RelativePathCwd(path2),
+	}, names)
+
+	// Switch to the modified buffer.
+	for _, r := range "modified" {
+		AppendRuneToMenuSearch(state, r)
+	}
+	ExecuteSelectedMenuItem(state)
+	assert.Equal(t, path, state.documentBuffer.fileWatcher.Path())
+	assert.Equal(t, "xabc", state.documentBuffer.textTree.String())
+	assert.Contains(t, state.StatusMsg().Text, "Switched to")
+
+	// The previous document is available from the timeline.
+	LoadPrevDocument(state)
+	assert.Equal(t, path2, state.documentBuffer.fileWatcher.Path())
+}
+
+func TestAbortIfAnyUnsavedChanges(t *testing.T) {
+	path, cleanup := createTestFile(t, "abc")
+	defer cleanup()
+	path2, cleanup2 := createTestFile(t, "xyz")
+	defer cleanup2()
+
+	state := NewEditorState(100, 100, nil, nil)
+	defer Quit(state)
+	LoadDocument(state, path, true, startOfDocLocator)
+	InsertRune(state, 'x')
+	LoadDocument(state, path2, true, startOfDocLocator)
+
+	// The current document has no unsaved changes, but a background document does.
+	var called bool
+	AbortIfAnyUnsavedChanges(state, func(*EditorState) { called = true })
+	assert.False(t, called)
+	assert.Equal(t, StatusMsgStyleError, state.StatusMsg().Style)
+	assert.Contains(t, state.StatusMsg().Text, file.RelativePathCwd(path))
+
+	// Save the background document, then try again.
+	LoadDocument(state, path, true, startOfDocLocator)
+	SaveDocument(state)
+	AbortIfAnyUnsavedChanges(state, func(*EditorState) { called = true })
+	assert.True(t, called)
+}
+
+func TestQuitWithRecoverySnapshotAllBuffers(t *testing.T) {
+	path, cleanup := createTestFile(t, "abc")
+	defer cleanup()
+	path2, cleanup2 := createTestFile(t, "xyz")
+	defer cleanup2()
+	store := file.NewRecoveryStore(t.TempDir())
+
+	state := NewEditorState(100, 100, nil, nil)
+	SetRecoveryStore(state, store)
+	LoadDocument(state, path, true, startOfDocLocator)
+	InsertRune(state, 'x')
+	LoadDocument(state, path2, true, startOfDocLocator)
+	InsertRune(state, 'y')
+	QuitWithRecoverySnapshot(state)
+
+	for _, p := range []string{path, path2} {
+		snapshot, err := store.Load(p)
+		require.NoError(t, err)
+		assert.NotNil(t, snapshot)
+	}
+}
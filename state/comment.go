@@ -0,0 +1,166 @@
+package state
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aretext/aretext/cellwidth"
+	"github.com/aretext/aretext/locate"
+	"github.com/aretext/aretext/syntax"
+	"github.com/aretext/aretext/text/segment"
+)
+
+// commentLine describes the layout of a line that may be commented or uncommented.
+type commentLine struct {
+	startPos     uint64
+	indentEndPos uint64
+	endPos       uint64
+	indentCols   uint64
+	content      string // Text after the indentation, excluding the line ending.
+}
+
+func (cl commentLine) isBlank() bool {
+	return cl.indentEndPos == cl.endPos
+}
+
+func (cl commentLine) isCommented(cs syntax.CommentSyntax) bool {
+	if !strings.HasPrefix(cl.content, cs.Prefix) {
+		return false
+	}
+
+	if cs.Suffix != "" {
+		trimmed := strings.TrimRight(cl.content, " \t")
+		return len(trimmed) >= len(cs.Prefix)+len(cs.Suffix) && strings.HasSuffix(trimmed, cs.Suffix)
+	}
+
+	return true
+}
+
+// ToggleComment comments or uncomments every line from the cursor position to the line found by targetLineLoc.
+// If every non-blank line is already commented, the comments are removed.
+// Otherwise, every non-blank line is commented, with the comment prefix aligned
+// to the minimum indentation of the lines.
+func ToggleComment(state *EditorState, targetLineLoc Locator) {
+	buffer := state.documentBuffer
+	cs := buffer.CommentSyntax()
+	if cs.Prefix == "" {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  fmt.Sprintf("No comment syntax configured for %s", buffer.syntaxLanguage),
+		})
+		return
+	}
+
+	firstLine := buffer.textTree.LineNumForPosition(buffer.cursor.position)
+	targetPos := targetLineLoc(locatorParamsForBuffer(buffer))
+	lastLine := buffer.textTree.LineNumForPosition(targetPos)
+	if lastLine < firstLine {
+		firstLine, lastLine = lastLine, firstLine
+	}
+
+	allCommented, minIndentCols, hasNonBlankLine := true, uint64(0), false
+	for lineNum := firstLine; lineNum <= lastLine; lineNum++ {
+		cl := commentLineForLineNum(buffer, lineNum)
+		if cl.isBlank() {
+			continue
+		}
+
+		if !hasNonBlankLine || cl.indentCols < minIndentCols {
+			minIndentCols = cl.indentCols
+		}
+		hasNonBlankLine = true
+		allCommented = allCommented && cl.isCommented(cs)
+	}
+
+	if hasNonBlankLine {
+		for lineNum := firstLine; lineNum <= lastLine; lineNum++ {
+			// Recalculate the line after each edit, since the positions of later lines will have changed.
+			cl := commentLineForLineNum(buffer, lineNum)
+			if cl.isBlank() {
+				continue
+			}
+
+			if allCommented {
+				removeCommentFromLine(state, cl, cs)
+			} else {
+				addCommentToLine(state, cl, cs, minIndentCols)
+			}
+		}
+	}
+
+	startOfFirstLinePos := locate.StartOfLineNum(buffer.textTree, firstLine)
+	newCursorPos := locate.NextNonWhitespaceOrNewline(buffer.textTree, startOfFirstLinePos)
+	buffer.cursor = cursorState{position: newCursorPos}
+}
+
+func commentLineForLineNum(buffer *BufferState, lineNum uint64) commentLine {
+	tree := buffer.textTree
+	startPos := locate.StartOfLineNum(tree, lineNum)
+	indentEndPos := locate.NextNonWhitespaceOrNewline(tree, startPos)
+	endPos := locate.NextLineBoundary(tree, true, startPos)
+	return commentLine{
+		startPos:     startPos,
+		indentEndPos: indentEndPos,
+		endPos:       endPos,
+		indentCols:   offsetInLine(buffer, indentEndPos),
+		content:      copyText(tree, indentEndPos, endPos-indentEndPos),
+	}
+}
+
+func addCommentToLine(state *EditorState, cl commentLine, cs syntax.CommentSyntax, indentCols uint64) {
+	// Insert the suffix first so the positions in the line remain valid for inserting the prefix.
+	if cs.Suffix != "" {
+		mustInsertTextAtPosition(state, " "+cs.Suffix, cl.endPos, true)
+	}
+
+	pos := posAtIndentCol(state.documentBuffer, cl.startPos, cl.indentEndPos, indentCols)
+	mustInsertTextAtPosition(state, cs.Prefix+" ", pos, true)
+}
+
+func removeCommentFromLine(state *EditorState, cl commentLine, cs syntax.CommentSyntax) {
+	// Byte offsets within the line content, including the space after the prefix if present.
+	prefixEnd := len(cs.Prefix)
+	if strings.HasPrefix(cl.content[prefixEnd:], " ") {
+		prefixEnd++
+	}
+
+	// Delete the suffix first so the positions in the line remain valid for deleting the prefix.
+	if cs.Suffix != "" {
+		trimmed := strings.TrimRight(cl.content, " \t")
+		suffixStart := len(trimmed) - len(cs.Suffix)
+		if suffixStart > prefixEnd && trimmed[suffixStart-1] == ' ' {
+			suffixStart--
+		} else if suffixStart < prefixEnd {
+			prefixEnd = suffixStart
+		}
+		deletePos := cl.indentEndPos + uint64(utf8.RuneCountInString(trimmed[0:suffixStart]))
+		numToDelete := uint64(utf8.RuneCountInString(trimmed[suffixStart:]))
+		deleteRunes(state, deletePos, numToDelete, true)
+	}
+
+	numToDelete := uint64(utf8.RuneCountInString(cl.content[0:prefixEnd]))
+	deleteRunes(state, cl.indentEndPos, numToDelete, true)
+}
+
+// posAtIndentCol returns the position of the first grapheme cluster in the indentation
+// at or after the specified column offset.
+func posAtIndentCol(buffer *BufferState, startOfLinePos uint64, endOfIndentPos uint64, numCols uint64) uint64 {
+	var offset uint64
+	pos := startOfLinePos
+	reader := buffer.textTree.ReaderAtPosition(pos)
+	iter := segment.NewGraphemeClusterIter(reader)
+	seg := segment.Empty()
+	for pos < endOfIndentPos && offset < numCols {
+		err := iter.NextSegment(seg)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			panic(err)
+		}
+		offset += cellwidth.GraphemeClusterWidth(seg.Runes(), offset, buffer.tabSize)
+		pos += seg.NumRunes()
+	}
+	return pos
+}
@@ -0,0 +1,188 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/syntax"
+	"github.com/aretext/aretext/text"
+)
+
+func TestToggleComment(t *testing.T) {
+	testCases := []struct {
+		name                  string
+		language              syntax.Language
+		commentSyntaxOverride syntax.CommentSyntax
+		inputString           string
+		cursorPos             uint64
+		targetLinePos         uint64
+		expectedCursor        cursorState
+		expectedText          string
+		expectedStatusMsg     StatusMsg
+	}{
+		{
+			name:           "empty",
+			language:       syntax.LanguageGo,
+			inputString:    "",
+			expectedCursor: cursorState{position: 0},
+			expectedText:   "",
+		},
+		{
+			name:           "blank line",
+			language:       syntax.LanguageGo,
+			inputString:    "abc\n   \ndef",
+			cursorPos:      4,
+			targetLinePos:  4,
+			expectedCursor: cursorState{position: 7},
+			expectedText:   "abc\n   \ndef",
+		},
+		{
+			name:           "comment single line",
+			language:       syntax.LanguageGo,
+			inputString:    "abc\ndef\nghi",
+			cursorPos:      5,
+			targetLinePos:  5,
+			expectedCursor: cursorState{position: 4},
+			expectedText:   "abc\n// def\nghi",
+		},
+		{
+			name:           "uncomment single line",
+			language:       syntax.LanguageGo,
+			inputString:    "abc\n// def\nghi",
+			cursorPos:      5,
+			targetLinePos:  5,
+			expectedCursor: cursorState{position: 4},
+			expectedText:   "abc\ndef\nghi",
+		},
+		{
+			name:           "uncomment single line without space after prefix",
+			language:       syntax.LanguageGo,
+			inputString:    "//def",
+			expectedCursor: cursorState{position: 0},
+			expectedText:   "def",
+		},
+		{
+			name:           "comment multiple lines aligned to minimum indentation",
+			language:       syntax.LanguagePython,
+			inputString:    "    if x:\n        y()\n\n    z()",
+			cursorPos:      0,
+			targetLinePos:  23,
+			expectedCursor: cursorState{position: 4},
+			expectedText:   "    # if x:\n    #     y()\n\n    # z()",
+		},
+		{
+			name:           "comment multiple lines, target before cursor",
+			language:       syntax.LanguageRust,
+			inputString:    "a\nb\nc",
+			cursorPos:      4,
+			targetLinePos:  2,
+			expectedCursor: cursorState{position: 2},
+			expectedText:   "a\n// b\n// c",
+		},
+		{
+			name:           "comment multiple lines with tabs",
+			language:       syntax.LanguageGo,
+			inputString:    "\tfoo()\n\t\tbar()",
+			cursorPos:      0,
+			targetLinePos:  7,
+			expectedCursor: cursorState{position: 1},
+			expectedText:   "\t// foo()\n\t// \tbar()",
+		},
+		{
+			name:           "some lines already commented",
+			language:       syntax.LanguageGo,
+			inputString:    "// a\nb",
+			cursorPos:      0,
+			targetLinePos:  5,
+			expectedCursor: cursorState{position: 0},
+			expectedText:   "// // a\n// b",
+		},
+		{
+			name:           "uncomment multiple lines with different indentation",
+			language:       syntax.LanguageYaml,
+			inputString:    "# a:\n  # b: 1\n\n# c: 2",
+			cursorPos:      0,
+			targetLinePos:  16,
+			expectedCursor: cursorState{position: 0},
+			expectedText:   "a:\n  b: 1\n\nc: 2",
+		},
+		{
+			name:           "comment with suffix",
+			language:       syntax.LanguageMarkdown,
+			inputString:    "  abc\n    def",
+			cursorPos:      0,
+			targetLinePos:  6,
+			expectedCursor: cursorState{position: 2},
+			expectedText:   "  <!-- abc -->\n  <!--   def -->",
+		},
+		{
+			name:           "uncomment with suffix",
+			language:       syntax.LanguageMarkdown,
+			inputString:    "<!-- abc -->\n<!--def-->",
+			cursorPos:      0,
+			targetLinePos:  13,
+			expectedCursor: cursorState{position: 0},
+			expectedText:   "abc\ndef",
+		},
+		{
+			name:           "uncomment empty comment with suffix",
+			language:       syntax.LanguageMarkdown,
+			inputString:    "<!-- -->",
+			expectedCursor: cursorState{position: 0},
+			expectedText:   "",
+		},
+		{
+			name:     "comment syntax override",
+			language: syntax.LanguagePlaintext,
+			commentSyntaxOverride: syntax.CommentSyntax{
+				Prefix: ";",
+			},
+			inputString:    "abc",
+			expectedCursor: cursorState{position: 0},
+			expectedText:   "; abc",
+		},
+		{
+			name:           "no comment syntax",
+			language:       syntax.LanguagePlaintext,
+			inputString:    "abc",
+			expectedCursor: cursorState{position: 0},
+			expectedText:   "abc",
+			expectedStatusMsg: StatusMsg{
+				Style: StatusMsgStyleError,
+				Text:  "No comment syntax configured for plaintext",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			textTree, err := text.NewTreeFromString(tc.inputString)
+			require.NoError(t, err)
+			state := NewEditorState(100, 100, nil, nil)
+			state.documentBuffer.textTree = textTree
+			state.documentBuffer.syntaxLanguage = tc.language
+			state.documentBuffer.commentSyntaxOverride = tc.commentSyntaxOverride
+			state.documentBuffer.cursor = cursorState{position: tc.cursorPos}
+			targetLineLoc := func(p LocatorParams) uint64 { return tc.targetLinePos }
+			ToggleComment(state, targetLineLoc)
+			assert.Equal(t, tc.expectedCursor, state.documentBuffer.cursor)
+			assert.Equal(t, tc.expectedText, textTree.String())
+			assert.Equal(t, tc.expectedStatusMsg, state.statusMsg)
+		})
+	}
+}
+
+func TestToggleCommentUndo(t *testing.T) {
+	textTree, err := text.NewTreeFromString("abc\ndef")
+	require.NoError(t, err)
+	state := NewEditorState(100, 100, nil, nil)
+	state.documentBuffer.textTree = textTree
+	state.documentBuffer.syntaxLanguage = syntax.LanguageGo
+	CheckpointUndoLog(state)
+	ToggleComment(state, func(p LocatorParams) uint64 { return 4 })
+	assert.Equal(t, "// abc\n// def", textTree.String())
+	Undo(state)
+	assert.Equal(t, "abc\ndef", textTree.String())
+}
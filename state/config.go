@@ -10,6 +10,11 @@ func ToggleShowSpaces(s *EditorState) {
 	toggleFlagAndSetStatus(s, &s.documentBuffer.showSpaces, "Showing spaces", "Hiding spaces")
 }
 
+// ToggleShowIndentGuides shows or hides guides at each indentation level.
+func ToggleShowIndentGuides(s *EditorState) {
+	toggleFlagAndSetStatus(s, &s.documentBuffer.showIndentGuides, "Showing indent guides", "Hiding indent guides")
+}
+
 // ToggleTabExpand toggles whether tabs should be expanded to spaces.
 func ToggleTabExpand(s *EditorState) {
 	toggleFlagAndSetStatus(s, &s.documentBuffer.tabExpand, "Enabled tab expand", "Disabled tab expand")
@@ -20,6 +25,32 @@ func ToggleShowLineNumbers(s *EditorState) {
 	toggleFlagAndSetStatus(s, &s.documentBuffer.showLineNum, "Showing line numbers", "Hiding line numbers")
 }
 
+// ToggleRelativeLineNumbers switches between absolute and relative line numbers, showing line numbers if hidden.
+// Relative line numbers use the configured relative mode, or hybrid mode if the configuration is absolute.
+func ToggleRelativeLineNumbers(s *EditorState) {
+	buffer := s.documentBuffer
+	buffer.showLineNum = true
+
+	if buffer.lineNumMode != LineNumModeAbsolute {
+		buffer.lineNumMode = LineNumModeAbsolute
+		SetStatusMsg(s, StatusMsg{
+			Style: StatusMsgStyleSuccess,
+			Text:  "Showing absolute line numbers",
+		})
+		return
+	}
+
+	buffer.lineNumMode = LineNumModeHybrid
+	cfg := s.configRuleSet.ConfigForPath(buffer.fileWatcher.Path())
+	if mode := lineNumModeFromConfig(cfg.LineNumberMode); mode != LineNumModeAbsolute {
+		buffer.lineNumMode = mode
+	}
+	SetStatusMsg(s, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  "Showing relative line numbers",
+	})
+}
+
 // ToggleAutoIndent enables or disables auto-indent.
 func ToggleAutoIndent(s *EditorState) {
 	toggleFlagAndSetStatus(s, &s.documentBuffer.autoIndent, "Enabled auto-indent", "Disabled auto-indent")
@@ -142,3 +142,35 @@ func TestToggleShowLineNumbers(t *testing.T) {
 		})
 	}
 }
+
+func TestToggleRelativeLineNumbers(t *testing.T) {
+	state := NewEditorState(100, 100, nil, nil)
+	for _, r := range "a\nb\nc\nd" {
+		InsertRune(state, r)
+	}
+	MoveCursor(state, func(LocatorParams) uint64 { return 2 })
+	buffer := state.documentBuffer
+	lineNumsForDisplay := func() []uint64 {
+		var lineNums []uint64
+		for n := uint64(0); n < buffer.textTree.NumLines(); n++ {
+			lineNums = append(lineNums, buffer.LineNumForDisplay(n))
+		}
+		return lineNums
+	}
+	assert.Equal(t, []uint64{1, 2, 3, 4}, lineNumsForDisplay())
+
+	// Relative line numbers default to hybrid mode, and show line numbers if hidden.
+	ToggleRelativeLineNumbers(state)
+	assert.True(t, buffer.showLineNum)
+	assert.Equal(t, LineNumModeHybrid, buffer.lineNumMode)
+	assert.Equal(t, []uint64{1, 2, 1, 2}, lineNumsForDisplay())
+	assert.Equal(t, "Showing relative line numbers", state.StatusMsg().Text)
+
+	buffer.lineNumMode = LineNumModeRelative
+	assert.Equal(t, []uint64{1, 0, 1, 2}, lineNumsForDisplay())
+
+	ToggleRelativeLineNumbers(state)
+	assert.Equal(t, LineNumModeAbsolute, buffer.lineNumMode)
+	assert.Equal(t, []uint64{1, 2, 3, 4}, lineNumsForDisplay())
+	assert.Equal(t, "Showing absolute line numbers", state.StatusMsg().Text)
+}
@@ -67,17 +67,41 @@ func MoveCursor(state *EditorState, loc Locator) {
 }
 
 // MoveCursorToLineAbove moves the cursor up by the specified number of lines, preserving the offset within the line.
+// Each closed fold counts as a single line.
 func MoveCursorToLineAbove(state *EditorState, count uint64) {
 	buffer := state.documentBuffer
-	targetLineStartPos := locate.StartOfLineAbove(buffer.textTree, count, buffer.cursor.position)
-	moveCursorToLine(buffer, targetLineStartPos)
+	moveCursorToLine(buffer, startOfLineAboveSkippingFolds(buffer, count))
 }
 
 // MoveCursorToLineBelow moves the cursor down by the specified number of lines, preserving the offset within the line.
+// Each closed fold counts as a single line.
 func MoveCursorToLineBelow(state *EditorState, count uint64) {
 	buffer := state.documentBuffer
-	targetLineStartPos := locate.StartOfLineBelow(buffer.textTree, count, buffer.cursor.position)
-	moveCursorToLine(buffer, targetLineStartPos)
+	moveCursorToLine(buffer, startOfLineBelowSkippingFolds(buffer, count))
+}
+
+// MoveCursorToStartOfLineAbove moves the cursor to the start of a line above the cursor.
+// Each closed fold counts as a single line.
+func MoveCursorToStartOfLineAbove(state *EditorState, count uint64) {
+	buffer := state.documentBuffer
+	buffer.cursor = cursorState{position: startOfLineAboveSkippingFolds(buffer, count)}
+}
+
+// MoveCursorToStartOfLineBelow moves the cursor to the start of a line below the cursor.
+// Each closed fold counts as a single line.
+func MoveCursorToStartOfLineBelow(state *EditorState, count uint64) {
+	buffer := state.documentBuffer
+	buffer.cursor = cursorState{position: startOfLineBelowSkippingFolds(buffer, count)}
+}
+
+func startOfLineAboveSkippingFolds(buffer *BufferState, count uint64) uint64 {
+	lineNum := buffer.textTree.LineNumForPosition(buffer.cursor.position)
+	return buffer.textTree.LineStartPosition(lineNumAboveSkippingFolds(buffer, lineNum, count))
+}
+
+func startOfLineBelowSkippingFolds(buffer *BufferState, count uint64) uint64 {
+	lineNum := buffer.textTree.LineNumForPosition(buffer.cursor.position)
+	return buffer.textTree.LineStartPosition(lineNumBelowSkippingFolds(buffer, lineNum, count))
 }
 
 func moveCursorToLine(buffer *BufferState, targetLineStartPos uint64) {
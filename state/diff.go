@@ -0,0 +1,454 @@
+package state
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/aretext/aretext/file"
+	"github.com/aretext/aretext/syntax"
+	"github.com/aretext/aretext/text"
+)
+
+// diffContextLines is the number of unchanged lines displayed before and after each hunk.
+const diffContextLines = 3
+
+// diffViewState is set for a buffer that displays the unsaved changes of another buffer.
+type diffViewState struct {
+	sourceBuffer *BufferState
+	hunks        []diffViewHunk
+}
+
+// diffViewHunk is a hunk displayed in the diff view.
+type diffViewHunk struct {
+	hunk text.DiffHunk
+
+	// startLineNum and endLineNum are the range of lines in the diff view
+	// that display removed and added lines for the hunk.
+	startLineNum, endLineNum uint64
+
+	// diskText is the text of the hunk in the file on disk,
+	// and bufferText is the text of the hunk in the source buffer.
+	diskText, bufferText string
+}
+
+// ToggleDiffView shows the unsaved changes to the current document compared to the file on disk.
+// If the diff view is already displayed, this returns to the original document.
+func ToggleDiffView(state *EditorState) {
+	if state.documentBuffer.diffView != nil {
+		closeDiffView(state)
+		return
+	}
+
+	if err := showDiffView(state, 0); err != nil {
+		log.Printf("Error showing diff view: %v\n", err)
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  fmt.Sprintf("Could not show diff: %s", err),
+		})
+	}
+}
+
+// showDiffView replaces the current document with a diff view, moving the cursor to the hunk at hunkIdx.
+// If the document matches the file on disk, this sets a status message and leaves the document displayed.
+func showDiffView(state *EditorState, hunkIdx int) error {
+	sourceBuffer := state.documentBuffer
+	path := sourceBuffer.fileWatcher.Path()
+	if path == "" {
+		return errors.New("Document has no file")
+	} else if sourceBuffer.fileFormat.Hex {
+		return errors.New("Cannot diff a document in hex mode")
+	} else if sourceBuffer.pager != nil {
+		return errors.New("Cannot diff a large file")
+	}
+
+	diskText, err := loadDiskTextForDiff(state, path)
+	if err != nil {
+		return err
+	}
+
+	// Compare the text as it would be saved, including the POSIX end-of-file indicator.
+	bufferText := sourceBuffer.textTree.String()
+	if sourceBuffer.fileFormat.EndsWithNewline {
+		bufferText += "\n"
+	}
+
+	hunks, err := text.Diff(strings.NewReader(diskText), strings.NewReader(bufferText))
+	if err != nil {
+		return errors.Wrap(err, "text.Diff")
+	}
+
+	if len(hunks) == 0 {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleSuccess,
+			Text:  "No changes from the file on disk",
+		})
+		return nil
+	}
+
+	diskLines, bufferLines := text.SplitLines(diskText), text.SplitLines(bufferText)
+	diffText, diffView := formatDiff(file.RelativePathCwd(path), diskLines, bufferLines, hunks)
+	diffView.sourceBuffer = sourceBuffer
+
+	// The text tree doesn't include the POSIX end-of-file indicator,
+	// so remove it from hunks at the end of the document before reverting them.
+	for i := range diffView.hunks {
+		h := &diffView.hunks[i]
+		if h.hunk.LeftEndLineNum == uint64(len(diskLines)) && strings.HasSuffix(diskText, "\n") {
+			h.diskText = strings.TrimSuffix(h.diskText, "\n")
+		}
+		if h.hunk.RightEndLineNum == uint64(len(bufferLines)) && sourceBuffer.fileFormat.EndsWithNewline {
+			h.bufferText = strings.TrimSuffix(h.bufferText, "\n")
+		}
+	}
+
+	tree, err := text.NewTreeFromString(diffText)
+	if err != nil {
+		return errors.Wrap(err, "text.NewTreeFromString")
+	}
+
+	buffer := newBufferState(sourceBuffer.view)
+	buffer.textTree = tree
+	buffer.tabSize = sourceBuffer.tabSize
+	buffer.showTabs = sourceBuffer.showTabs
+	buffer.showSpaces = sourceBuffer.showSpaces
+	buffer.highlightCursorLine = sourceBuffer.highlightCursorLine
+	buffer.lineWrapAllowCharBreaks = sourceBuffer.lineWrapAllowCharBreaks
+	buffer.readOnly = true
+	buffer.diffView = diffView
+	buffer.view.textOrigin = 0
+	setSyntaxAndRetokenize(buffer, syntax.LanguageDiff)
+	switchToBuffer(state, buffer)
+
+	if hunkIdx >= len(diffView.hunks) {
+		hunkIdx = len(diffView.hunks) - 1
+	}
+	moveCursorToDiffHunk(state, diffView.hunks[hunkIdx])
+
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  fmt.Sprintf("Showing unsaved changes to %s", file.RelativePathCwd(path)),
+	})
+	return nil
+}
+
+// loadDiskTextForDiff returns the text of the file on disk, or an empty string if the file does not exist.
+// Unlike a loaded document, the text includes the POSIX end-of-file indicator.
+func loadDiskTextForDiff(state *EditorState, path string) (string, error) {
+	cfg := state.configRuleSet.ConfigForPath(path)
+	tree, watcher, format, err := loadFile(path, cfg, false)
+	if errors.Is(errors.Cause(err), fs.ErrNotExist) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+
+	// The diff view is a snapshot, so it doesn't need to watch the file for changes.
+	watcher.Stop()
+
+	if format.Hex {
+		return "", errors.New("File on disk is not valid text")
+	}
+
+	diskText := tree.String()
+	if format.EndsWithNewline {
+		diskText += "\n"
+	}
+	return diskText, nil
+}
+
+// closeDiffView returns from the diff view to its source document.
+// If the cursor is on a hunk, the cursor moves to the same hunk in the source document.
+func closeDiffView(state *EditorState) {
+	diffView := state.documentBuffer.diffView
+	sourceBuffer := diffView.sourceBuffer
+	hunk, ok := diffHunkAtCursor(state)
+	if findBackgroundBuffer(state, sourceBuffer.fileWatcher.Path()) != sourceBuffer {
+		// The source document was closed or reloaded while the diff view was displayed.
+		LoadDocument(state, sourceBuffer.fileWatcher.Path(), false, func(LocatorParams) uint64 { return 0 })
+		return
+	}
+
+	switchToBuffer(state, sourceBuffer)
+	if ok {
+		tree := sourceBuffer.textTree
+		sourceBuffer.cursor = cursorState{position: tree.LineStartPosition(hunk.hunk.RightStartLineNum)}
+		if sourceBuffer.cursor.position > tree.NumChars() {
+			sourceBuffer.cursor.position = tree.NumChars()
+		}
+		ScrollViewToCursor(state)
+	}
+}
+
+// NextDiffHunk moves the cursor to the next hunk in the diff view.
+// Outside a diff view, it moves to the next lines changed since the git HEAD commit.
+func NextDiffHunk(state *EditorState) {
+	if buffer := state.documentBuffer; buffer.diffView == nil && buffer.gitChanges != nil {
+		if lineNum, ok := nextGitChangeLineNum(state); ok {
+			moveCursorToLineStart(state, lineNum)
+			return
+		}
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  "No next hunk",
+		})
+		return
+	}
+
+	diffView := diffViewOrShowError(state)
+	if diffView == nil {
+		return
+	}
+
+	buffer := state.documentBuffer
+	cursorLineNum := buffer.textTree.LineNumForPosition(buffer.cursor.position)
+	for _, h := range diffView.hunks {
+		if h.startLineNum > cursorLineNum {
+			moveCursorToDiffHunk(state, h)
+			return
+		}
+	}
+
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleError,
+		Text:  "No next hunk",
+	})
+}
+
+// PrevDiffHunk moves the cursor to the previous hunk in the diff view.
+// If the cursor is within a hunk, it moves to the start of that hunk.
+// Outside a diff view, it moves to the previous lines changed since the git HEAD commit.
+func PrevDiffHunk(state *EditorState) {
+	if buffer := state.documentBuffer; buffer.diffView == nil && buffer.gitChanges != nil {
+		if lineNum, ok := prevGitChangeLineNum(state); ok {
+			moveCursorToLineStart(state, lineNum)
+			return
+		}
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  "No previous hunk",
+		})
+		return
+	}
+
+	diffView := diffViewOrShowError(state)
+	if diffView == nil {
+		return
+	}
+
+	buffer := state.documentBuffer
+	cursorLineNum := buffer.textTree.LineNumForPosition(buffer.cursor.position)
+	for i := len(diffView.hunks) - 1; i >= 0; i-- {
+		if h := diffView.hunks[i]; h.startLineNum < cursorLineNum {
+			moveCursorToDiffHunk(state, h)
+			return
+		}
+	}
+
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleError,
+		Text:  "No previous hunk",
+	})
+}
+
+// RevertDiffHunk discards the unsaved changes in the hunk under the cursor in the diff view.
+// The hunk in the source document is replaced by the text from the file on disk, and
+// the diff view is refreshed to show the remaining changes.
+func RevertDiffHunk(state *EditorState) {
+	diffView := diffViewOrShowError(state)
+	if diffView == nil {
+		return
+	}
+
+	hunk, ok := diffHunkAtCursor(state)
+	if !ok {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  "Cursor is not on a changed line",
+		})
+		return
+	}
+
+	sourceBuffer := diffView.sourceBuffer
+	if sourceBuffer.readOnly {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  "Document is read-only",
+		})
+		return
+	}
+
+	tree := sourceBuffer.textTree
+	startPos := tree.LineStartPosition(hunk.hunk.RightStartLineNum)
+	endPos := tree.LineStartPosition(hunk.hunk.RightEndLineNum)
+	if findBackgroundBuffer(state, sourceBuffer.fileWatcher.Path()) != sourceBuffer ||
+		endPos < startPos || endPos > tree.NumChars() ||
+		readDiffHunkText(tree, startPos, endPos) != hunk.bufferText {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  "Document changed since the diff was shown",
+		})
+		return
+	}
+
+	hunkIdx := 0
+	for i, h := range diffView.hunks {
+		if h.startLineNum <= hunk.startLineNum {
+			hunkIdx = i
+		}
+	}
+
+	switchToBuffer(state, sourceBuffer)
+	CheckpointUndoLog(state)
+	deleteRunes(state, startPos, endPos-startPos, true)
+	if err := insertTextAtPosition(state, hunk.diskText, startPos, true); err != nil {
+		log.Printf("Error reverting hunk: %v\n", err)
+	}
+	CheckpointUndoLog(state)
+	sourceBuffer.cursor = cursorState{position: startPos}
+	ScrollViewToCursor(state)
+	log.Printf("Reverted hunk at line %d\n", hunk.hunk.RightStartLineNum)
+
+	if err := showDiffView(state, hunkIdx); err != nil {
+		log.Printf("Error showing diff view: %v\n", err)
+	}
+
+	if state.documentBuffer == sourceBuffer {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleSuccess,
+			Text:  "Reverted hunk, no changes remaining",
+		})
+	} else {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleSuccess,
+			Text:  "Reverted hunk",
+		})
+	}
+}
+
+func diffViewOrShowError(state *EditorState) *diffViewState {
+	diffView := state.documentBuffer.diffView
+	if diffView == nil {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  "Not in a diff view",
+		})
+	}
+	return diffView
+}
+
+// diffHunkAtCursor returns the hunk with a removed or added line under the cursor.
+func diffHunkAtCursor(state *EditorState) (diffViewHunk, bool) {
+	buffer := state.documentBuffer
+	cursorLineNum := buffer.textTree.LineNumForPosition(buffer.cursor.position)
+	for _, h := range buffer.diffView.hunks {
+		if cursorLineNum >= h.startLineNum && cursorLineNum < h.endLineNum {
+			return h, true
+		}
+	}
+	return diffViewHunk{}, false
+}
+
+func moveCursorToDiffHunk(state *EditorState, h diffViewHunk) {
+	buffer := state.documentBuffer
+	buffer.cursor = cursorState{position: buffer.textTree.LineStartPosition(h.startLineNum)}
+	ScrollViewToCursor(state)
+}
+
+func readDiffHunkText(tree *text.Tree, startPos, endPos uint64) string {
+	var sb strings.Builder
+	reader := tree.ReaderAtPosition(startPos)
+	for pos := startPos; pos < endPos; pos++ {
+		r, _, err := reader.ReadRune()
+		if err != nil {
+			break
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// formatDiff formats hunks in unified diff format, with changes from the file on disk to the buffer.
+// Hunks separated by only a few unchanged lines are displayed together with shared context.
+func formatDiff(name string, diskLines, bufferLines []string, hunks []text.DiffHunk) (string, *diffViewState) {
+	var sb strings.Builder
+	var lineNum uint64
+	writeLine := func(prefix string, line string) {
+		sb.WriteString(prefix)
+		sb.WriteString(line)
+		lineNum++
+		if !strings.HasSuffix(line, "\n") {
+			sb.WriteString("\n\\ No newline at end of file\n")
+			lineNum++
+		}
+	}
+
+	writeLine("--- ", fmt.Sprintf("%s (on disk)\n", name))
+	writeLine("+++ ", fmt.Sprintf("%s (unsaved changes)\n", name))
+
+	diffView := &diffViewState{}
+	numDiskLines := uint64(len(diskLines))
+	for i := 0; i < len(hunks); {
+		// Group hunks whose context would overlap.
+		j := i + 1
+		for j < len(hunks) && hunks[j].LeftStartLineNum-hunks[j-1].LeftEndLineNum <= 2*diffContextLines {
+			j++
+		}
+		first, last := hunks[i], hunks[j-1]
+
+		leftStart := first.LeftStartLineNum - minUint64(first.LeftStartLineNum, diffContextLines)
+		leftEnd := minUint64(last.LeftEndLineNum+diffContextLines, numDiskLines)
+		rightStart := first.RightStartLineNum - (first.LeftStartLineNum - leftStart)
+		rightEnd := last.RightEndLineNum + (leftEnd - last.LeftEndLineNum)
+		writeLine("", fmt.Sprintf("@@ -%s +%s @@\n", formatDiffRange(leftStart, leftEnd), formatDiffRange(rightStart, rightEnd)))
+
+		leftLineNum := leftStart
+		for _, h := range hunks[i:j] {
+			for ; leftLineNum < h.LeftStartLineNum; leftLineNum++ {
+				writeLine(" ", diskLines[leftLineNum])
+			}
+
+			viewHunk := diffViewHunk{hunk: h, startLineNum: lineNum}
+			for n := h.LeftStartLineNum; n < h.LeftEndLineNum; n++ {
+				writeLine("-", diskLines[n])
+			}
+			for n := h.RightStartLineNum; n < h.RightEndLineNum; n++ {
+				writeLine("+", bufferLines[n])
+			}
+			viewHunk.endLineNum = lineNum
+			viewHunk.diskText = strings.Join(diskLines[h.LeftStartLineNum:h.LeftEndLineNum], "")
+			viewHunk.bufferText = strings.Join(bufferLines[h.RightStartLineNum:h.RightEndLineNum], "")
+			diffView.hunks = append(diffView.hunks, viewHunk)
+			leftLineNum = h.LeftEndLineNum
+		}
+
+		for ; leftLineNum < leftEnd; leftLineNum++ {
+			writeLine(" ", diskLines[leftLineNum])
+		}
+		i = j
+	}
+
+	return strings.TrimSuffix(sb.String(), "\n"), diffView
+}
+
+// formatDiffRange formats the lines [start, end) as a range in a unified diff hunk header.
+func formatDiffRange(start, end uint64) string {
+	n := end - start
+	if n == 0 {
+		// An empty range refers to the line before the change.
+		return fmt.Sprintf("%d,0", start)
+	} else if n == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, n)
+}
+
+func minUint64(a, b uint64) uint64 {
+	if a < b {
+		return a
+	}
+	return b
+}
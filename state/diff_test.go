@@ -0,0 +1,207 @@
+package state
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/file"
+	"github.com/aretext/aretext/text"
+)
+
+func TestToggleDiffView(t *testing.T) {
+	path, cleanup := createTestFile(t, "abc\ndef\nghi\n")
+	defer cleanup()
+
+	state := NewEditorState(100, 100, nil, nil)
+	defer Quit(state)
+	LoadDocument(state, path, true, startOfDocLocator)
+	MoveCursor(state, func(LocatorParams) uint64 { return 4 })
+	InsertRune(state, 'x')
+
+	// Show the diff view with the cursor on the first changed line.
+	ToggleDiffView(state)
+	require.NotNil(t, state.documentBuffer.diffView)
+	relPath := file.RelativePathCwd(path)
+	expectedText := fmt.Sprintf("--- %s (on disk)\n+++ %s (unsaved changes)\n@@ -1,3 +1,3 @@\n abc\n-def\n+xdef\n ghi", relPath, relPath)
+	assert.Equal(t, expectedText, state.documentBuffer.textTree.String())
+	assert.Equal(t, uint64(4), state.documentBuffer.textTree.LineNumForPosition(state.documentBuffer.cursor.position))
+	assert.True(t, state.documentBuffer.readOnly)
+
+	// Return to the document, with the cursor on the changed line.
+	ToggleDiffView(state)
+	assert.Nil(t, state.documentBuffer.diffView)
+	assert.Equal(t, path, state.documentBuffer.fileWatcher.Path())
+	assert.Equal(t, "abc\nxdef\nghi", state.documentBuffer.textTree.String())
+	assert.Equal(t, uint64(4), state.documentBuffer.cursor.position)
+	assert.Equal(t, 0, len(state.backgroundBuffers))
+}
+
+func TestToggleDiffViewNoChanges(t *testing.T) {
+	path, cleanup := createTestFile(t, "abc")
+	defer cleanup()
+
+	state := NewEditorState(100, 100, nil, nil)
+	defer Quit(state)
+	LoadDocument(state, path, true, startOfDocLocator)
+	ToggleDiffView(state)
+	assert.Nil(t, state.documentBuffer.diffView)
+	assert.Equal(t, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  "No changes from the file on disk",
+	}, state.StatusMsg())
+}
+
+func TestDiffHunkNavigation(t *testing.T) {
+	lines := make([]string, 20)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d\n", i)
+	}
+	path, cleanup := createTestFile(t, strings.Join(lines, ""))
+	defer cleanup()
+
+	state := NewEditorState(100, 100, nil, nil)
+	defer Quit(state)
+	LoadDocument(state, path, true, startOfDocLocator)
+
+	// Not in a diff view.
+	NextDiffHunk(state)
+	assert.Equal(t, StatusMsgStyleError, state.StatusMsg().Style)
+
+	// Change the first and last lines, which are far enough apart to display as separate hunks.
+	InsertRune(state, 'x')
+	MoveCursor(state, func(p LocatorParams) uint64 { return p.TextTree.LineStartPosition(19) })
+	InsertRune(state, 'y')
+	ToggleDiffView(state)
+	require.NotNil(t, state.documentBuffer.diffView)
+	require.Equal(t, 2, len(state.documentBuffer.diffView.hunks))
+
+	cursorLine := func() string {
+		tree := state.documentBuffer.textTree
+		lineNum := tree.LineNumForPosition(state.documentBuffer.cursor.position)
+		return strings.Split(tree.String(), "\n")[lineNum]
+	}
+	assert.Equal(t, "-line 0", cursorLine())
+
+	NextDiffHunk(state)
+	assert.Equal(t, "-line 19", cursorLine())
+
+	NextDiffHunk(state)
+	assert.Equal(t, StatusMsg{Style: StatusMsgStyleError, Text: "No next hunk"}, state.StatusMsg())
+	assert.Equal(t, "-line 19", cursorLine())
+
+	PrevDiffHunk(state)
+	assert.Equal(t, "-line 0", cursorLine())
+
+	PrevDiffHunk(state)
+	assert.Equal(t, StatusMsg{Style: StatusMsgStyleError, Text: "No previous hunk"}, state.StatusMsg())
+}
+
+func TestRevertDiffHunk(t *testing.T) {
+	lines := make([]string, 20)
+	for i := range lines {
+		lines[i] = fmt.Sprintf("line %d\n", i)
+	}
+	path, cleanup := createTestFile(t, strings.Join(lines, ""))
+	defer cleanup()
+
+	state := NewEditorState(100, 100, nil, nil)
+	defer Quit(state)
+	LoadDocument(state, path, true, startOfDocLocator)
+	InsertRune(state, 'x')
+	MoveCursor(state, func(p LocatorParams) uint64 { return p.TextTree.LineStartPosition(19) })
+	InsertRune(state, 'y')
+	ToggleDiffView(state)
+
+	// The cursor must be on a changed line.
+	MoveCursor(state, startOfDocLocator)
+	RevertDiffHunk(state)
+	assert.Equal(t, StatusMsg{Style: StatusMsgStyleError, Text: "Cursor is not on a changed line"}, state.StatusMsg())
+
+	// Revert the second hunk, and the diff view shows the remaining hunk.
+	NextDiffHunk(state)
+	NextDiffHunk(state)
+	RevertDiffHunk(state)
+	require.NotNil(t, state.documentBuffer.diffView)
+	assert.Equal(t, 1, len(state.documentBuffer.diffView.hunks))
+	assert.Equal(t, "Reverted hunk", state.StatusMsg().Text)
+	sourceBuffer := state.documentBuffer.diffView.sourceBuffer
+	assert.Equal(t, strings.TrimSuffix("xline 0\n"+strings.Join(lines[1:], ""), "\n"), sourceBuffer.textTree.String())
+
+	// Revert the last hunk, which returns to the document.
+	RevertDiffHunk(state)
+	assert.Nil(t, state.documentBuffer.diffView)
+	assert.Equal(t, strings.TrimSuffix(strings.Join(lines, ""), "\n"), state.documentBuffer.textTree.String())
+	assert.Equal(t, "Reverted hunk, no changes remaining", state.StatusMsg().Text)
+
+	// Each revert can be undone.
+	Undo(state)
+	assert.Equal(t, strings.TrimSuffix("xline 0\n"+strings.Join(lines[1:], ""), "\n"), state.documentBuffer.textTree.String())
+}
+
+func TestFormatDiff(t *testing.T) {
+	testCases := []struct {
+		name          string
+		diskText      string
+		bufferText    string
+		expectedText  string
+		expectedHunks []diffViewHunk
+	}{
+		{
+			name:         "added lines at start",
+			diskText:     "abc\n",
+			bufferText:   "xyz\nabc\n",
+			expectedText: "--- test.txt (on disk)\n+++ test.txt (unsaved changes)\n@@ -1 +1,2 @@\n+xyz\n abc",
+			expectedHunks: []diffViewHunk{
+				{
+					hunk:         text.DiffHunk{LeftStartLineNum: 0, LeftEndLineNum: 0, RightStartLineNum: 0, RightEndLineNum: 1},
+					startLineNum: 3,
+					endLineNum:   4,
+					bufferText:   "xyz\n",
+				},
+			},
+		},
+		{
+			name:         "removed all lines",
+			diskText:     "abc\ndef\n",
+			bufferText:   "",
+			expectedText: "--- test.txt (on disk)\n+++ test.txt (unsaved changes)\n@@ -1,2 +0,0 @@\n-abc\n-def",
+			expectedHunks: []diffViewHunk{
+				{
+					hunk:         text.DiffHunk{LeftStartLineNum: 0, LeftEndLineNum: 2, RightStartLineNum: 0, RightEndLineNum: 0},
+					startLineNum: 3,
+					endLineNum:   5,
+					diskText:     "abc\ndef\n",
+				},
+			},
+		},
+		{
+			name:         "no newline at end of file",
+			diskText:     "abc\ndef\n",
+			bufferText:   "abc\ndef",
+			expectedText: "--- test.txt (on disk)\n+++ test.txt (unsaved changes)\n@@ -1,2 +1,2 @@\n abc\n-def\n+def\n\\ No newline at end of file",
+			expectedHunks: []diffViewHunk{
+				{
+					hunk:         text.DiffHunk{LeftStartLineNum: 1, LeftEndLineNum: 2, RightStartLineNum: 1, RightEndLineNum: 2},
+					startLineNum: 4,
+					endLineNum:   7,
+					diskText:     "def\n",
+					bufferText:   "def",
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hunks, err := text.Diff(strings.NewReader(tc.diskText), strings.NewReader(tc.bufferText))
+			require.NoError(t, err)
+			diffText, diffView := formatDiff("test.txt", text.SplitLines(tc.diskText), text.SplitLines(tc.bufferText), hunks)
+			assert.Equal(t, tc.expectedText, diffText)
+			assert.Equal(t, tc.expectedHunks, diffView.hunks)
+		})
+	}
+}
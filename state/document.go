@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io/fs"
 	"log"
+	"os"
 	"sort"
 	"strings"
 	"time"
@@ -19,15 +20,33 @@ import (
 )
 
 // LoadDocument loads a file into the editor.
+// If the file is not valid text, it is loaded as a hex dump.
 func LoadDocument(state *EditorState, path string, requireExists bool, cursorLoc Locator) {
+	loadDocument(state, path, requireExists, false, cursorLoc)
+}
+
+// LoadDocumentAsHex loads a file into the editor as a hex dump.
+func LoadDocumentAsHex(state *EditorState, path string, requireExists bool, cursorLoc Locator) {
+	loadDocument(state, path, requireExists, true, cursorLoc)
+}
+
+func loadDocument(state *EditorState, path string, requireExists bool, hex bool, cursorLoc Locator) {
+	if buffer := state.documentBuffer; path == buffer.fileWatcher.Path() && (!hex || buffer.fileFormat.Hex) {
+		// The document is already open, so move the cursor without reloading it.
+		// Reloading would discard any unsaved changes.
+		MoveCursor(state, cursorLoc)
+		ScrollViewToCursor(state)
+		return
+	}
+
 	timelineState := currentTimelineState(state)
-	fileExists, err := loadDocumentAndResetState(state, path, requireExists)
+	fileExists, err := loadDocumentAndResetState(state, path, requireExists, hex)
 	if err != nil {
 		// If this is the first document loaded into the editor, set a watcher
 		// even if the load failed.  This retains the attempted path so the user
 		// can try saving or reloading the document later.
-		if state.fileWatcher.Path() == "" {
-			state.fileWatcher = file.NewWatcher(file.DefaultPollInterval, path, time.Time{}, 0, "")
+		if state.documentBuffer.fileWatcher.Path() == "" {
+			state.documentBuffer.fileWatcher = file.NewWatcher(file.DefaultPollInterval, path, time.Time{}, 0, "")
 		}
 
 		reportLoadError(state, err, path)
@@ -45,11 +64,14 @@ func LoadDocument(state *EditorState, path string, requireExists bool, cursorLoc
 	} else {
 		reportCreateSuccess(state, path)
 	}
+
+	showRecoveryMenuIfSnapshotExists(state)
+	loadBlameIfShown(state)
 }
 
 // ReloadDocument reloads the current document.
 func ReloadDocument(state *EditorState) {
-	path := state.fileWatcher.Path()
+	path := state.documentBuffer.fileWatcher.Path()
 
 	// Store the configuration we want to preserve.
 	oldTextTree := state.documentBuffer.textTree
@@ -58,12 +80,16 @@ func ReloadDocument(state *EditorState) {
 	oldCursorLineNum, oldCursorCol := locate.PosToLineNumAndCol(oldTextTree, state.documentBuffer.cursor.position)
 	oldSyntaxLanguage := state.documentBuffer.syntaxLanguage
 	oldAutoIndent := state.documentBuffer.autoIndent
+	oldAutoPair := state.documentBuffer.autoPair
 	oldShowTabs := state.documentBuffer.showTabs
 	oldShowSpaces := state.documentBuffer.showSpaces
+	oldShowIndentGuides := state.documentBuffer.showIndentGuides
 	oldShowLineNum := state.documentBuffer.showLineNum
+	oldLineNumMode := state.documentBuffer.lineNumMode
+	oldReadOnly := state.documentBuffer.readOnly
 
 	// Reload the document.
-	_, err := loadDocumentAndResetState(state, path, true)
+	_, err := loadDocumentAndResetState(state, path, true, state.documentBuffer.fileFormat.Hex)
 	if err != nil {
 		reportLoadError(state, err, path)
 		return
@@ -76,12 +102,16 @@ func ReloadDocument(state *EditorState) {
 	}
 
 	// Attempt to restore the original cursor and scroll positions, aligned to the new document.
+	// Aligning a large file would be too slow, so keep the same line numbers instead.
 	newTextTree := state.documentBuffer.textTree
-	newTreeReader := newTextTree.ReaderAtPosition(0)
-	oldReader := strings.NewReader(oldText)
-	lineMatches, err := text.Align(oldReader, &newTreeReader)
-	if err != nil {
-		panic(err) // Should never happen since we're reading from in-memory strings.
+	var lineMatches []text.LineMatch
+	if state.documentBuffer.pager == nil {
+		newTreeReader := newTextTree.ReaderAtPosition(0)
+		oldReader := strings.NewReader(oldText)
+		lineMatches, err = text.Align(oldReader, &newTreeReader)
+		if err != nil {
+			panic(err) // Should never happen since we're reading from in-memory strings.
+		}
 	}
 	state.documentBuffer.cursor.position = locate.LineNumAndColToPos(
 		newTextTree,
@@ -95,11 +125,16 @@ func ReloadDocument(state *EditorState) {
 
 	// Restore other configuration that might have been toggled with menu commands.
 	state.documentBuffer.autoIndent = oldAutoIndent
+	state.documentBuffer.autoPair = oldAutoPair
 	state.documentBuffer.showTabs = oldShowTabs
 	state.documentBuffer.showSpaces = oldShowSpaces
+	state.documentBuffer.showIndentGuides = oldShowIndentGuides
 	state.documentBuffer.showLineNum = oldShowLineNum
+	state.documentBuffer.lineNumMode = oldLineNumMode
+	state.documentBuffer.readOnly = oldReadOnly || state.documentBuffer.largeFile
 
 	reportReloadSuccess(state, path)
+	loadBlameIfShown(state)
 }
 
 func translateLineNum(lineMatches []text.LineMatch, lineNum uint64) uint64 {
@@ -131,7 +166,7 @@ func LoadPrevDocument(state *EditorState) {
 
 	timelineState := currentTimelineState(state)
 	path := prev.Path
-	_, err := loadDocumentAndResetState(state, path, false)
+	_, err := loadDocumentAndResetState(state, path, false, false)
 	if err != nil {
 		reportLoadError(state, err, path)
 		return
@@ -142,6 +177,7 @@ func LoadPrevDocument(state *EditorState) {
 		return locate.LineNumAndColToPos(p.TextTree, prev.LineNum, prev.Col)
 	})
 	reportOpenSuccess(state, path)
+	showRecoveryMenuIfSnapshotExists(state)
 }
 
 // LoadNextDocument loads the next document from the timeline in the editor.
@@ -158,7 +194,7 @@ func LoadNextDocument(state *EditorState) {
 
 	timelineState := currentTimelineState(state)
 	path := next.Path
-	_, err := loadDocumentAndResetState(state, path, false)
+	_, err := loadDocumentAndResetState(state, path, false, false)
 	if err != nil {
 		reportLoadError(state, err, path)
 		return
@@ -169,24 +205,45 @@ func LoadNextDocument(state *EditorState) {
 		return locate.LineNumAndColToPos(p.TextTree, next.LineNum, next.Col)
 	})
 	reportOpenSuccess(state, path)
+	showRecoveryMenuIfSnapshotExists(state)
 }
 
 func currentTimelineState(state *EditorState) file.TimelineState {
 	buffer := state.documentBuffer
 	lineNum, col := locate.PosToLineNumAndCol(buffer.textTree, buffer.cursor.position)
 	return file.TimelineState{
-		Path:    state.fileWatcher.Path(),
+		Path:    state.documentBuffer.fileWatcher.Path(),
 		LineNum: lineNum,
 		Col:     col,
 	}
 }
 
-func loadDocumentAndResetState(state *EditorState, path string, requireExists bool) (fileExists bool, err error) {
+func loadDocumentAndResetState(state *EditorState, path string, requireExists bool, hex bool) (fileExists bool, err error) {
+	if buffer := findBackgroundBuffer(state, path); buffer != nil {
+		// The document is already loaded, so switch to it instead of loading it again.
+		// This preserves any unsaved changes, as well as the cursor and undo history.
+		switchToBuffer(state, buffer)
+		_, err := os.Stat(path)
+		return err == nil, nil
+	}
+
 	cfg := state.configRuleSet.ConfigForPath(path)
-	tree, watcher, err := file.Load(path, file.DefaultPollInterval)
+	var tree *text.Tree
+	var watcher *file.Watcher
+	var format file.Format
+	var pager *file.Pager
+	if !hex && isLargeFile(path, cfg) {
+		tree, watcher, format, pager, err = loadLargeFile(path)
+	} else {
+		tree, watcher, format, err = loadFile(path, cfg, hex)
+	}
 	if err := errors.Cause(err); errors.Is(err, fs.ErrNotExist) && !requireExists {
 		tree = text.NewTree()
 		watcher = file.NewWatcher(file.DefaultPollInterval, path, time.Time{}, 0, "")
+		format = file.DefaultFormat
+		if hex {
+			format = file.HexFormat
+		}
 	} else if err != nil {
 		return false, err
 	} else {
@@ -194,31 +251,129 @@ func loadDocumentAndResetState(state *EditorState, path string, requireExists bo
 	}
 
 	CancelTaskIfRunning(state)
+
+	buffer := state.documentBuffer
+	if path == buffer.fileWatcher.Path() {
+		// Reloading the current document replaces its contents.
+		// Save the undo history first, and remove the recovery snapshot since any unsaved changes are discarded.
+		persistUndoLog(state, buffer)
+		removeRecoverySnapshot(state, buffer)
+		closeBuffer(buffer)
+	} else {
+		// Keep the previous document loaded in the background, so the user can switch back to it.
+		buffer = newBufferState(buffer.view)
+		moveCurrentBufferToBackground(state)
+		state.documentBuffer = buffer
+	}
+
+	buffer.textTree = tree
+	buffer.fileWatcher = watcher
+	buffer.cursor = cursorState{}
+	buffer.view.textOrigin = 0
+	buffer.selector.Clear()
+	buffer.search = searchState{}
+	buffer.tabSize = uint64(cfg.TabSize) // safe b/c we validated the config.
+	buffer.tabExpand = cfg.TabExpand
+	buffer.showTabs = cfg.ShowTabs
+	buffer.showSpaces = cfg.ShowSpaces
+	buffer.showIndentGuides = cfg.ShowIndentGuides && !format.Hex
+	buffer.autoIndent = cfg.AutoIndent && !format.Hex
+	buffer.autoPair = cfg.AutoPair && !format.Hex
+	buffer.autoPairs = autoPairsFromConfig(cfg.AutoPairs)
+	if len(buffer.autoPairs) == 0 {
+		buffer.autoPairs = autoPairsFromConfig(config.DefaultAutoPairs)
+	}
+	buffer.showLineNum = cfg.ShowLineNumbers
+	buffer.lineNumMode = lineNumModeFromConfig(cfg.LineNumberMode)
+	buffer.highlightCursorLine = cfg.HighlightCursorLine
+	buffer.rulers = rulersFromConfig(cfg.Rulers)
+	buffer.showBlame = cfg.ShowBlame
+	buffer.lineWrapAllowCharBreaks = bool(cfg.LineWrap == config.LineWrapCharacter)
+	buffer.trimTrailingWhitespace = cfg.TrimTrailingWhitespace && !format.Hex
+	buffer.trimTrailingBlankLines = cfg.TrimTrailingBlankLines && !format.Hex
+	buffer.fileFormat = fileFormatForConfig(format, cfg)
+	buffer.fileFormatChanged = false
+	buffer.pager = pager
+	buffer.largeFile = pager != nil
+	buffer.readOnly = pager != nil
+	buffer.indentRulesOverride = syntax.IndentRules{
+		IndentAfter: cfg.IndentAfter,
+		DedentOn:    cfg.DedentOn,
+	}
+	buffer.commentSyntaxOverride = syntax.CommentSyntax{
+		Prefix: cfg.CommentPrefix,
+		Suffix: cfg.CommentSuffix,
+	}
+	buffer.undoLog = restoreUndoLog(state, path, watcher, format, cfg)
+	buffer.recoverySnapshot = recoverySnapshotState{}
+	setBaseText(buffer)
+	buffer.gitChanges = nil
+	if !format.Hex && pager == nil {
+		loadGitChangesInBackground(state, buffer, cfg)
+	}
+	buffer.blame = nil
+	buffer.folds = nil
+	if format.Hex || pager != nil {
+		// Hex dumps have no syntax, and highlighting a large file would require parsing the entire file.
+		setSyntaxAndRetokenize(buffer, syntax.LanguagePlaintext)
+	} else {
+		setSyntaxAndRetokenize(buffer, syntax.Language(cfg.SyntaxLanguage))
+	}
+
+	resetStateForCurrentDocument(state, cfg)
+	return fileExists, nil
+}
+
+func rulersFromConfig(rulers []int) []uint64 {
+	result := make([]uint64, 0, len(rulers))
+	for _, r := range rulers {
+		result = append(result, uint64(r)) // safe b/c we validated the config.
+	}
+	return result
+}
+
+// resetStateForCurrentDocument resets editor state that isn't specific to a buffer
+// after the current document changes.
+func resetStateForCurrentDocument(state *EditorState, cfg config.Config) {
 	state.documentLoadCount++
-	state.documentBuffer.textTree = tree
-	state.fileWatcher.Stop()
-	state.fileWatcher = watcher
 	state.inputMode = InputModeNormal
 	state.prevInputMode = InputModeNormal
-	state.documentBuffer.cursor = cursorState{}
-	state.documentBuffer.view.textOrigin = 0
-	state.documentBuffer.selector.Clear()
-	state.documentBuffer.search = searchState{}
-	state.documentBuffer.tabSize = uint64(cfg.TabSize) // safe b/c we validated the config.
-	state.documentBuffer.tabExpand = cfg.TabExpand
-	state.documentBuffer.showTabs = cfg.ShowTabs
-	state.documentBuffer.showSpaces = cfg.ShowSpaces
-	state.documentBuffer.autoIndent = cfg.AutoIndent
-	state.documentBuffer.showLineNum = cfg.ShowLineNumbers
-	state.documentBuffer.lineWrapAllowCharBreaks = bool(cfg.LineWrap == config.LineWrapCharacter)
-	state.documentBuffer.undoLog.TrackLoad()
 	state.menu = &MenuState{}
 	state.customMenuItems = customMenuItems(cfg)
 	state.dirPatternsToHide = cfg.HideDirectories
 	state.styles = cfg.Styles
-	setSyntaxAndRetokenize(state.documentBuffer, syntax.Language(cfg.SyntaxLanguage))
+	setConfigTheme(state, cfg.Theme)
+	setStatusBarConfig(state, cfg.StatusBar)
+}
 
-	return fileExists, nil
+// loadFile loads a file as text, falling back to a hex dump if the file is not valid text.
+func loadFile(path string, cfg config.Config, hex bool) (*text.Tree, *file.Watcher, file.Format, error) {
+	if hex {
+		return file.LoadHex(path, file.DefaultPollInterval)
+	}
+
+	var fallbackEncoding file.Encoding
+	if cfg.FallbackEncoding != config.FallbackEncodingNone {
+		fallbackEncoding = file.Encoding(cfg.FallbackEncoding)
+	}
+
+	tree, watcher, format, err := file.Load(path, fallbackEncoding, file.DefaultPollInterval)
+	if errors.Cause(err) == text.InvalidUtf8Error {
+		log.Printf("File at '%s' is not valid UTF-8, so loading it as hex\n", path)
+		return file.LoadHex(path, file.DefaultPollInterval)
+	}
+	return tree, watcher, format, err
+}
+
+// fileFormatForConfig returns the format used to save a loaded file.
+func fileFormatForConfig(format file.Format, cfg config.Config) file.Format {
+	switch cfg.FinalNewline {
+	case config.FinalNewlineAlways:
+		format.EndsWithNewline = true
+	case config.FinalNewlineNever:
+		format.EndsWithNewline = false
+	}
+	return format
 }
 
 func setCursorAfterLoad(state *EditorState, cursorLoc Locator) {
@@ -281,6 +436,14 @@ func actionForCustomMenuItem(cmd config.MenuCommandConfig) func(*EditorState) {
 func reportOpenSuccess(state *EditorState, path string) {
 	log.Printf("Successfully opened file from '%s'", path)
 	msg := fmt.Sprintf("Opened %s", file.RelativePathCwd(path))
+	if state.documentBuffer.fileFormat.Hex {
+		msg += " in hex mode"
+	} else if state.documentBuffer.pager != nil {
+		msg += " in large file mode (read-only)"
+	}
+	if reportMixedLineEndings(state, msg) {
+		return
+	}
 	SetStatusMsg(state, StatusMsg{
 		Style: StatusMsgStyleSuccess,
 		Text:  msg,
@@ -299,12 +462,31 @@ func reportCreateSuccess(state *EditorState, path string) {
 func reportReloadSuccess(state *EditorState, path string) {
 	log.Printf("Successfully reloaded file from '%s'", path)
 	msg := fmt.Sprintf("Reloaded %s", file.RelativePathCwd(path))
+	if reportMixedLineEndings(state, msg) {
+		return
+	}
 	SetStatusMsg(state, StatusMsg{
 		Style: StatusMsgStyleSuccess,
 		Text:  msg,
 	})
 }
 
+// reportMixedLineEndings warns the user if the loaded file had mixed line endings,
+// since these will be normalized when the file is saved.
+// It returns true if a warning was shown.
+func reportMixedLineEndings(state *EditorState, msg string) bool {
+	format := state.documentBuffer.fileFormat
+	if !format.MixedLineEndings {
+		return false
+	}
+	log.Printf("File has mixed line endings, will be saved as %s\n", format.LineEnding)
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleError,
+		Text:  fmt.Sprintf("%s with mixed line endings (will be saved as %s)", msg, format.LineEnding),
+	})
+	return true
+}
+
 func reportLoadError(state *EditorState, err error, path string) {
 	log.Printf("Error loading file at '%s': %v\n", path, err)
 	SetStatusMsg(state, StatusMsg{
@@ -314,18 +496,43 @@ func reportLoadError(state *EditorState, err error, path string) {
 }
 
 // SaveDocument saves the currently loaded document to disk.
+// Depending on the configuration, this may first remove trailing whitespace and blank lines.
+// These cleanup edits are tracked in the undo log, so they can be undone after saving.
+// A file opened in large file mode cannot be saved, since this could truncate the file
+// or replace invalid UTF-8 in the file.
 func SaveDocument(state *EditorState) {
-	path := state.fileWatcher.Path()
-	tree := state.documentBuffer.textTree
-	newWatcher, err := file.Save(path, tree, file.DefaultPollInterval)
+	if state.documentBuffer.largeFile {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  "Cannot save a file opened in large file mode",
+		})
+		return
+	}
+
+	buffer := state.documentBuffer
+	if buffer.trimTrailingWhitespace {
+		TrimTrailingWhitespace(state)
+	}
+	if buffer.trimTrailingBlankLines {
+		TrimTrailingBlankLines(state)
+	}
+
+	path := state.documentBuffer.fileWatcher.Path()
+	tree := buffer.textTree
+	newWatcher, err := file.Save(path, tree, buffer.fileFormat, file.DefaultPollInterval)
 	if err != nil {
 		reportSaveError(state, err, path)
 		return
 	}
 
-	state.fileWatcher.Stop()
-	state.fileWatcher = newWatcher
+	state.documentBuffer.fileWatcher.Stop()
+	state.documentBuffer.fileWatcher = newWatcher
 	state.documentBuffer.undoLog.TrackSave()
+	setBaseText(state.documentBuffer)
+	persistUndoLog(state, state.documentBuffer)
+	removeRecoverySnapshot(state, state.documentBuffer)
+	state.documentBuffer.fileFormat.MixedLineEndings = false
+	state.documentBuffer.fileFormatChanged = false
 	reportSaveSuccess(state, path)
 }
 
@@ -347,7 +554,7 @@ func reportSaveSuccess(state *EditorState, path string) {
 
 // AbortIfUnsavedChanges executes a function only if the document does not have unsaved changes and shows an error status msg otherwise.
 func AbortIfUnsavedChanges(state *EditorState, f func(*EditorState), showStatus bool) {
-	if state.documentBuffer.undoLog.HasUnsavedChanges() {
+	if state.documentBuffer.hasUnsavedChanges() {
 		log.Printf("Aborting operation because document has unsaved changes\n")
 		if showStatus {
 			SetStatusMsg(state, StatusMsg{
@@ -362,8 +569,8 @@ func AbortIfUnsavedChanges(state *EditorState, f func(*EditorState), showStatus
 
 // AbortIfFileExistsWithChangedContent aborts with an error message if the file exists with a different checksum than the last load/save.
 func AbortIfFileExistsWithChangedContent(state *EditorState, f func(*EditorState)) {
-	path := state.fileWatcher.Path()
-	changed, err := state.fileWatcher.CheckFileContentsChanged()
+	path := state.documentBuffer.fileWatcher.Path()
+	changed, err := state.documentBuffer.fileWatcher.CheckFileContentsChanged()
 	if changed {
 		log.Printf("Aborting operation because file changed on disk\n")
 		SetStatusMsg(state, StatusMsg{
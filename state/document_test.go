@@ -11,6 +11,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/aretext/aretext/config"
+	"github.com/aretext/aretext/locate"
 	"github.com/aretext/aretext/syntax"
 )
 
@@ -37,7 +38,7 @@ func TestLoadDocumentShowStatus(t *testing.T) {
 	// Load a document.
 	path, cleanup := createTestFile(t, "abcd")
 	LoadDocument(state, path, true, startOfDocLocator)
-	defer state.fileWatcher.Stop()
+	defer state.documentBuffer.fileWatcher.Stop()
 
 	// Expect that the text and watcher are installed.
 	assert.Equal(t, "abcd", state.documentBuffer.textTree.String())
@@ -47,12 +48,13 @@ func TestLoadDocumentShowStatus(t *testing.T) {
 	assert.Contains(t, state.statusMsg.Text, "Opened")
 	assert.Equal(t, StatusMsgStyleSuccess, state.statusMsg.Style)
 
-	// Delete the test file.
 	cleanup()
 
 	// Load a non-existent path, expect error msg.
-	LoadDocument(state, path, true, startOfDocLocator)
-	defer state.fileWatcher.Stop()
+	path2, cleanup2 := createTestFile(t, "")
+	cleanup2()
+	LoadDocument(state, path2, true, startOfDocLocator)
+	defer state.documentBuffer.fileWatcher.Stop()
 	assert.Contains(t, state.statusMsg.Text, "Could not open")
 	assert.Equal(t, StatusMsgStyleError, state.statusMsg.Style)
 }
@@ -77,7 +79,7 @@ func TestLoadDocumentSameFile(t *testing.T) {
 	err := os.WriteFile(path, []byte("ab"), 0644)
 	require.NoError(t, err)
 	ReloadDocument(state)
-	defer state.fileWatcher.Stop()
+	defer state.documentBuffer.fileWatcher.Stop()
 
 	// Expect that the cursor moved back to the end of the text,
 	// the view scrolled to make the cursor visible,
@@ -88,6 +90,30 @@ func TestLoadDocumentSameFile(t *testing.T) {
 	assert.Equal(t, syntax.LanguageJson, state.documentBuffer.syntaxLanguage)
 }
 
+func TestLoadDocumentSameFileKeepsUnsavedChanges(t *testing.T) {
+	// Load the initial document.
+	path, cleanup := createTestFile(t, "abc\ndef")
+	defer cleanup()
+	state := NewEditorState(100, 100, nil, nil)
+	LoadDocument(state, path, true, startOfDocLocator)
+	defer state.documentBuffer.fileWatcher.Stop()
+
+	// Make an unsaved change.
+	InsertRune(state, 'x')
+	assert.Equal(t, "xabc\ndef", state.documentBuffer.textTree.String())
+	assert.True(t, state.documentBuffer.hasUnsavedChanges())
+
+	// Load the same document again, as when choosing it from a menu.
+	LoadDocument(state, path, true, func(p LocatorParams) uint64 {
+		return locate.StartOfLineNum(p.TextTree, 1)
+	})
+
+	// Expect that the unsaved change is preserved and the cursor moved.
+	assert.Equal(t, "xabc\ndef", state.documentBuffer.textTree.String())
+	assert.True(t, state.documentBuffer.hasUnsavedChanges())
+	assert.Equal(t, uint64(5), state.documentBuffer.cursor.position)
+}
+
 func TestLoadDocumentDifferentFile(t *testing.T) {
 	// Load the initial document.
 	path, cleanup := createTestFile(t, "abcd\nefghi\njklmnop\nqrst")
@@ -108,7 +134,7 @@ func TestLoadDocumentDifferentFile(t *testing.T) {
 	path2, cleanup2 := createTestFile(t, "ab")
 	defer cleanup2()
 	LoadDocument(state, path2, true, startOfDocLocator)
-	defer state.fileWatcher.Stop()
+	defer state.documentBuffer.fileWatcher.Stop()
 
 	// Expect that the cursor, view, and syntax are reset.
 	assert.Equal(t, "ab", state.documentBuffer.textTree.String())
@@ -136,7 +162,7 @@ func TestLoadPrevDocument(t *testing.T) {
 	// Return to the previous document.
 	LoadPrevDocument(state)
 	assert.Equal(t, "abcd\nefghi\njklmnop\nqrst", state.documentBuffer.textTree.String())
-	assert.Equal(t, path, state.fileWatcher.Path())
+	assert.Equal(t, path, state.documentBuffer.fileWatcher.Path())
 	assert.Equal(t, uint64(7), state.documentBuffer.cursor.position)
 }
 
@@ -152,16 +178,16 @@ func TestLoadNextDocument(t *testing.T) {
 	path2, cleanup2 := createTestFile(t, "qrs\ntuv\nwxyz")
 	defer cleanup2()
 	LoadDocument(state, path2, true, startOfDocLocator)
-	assert.Equal(t, path2, state.fileWatcher.Path())
+	assert.Equal(t, path2, state.documentBuffer.fileWatcher.Path())
 	MoveCursor(state, func(LocatorParams) uint64 { return 5 })
 
 	// Return to the previous document.
 	LoadPrevDocument(state)
-	assert.Equal(t, path, state.fileWatcher.Path())
+	assert.Equal(t, path, state.documentBuffer.fileWatcher.Path())
 
 	// Forward to the next document.
 	LoadNextDocument(state)
-	assert.Equal(t, path2, state.fileWatcher.Path())
+	assert.Equal(t, path2, state.documentBuffer.fileWatcher.Path())
 	assert.Equal(t, "qrs\ntuv\nwxyz", state.documentBuffer.textTree.String())
 	assert.Equal(t, uint64(5), state.documentBuffer.cursor.position)
 }
@@ -174,7 +200,7 @@ func TestLoadDocumentIncrementLoadCount(t *testing.T) {
 	// Load a document.
 	path, cleanup := createTestFile(t, "abcd")
 	LoadDocument(state, path, true, startOfDocLocator)
-	defer state.fileWatcher.Stop()
+	defer state.documentBuffer.fileWatcher.Stop()
 	defer cleanup()
 
 	// Expect that the load count was bumped.
@@ -201,7 +227,7 @@ func TestReloadDocumentAlignCursorAndScroll(t *testing.T) {
 
 	// Reload the document.
 	ReloadDocument(state)
-	defer state.fileWatcher.Stop()
+	defer state.documentBuffer.fileWatcher.Stop()
 
 	// Expect that the cursor and scroll position moved to the
 	// equivalent line in the new document.
@@ -225,7 +251,7 @@ func TestReloadDocumentWithMenuOpen(t *testing.T) {
 	err := os.WriteFile(path, []byte("ab"), 0644)
 	require.NoError(t, err)
 	ReloadDocument(state)
-	defer state.fileWatcher.Stop()
+	defer state.documentBuffer.fileWatcher.Stop()
 
 	// Expect that the input mode is normal and the menu is hidden.
 	assert.Equal(t, "ab", state.documentBuffer.textTree.String())
@@ -241,12 +267,12 @@ func TestSaveDocument(t *testing.T) {
 	path, cleanup := createTestFile(t, "")
 	defer cleanup()
 	LoadDocument(state, path, true, startOfDocLocator)
-	defer state.fileWatcher.Stop()
+	defer state.documentBuffer.fileWatcher.Stop()
 
 	// Modify and save the document
 	InsertRune(state, 'x')
 	SaveDocument(state)
-	defer state.fileWatcher.Stop()
+	defer state.documentBuffer.fileWatcher.Stop()
 
 	// Expect a success message.
 	assert.Contains(t, state.statusMsg.Text, "Saved")
@@ -258,6 +284,101 @@ func TestSaveDocument(t *testing.T) {
 	assert.Equal(t, "x\n", string(contents))
 }
 
+func TestSaveDocumentFinalNewline(t *testing.T) {
+	testCases := []struct {
+		name             string
+		finalNewline     string
+		fileContents     string
+		expectedContents string
+	}{
+		{
+			name:             "preserve with final newline",
+			finalNewline:     "preserve",
+			fileContents:     "abc\n",
+			expectedContents: "xabc\n",
+		},
+		{
+			name:             "preserve without final newline",
+			finalNewline:     "preserve",
+			fileContents:     "abc",
+			expectedContents: "xabc",
+		},
+		{
+			name:             "always without final newline",
+			finalNewline:     "always",
+			fileContents:     "abc",
+			expectedContents: "xabc\n",
+		},
+		{
+			name:             "never with final newline",
+			finalNewline:     "never",
+			fileContents:     "abc\n",
+			expectedContents: "xabc",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			configRuleSet := config.RuleSet{
+				{
+					Name:    "finalNewline",
+					Pattern: "**",
+					Config:  map[string]any{"finalNewline": tc.finalNewline},
+				},
+			}
+
+			state := NewEditorState(100, 100, configRuleSet, nil)
+			path, cleanup := createTestFile(t, tc.fileContents)
+			defer cleanup()
+			LoadDocument(state, path, true, startOfDocLocator)
+			defer state.documentBuffer.fileWatcher.Stop()
+
+			InsertRune(state, 'x')
+			SaveDocument(state)
+			defer state.documentBuffer.fileWatcher.Stop()
+
+			contents, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedContents, string(contents))
+		})
+	}
+}
+
+func TestSaveDocumentWithWhitespaceCleanup(t *testing.T) {
+	configRuleSet := config.RuleSet{
+		{
+			Name:    "cleanup",
+			Pattern: "**",
+			Config: map[string]any{
+				"trimTrailingWhitespace": true,
+				"trimTrailingBlankLines": true,
+				"finalNewline":           "never",
+			},
+		},
+	}
+
+	state := NewEditorState(100, 100, configRuleSet, nil)
+	path, cleanup := createTestFile(t, "ab  \ncd\t\n\n  \n\n")
+	defer cleanup()
+	LoadDocument(state, path, true, startOfDocLocator)
+	defer state.documentBuffer.fileWatcher.Stop()
+
+	// Save the document, which should trigger the whitespace cleanup.
+	SaveDocument(state)
+	defer state.documentBuffer.fileWatcher.Stop()
+	assert.Equal(t, StatusMsgStyleSuccess, state.statusMsg.Style)
+	assert.False(t, state.documentBuffer.undoLog.HasUnsavedChanges())
+
+	contents, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "ab\ncd", string(contents))
+
+	// Undo the cleanup edits.
+	Undo(state)
+	assert.Equal(t, "ab  \ncd\t\n\n  \n", state.documentBuffer.textTree.String())
+	assert.True(t, state.documentBuffer.undoLog.HasUnsavedChanges())
+}
+
 func TestAbortIfFileExistsWithChangedContent(t *testing.T) {
 	testCases := []struct {
 		name        string
@@ -12,6 +12,7 @@ import (
 	"github.com/aretext/aretext/clipboard"
 	"github.com/aretext/aretext/locate"
 	"github.com/aretext/aretext/selection"
+	"github.com/aretext/aretext/syntax"
 	"github.com/aretext/aretext/syntax/parser"
 	"github.com/aretext/aretext/text"
 	"github.com/aretext/aretext/text/segment"
@@ -19,20 +20,46 @@ import (
 )
 
 // InsertRune inserts a rune at the current cursor location.
+// If auto-pair is enabled, this may insert or skip over a matching closing character.
+// In hex mode, this overwrites the next hex digit instead of inserting.
 func InsertRune(state *EditorState, r rune) {
 	buffer := state.documentBuffer
+	if buffer.fileFormat.Hex {
+		overwriteHexDigit(state, r)
+		return
+	}
+
+	if buffer.autoPair && skipAutoPairCloser(state, r) {
+		return
+	}
+
+	if buffer.autoIndent {
+		dedentBeforeInsertingRune(state, r)
+	}
+
 	startPos := buffer.cursor.position
 	if err := insertTextAtPosition(state, string(r), startPos, true); err != nil {
 		log.Printf("Error inserting rune: %v\n", err)
 		return
 	}
 	buffer.cursor.position = startPos + 1
+
+	if buffer.autoPair {
+		insertAutoPairCloser(state, r, startPos)
+	}
 }
 
+// errReadOnly is returned when attempting to edit a read-only document.
+var errReadOnly = errors.New("document is read-only")
+
 // insertTextAtPosition inserts text into the document.
 // It also updates the syntax tokens and unsaved changes flag.
 // It does NOT move the cursor.
 func insertTextAtPosition(state *EditorState, s string, pos uint64, updateUndoLog bool) error {
+	if abortIfReadOnly(state) {
+		return errReadOnly
+	}
+
 	buffer := state.documentBuffer
 
 	var n uint64
@@ -45,6 +72,10 @@ func insertTextAtPosition(state *EditorState, s string, pos uint64, updateUndoLo
 
 	edit := parser.NewInsertEdit(pos, n)
 	retokenizeAfterEdit(buffer, edit)
+	buffer.gitChanges.markStale()
+	buffer.blame.markStale()
+	updateFoldsAfterInsert(buffer, pos, n)
+	updateInactivePanesAfterInsert(state, pos, n)
 
 	if updateUndoLog && len(s) > 0 {
 		op := undo.InsertOp(pos, s)
@@ -56,7 +87,7 @@ func insertTextAtPosition(state *EditorState, s string, pos uint64, updateUndoLo
 
 func mustInsertTextAtPosition(state *EditorState, text string, pos uint64, updateUndoLog bool) {
 	err := insertTextAtPosition(state, text, pos, updateUndoLog)
-	if err != nil {
+	if err != nil && err != errReadOnly {
 		panic(err)
 	}
 }
@@ -66,7 +97,12 @@ func mustInsertRuneAtPosition(state *EditorState, r rune, pos uint64, updateUndo
 }
 
 // InsertNewline inserts a newline at the current cursor position.
+// This does nothing in hex mode, since the hex dump has a fixed layout.
 func InsertNewline(state *EditorState) {
+	if state.documentBuffer.fileFormat.Hex {
+		return
+	}
+
 	cursorPos := state.documentBuffer.cursor.position
 	mustInsertRuneAtPosition(state, '\n', cursorPos, true)
 	cursorPos++
@@ -74,13 +110,106 @@ func InsertNewline(state *EditorState) {
 	buffer := state.documentBuffer
 	if buffer.autoIndent {
 		deleteToNextNonWhitespace(state, cursorPos)
-		numCols := numColsIndentedPrevLine(buffer, cursorPos)
+		rules := buffer.IndentRules()
+		prevLineNumCols := numColsIndentedPrevLine(buffer, cursorPos)
+		numCols := prevLineNumCols
+		indentAfterPrevLine := prevLineEndsWithIndentAfter(buffer, rules, cursorPos)
+		if indentAfterPrevLine {
+			numCols += buffer.tabSize
+		}
+
+		if startsWithDedentOn(buffer, rules, cursorPos) {
+			if indentAfterPrevLine {
+				// Split the pair (for example, "{}") so the closer is on its own line
+				// aligned with the opener, and the cursor is on an indented line between them.
+				mustInsertRuneAtPosition(state, '\n', cursorPos, true)
+				indentFromPos(state, cursorPos+1, prevLineNumCols)
+			} else if numCols >= buffer.tabSize {
+				numCols -= buffer.tabSize
+			} else {
+				numCols = 0
+			}
+		}
+
 		cursorPos = indentFromPos(state, cursorPos, numCols)
 	}
 
 	buffer.cursor = cursorState{position: cursorPos}
 }
 
+// prevLineEndsWithIndentAfter checks whether the line before the one at pos
+// ends with a string that should increase indentation, ignoring trailing whitespace.
+func prevLineEndsWithIndentAfter(buffer *BufferState, rules syntax.IndentRules, pos uint64) bool {
+	if len(rules.IndentAfter) == 0 {
+		return false
+	}
+
+	lineNum := buffer.textTree.LineNumForPosition(pos)
+	if lineNum == 0 {
+		return false
+	}
+
+	prevLineStartPos := buffer.textTree.LineStartPosition(lineNum - 1)
+	prevLineEndPos := locate.NextLineBoundary(buffer.textTree, true, prevLineStartPos)
+	prevLine := strings.TrimRight(copyText(buffer.textTree, prevLineStartPos, prevLineEndPos-prevLineStartPos), " \t")
+	for _, s := range rules.IndentAfter {
+		if strings.HasSuffix(prevLine, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// startsWithDedentOn checks whether the text at pos starts with a string that should decrease indentation.
+func startsWithDedentOn(buffer *BufferState, rules syntax.IndentRules, pos uint64) bool {
+	if len(rules.DedentOn) == 0 {
+		return false
+	}
+
+	endOfLinePos := locate.NextLineBoundary(buffer.textTree, true, pos)
+	line := copyText(buffer.textTree, pos, endOfLinePos-pos)
+	for _, s := range rules.DedentOn {
+		if strings.HasPrefix(line, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// dedentBeforeInsertingRune removes one level of indentation from the cursor's line
+// if the line is blank before the cursor and the inserted rune should decrease indentation.
+func dedentBeforeInsertingRune(state *EditorState, r rune) {
+	buffer := state.documentBuffer
+	isDedentOn := false
+	for _, s := range buffer.IndentRules().DedentOn {
+		if s == string(r) {
+			isDedentOn = true
+			break
+		}
+	}
+
+	if !isDedentOn {
+		return
+	}
+
+	cursorPos := buffer.cursor.position
+	startOfLinePos := locate.StartOfLineAtPos(buffer.textTree, cursorPos)
+	endOfIndentPos := locate.NextNonWhitespaceOrNewline(buffer.textTree, startOfLinePos)
+	endOfLinePos := locate.NextLineBoundary(buffer.textTree, true, startOfLinePos)
+	if startOfLinePos == cursorPos || endOfIndentPos < endOfLinePos {
+		// The cursor is at the start of the line, or the line isn't blank.
+		return
+	}
+
+	numToDelete := numRunesInIndent(buffer, startOfLinePos, 1)
+	deleteRunes(state, startOfLinePos, numToDelete, true)
+	if cursorPos-startOfLinePos > numToDelete {
+		buffer.cursor = cursorState{position: cursorPos - numToDelete}
+	} else {
+		buffer.cursor = cursorState{position: startOfLinePos}
+	}
+}
+
 func deleteToNextNonWhitespace(state *EditorState, startPos uint64) {
 	pos := locate.NextNonWhitespaceOrNewline(state.documentBuffer.textTree, startPos)
 	count := pos - startPos
@@ -164,7 +293,12 @@ func ClearAutoIndentWhitespaceLine(state *EditorState, startOfLineLoc Locator) {
 }
 
 // InsertTab inserts a tab at the current cursor position.
+// This does nothing in hex mode, since the hex dump has a fixed layout.
 func InsertTab(state *EditorState) {
+	if state.documentBuffer.fileFormat.Hex {
+		return
+	}
+
 	cursorPos := state.documentBuffer.cursor.position
 	newCursorPos := insertTabsAtPos(state, cursorPos, tabText(state, 1))
 	state.documentBuffer.cursor = cursorState{position: newCursorPos}
@@ -319,6 +453,10 @@ func stripStartingAndTrailingNewlines(s string) string {
 // It also updates the syntax token and undo log.
 // It does NOT move the cursor.
 func deleteRunes(state *EditorState, pos uint64, count uint64, updateUndoLog bool) string {
+	if abortIfReadOnly(state) {
+		return ""
+	}
+
 	deletedRunes := make([]rune, 0, count)
 	buffer := state.documentBuffer
 	for i := uint64(0); i < count; i++ {
@@ -330,6 +468,10 @@ func deleteRunes(state *EditorState, pos uint64, count uint64, updateUndoLog boo
 
 	edit := parser.NewDeleteEdit(pos, count)
 	retokenizeAfterEdit(buffer, edit)
+	buffer.gitChanges.markStale()
+	buffer.blame.markStale()
+	updateFoldsAfterDelete(buffer, pos, uint64(len(deletedRunes)))
+	updateInactivePanesAfterDelete(state, pos, uint64(len(deletedRunes)))
 
 	deletedText := string(deletedRunes)
 	if updateUndoLog && deletedText != "" {
@@ -343,6 +485,11 @@ func deleteRunes(state *EditorState, pos uint64, count uint64, updateUndoLog boo
 // ReplaceChar replaces the character under the cursor.
 func ReplaceChar(state *EditorState, newChar rune) {
 	buffer := state.documentBuffer
+	if buffer.fileFormat.Hex {
+		replaceHexDigitAtCursor(state, newChar)
+		return
+	}
+
 	pos := state.documentBuffer.cursor.position
 	nextCharPos := locate.NextCharInLine(buffer.textTree, 1, true, pos)
 
@@ -9,6 +9,7 @@ import (
 	"github.com/aretext/aretext/clipboard"
 	"github.com/aretext/aretext/locate"
 	"github.com/aretext/aretext/selection"
+	"github.com/aretext/aretext/syntax"
 	"github.com/aretext/aretext/text"
 )
 
@@ -140,6 +141,7 @@ func TestInsertNewline(t *testing.T) {
 	testCases := []struct {
 		name              string
 		inputString       string
+		syntaxLanguage    syntax.Language
 		autoIndent        bool
 		cursorPos         uint64
 		tabExpand         bool
@@ -225,6 +227,79 @@ func TestInsertNewline(t *testing.T) {
 			expectedCursorPos: 13,
 			expectedText:      "    abcd\n    xyz",
 		},
+		{
+			name:              "smart indent after opening brace",
+			inputString:       "	func f() {",
+			syntaxLanguage:    syntax.LanguageGo,
+			autoIndent:        true,
+			cursorPos:         11,
+			expectedCursorPos: 14,
+			expectedText:      "	func f() {\n\t\t",
+		},
+		{
+			name:              "smart indent after opening brace with trailing whitespace",
+			inputString:       "x = [  ",
+			syntaxLanguage:    syntax.LanguagePython,
+			autoIndent:        true,
+			tabExpand:         true,
+			cursorPos:         7,
+			expectedCursorPos: 12,
+			expectedText:      "x = [  \n    ",
+		},
+		{
+			name:              "smart indent after colon",
+			inputString:       "if x:",
+			syntaxLanguage:    syntax.LanguagePython,
+			autoIndent:        true,
+			tabExpand:         true,
+			cursorPos:         5,
+			expectedCursorPos: 10,
+			expectedText:      "if x:\n    ",
+		},
+		{
+			name:              "no smart indent after colon for go",
+			inputString:       "case x:",
+			syntaxLanguage:    syntax.LanguageGo,
+			autoIndent:        true,
+			cursorPos:         7,
+			expectedCursorPos: 8,
+			expectedText:      "case x:\n",
+		},
+		{
+			name:              "smart indent disabled if autoindent disabled",
+			inputString:       "if x:",
+			syntaxLanguage:    syntax.LanguagePython,
+			cursorPos:         5,
+			expectedCursorPos: 6,
+			expectedText:      "if x:\n",
+		},
+		{
+			name:              "smart indent split empty pair",
+			inputString:       "	f() {}",
+			syntaxLanguage:    syntax.LanguageGo,
+			autoIndent:        true,
+			cursorPos:         6,
+			expectedCursorPos: 9,
+			expectedText:      "\tf() {\n\t\t\n\t}",
+		},
+		{
+			name:              "smart indent dedent closer",
+			inputString:       "		x)",
+			syntaxLanguage:    syntax.LanguageGo,
+			autoIndent:        true,
+			cursorPos:         3,
+			expectedCursorPos: 5,
+			expectedText:      "\t\tx\n\t)",
+		},
+		{
+			name:              "smart indent continuation keeps indentation",
+			inputString:       "	foo(a,",
+			syntaxLanguage:    syntax.LanguageGo,
+			autoIndent:        true,
+			cursorPos:         8,
+			expectedCursorPos: 10,
+			expectedText:      "\tfoo(a,\n\t",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -237,6 +312,9 @@ func TestInsertNewline(t *testing.T) {
 			state.documentBuffer.autoIndent = tc.autoIndent
 			state.documentBuffer.tabSize = 4
 			state.documentBuffer.tabExpand = tc.tabExpand
+			if tc.syntaxLanguage != "" {
+				state.documentBuffer.syntaxLanguage = tc.syntaxLanguage
+			}
 			InsertNewline(state)
 			assert.Equal(t, cursorState{position: tc.expectedCursorPos}, state.documentBuffer.cursor)
 			assert.Equal(t, tc.expectedText, textTree.String())
@@ -244,6 +322,88 @@ func TestInsertNewline(t *testing.T) {
 	}
 }
 
+func TestInsertRuneDedent(t *testing.T) {
+	testCases := []struct {
+		name              string
+		inputString       string
+		autoIndent        bool
+		cursorPos         uint64
+		insertRune        rune
+		expectedCursorPos uint64
+		expectedText      string
+	}{
+		{
+			name:              "closer on blank line",
+			inputString:       "{\n\t\t",
+			autoIndent:        true,
+			cursorPos:         4,
+			insertRune:        '}',
+			expectedCursorPos: 4,
+			expectedText:      "{\n\t}",
+		},
+		{
+			name:              "closer on blank line with spaces",
+			inputString:       "{\n      ",
+			autoIndent:        true,
+			cursorPos:         8,
+			insertRune:        ']',
+			expectedCursorPos: 5,
+			expectedText:      "{\n  ]",
+		},
+		{
+			name:              "closer after non-whitespace",
+			inputString:       "\tx",
+			autoIndent:        true,
+			cursorPos:         2,
+			insertRune:        '}',
+			expectedCursorPos: 3,
+			expectedText:      "\tx}",
+		},
+		{
+			name:              "closer before non-whitespace",
+			inputString:       "\tx",
+			autoIndent:        true,
+			cursorPos:         1,
+			insertRune:        '}',
+			expectedCursorPos: 2,
+			expectedText:      "\t}x",
+		},
+		{
+			name:              "closer with autoindent disabled",
+			inputString:       "\t",
+			cursorPos:         1,
+			insertRune:        '}',
+			expectedCursorPos: 2,
+			expectedText:      "\t}",
+		},
+		{
+			name:              "not a closer",
+			inputString:       "\t",
+			autoIndent:        true,
+			cursorPos:         1,
+			insertRune:        'x',
+			expectedCursorPos: 2,
+			expectedText:      "\tx",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			textTree, err := text.NewTreeFromString(tc.inputString)
+			require.NoError(t, err)
+			state := NewEditorState(100, 100, nil, nil)
+			state.documentBuffer.textTree = textTree
+			state.documentBuffer.cursor = cursorState{position: tc.cursorPos}
+			state.documentBuffer.autoIndent = tc.autoIndent
+			state.documentBuffer.tabSize = 4
+			state.documentBuffer.syntaxLanguage = syntax.LanguageGo
+			InsertRune(state, tc.insertRune)
+			assert.Equal(t, cursorState{position: tc.expectedCursorPos}, state.documentBuffer.cursor)
+			assert.Equal(t, tc.expectedText, textTree.String())
+		})
+	}
+}
+
 func TestClearAutoIndentWhitespaceLine(t *testing.T) {
 	testCases := []struct {
 		name              string
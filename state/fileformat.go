@@ -0,0 +1,76 @@
+package state
+
+import (
+	"fmt"
+
+	"github.com/aretext/aretext/file"
+)
+
+// ConvertLineEndings changes the line ending used when saving the document.
+// The document is marked as having unsaved changes until the next save.
+func ConvertLineEndings(state *EditorState, lineEnding file.LineEnding) {
+	buffer := state.documentBuffer
+	if buffer.fileFormat.Hex {
+		reportUnsupportedInHexMode(state)
+		return
+	}
+
+	if abortIfReadOnly(state) {
+		return
+	}
+
+	if buffer.fileFormat.LineEnding == lineEnding && !buffer.fileFormat.MixedLineEndings {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleSuccess,
+			Text:  fmt.Sprintf("Line endings are already %s", lineEnding),
+		})
+		return
+	}
+
+	buffer.fileFormat.LineEnding = lineEnding
+	buffer.fileFormat.MixedLineEndings = false
+	buffer.fileFormatChanged = true
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  fmt.Sprintf("Converted line endings to %s", lineEnding),
+	})
+}
+
+// ConvertEncoding changes the character encoding used when saving the document.
+// The document is marked as having unsaved changes until the next save.
+func ConvertEncoding(state *EditorState, encoding file.Encoding, bom bool) {
+	buffer := state.documentBuffer
+	if buffer.fileFormat.Hex {
+		reportUnsupportedInHexMode(state)
+		return
+	}
+
+	if abortIfReadOnly(state) {
+		return
+	}
+
+	newFormat := buffer.fileFormat
+	newFormat.Encoding = encoding
+	newFormat.BOM = bom
+	if newFormat == buffer.fileFormat {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleSuccess,
+			Text:  fmt.Sprintf("Encoding is already %s", newFormat.EncodingName()),
+		})
+		return
+	}
+
+	buffer.fileFormat = newFormat
+	buffer.fileFormatChanged = true
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  fmt.Sprintf("Converted encoding to %s", newFormat.EncodingName()),
+	})
+}
+
+func reportUnsupportedInHexMode(state *EditorState) {
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleError,
+		Text:  "Cannot convert the file format in hex mode",
+	})
+}
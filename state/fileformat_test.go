@@ -0,0 +1,259 @@
+package state
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/config"
+	"github.com/aretext/aretext/file"
+	"github.com/aretext/aretext/syntax"
+)
+
+func TestLoadAndSaveDocumentLineEndings(t *testing.T) {
+	testCases := []struct {
+		name               string
+		fileContents       string
+		convertLineEnding  file.LineEnding
+		expectedStatusMsg  StatusMsg
+		expectedContents   string
+		expectedLineEnding file.LineEnding
+	}{
+		{
+			name:         "preserve LF",
+			fileContents: "ab\ncd\n",
+			expectedStatusMsg: StatusMsg{
+				Style: StatusMsgStyleSuccess,
+				Text:  "Opened",
+			},
+			expectedContents:   "xab\ncd\n",
+			expectedLineEnding: file.LineEndingLF,
+		},
+		{
+			name:         "preserve CRLF",
+			fileContents: "ab\r\ncd\r\n",
+			expectedStatusMsg: StatusMsg{
+				Style: StatusMsgStyleSuccess,
+				Text:  "Opened",
+			},
+			expectedContents:   "xab\r\ncd\r\n",
+			expectedLineEnding: file.LineEndingCRLF,
+		},
+		{
+			name:         "mixed line endings normalized to dominant line ending",
+			fileContents: "ab\r\ncd\nef\r\n",
+			expectedStatusMsg: StatusMsg{
+				Style: StatusMsgStyleError,
+				Text:  "with mixed line endings (will be saved as CRLF)",
+			},
+			expectedContents:   "xab\r\ncd\r\nef\r\n",
+			expectedLineEnding: file.LineEndingCRLF,
+		},
+		{
+			name:              "convert LF to CRLF",
+			fileContents:      "ab\ncd\n",
+			convertLineEnding: file.LineEndingCRLF,
+			expectedStatusMsg: StatusMsg{
+				Style: StatusMsgStyleSuccess,
+				Text:  "Converted line endings to CRLF",
+			},
+			expectedContents:   "xab\r\ncd\r\n",
+			expectedLineEnding: file.LineEndingCRLF,
+		},
+		{
+			name:              "convert CRLF to LF",
+			fileContents:      "ab\r\ncd\r\n",
+			convertLineEnding: file.LineEndingLF,
+			expectedStatusMsg: StatusMsg{
+				Style: StatusMsgStyleSuccess,
+				Text:  "Converted line endings to LF",
+			},
+			expectedContents:   "xab\ncd\n",
+			expectedLineEnding: file.LineEndingLF,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path, cleanup := createTestFile(t, tc.fileContents)
+			defer cleanup()
+
+			state := NewEditorState(100, 100, nil, nil)
+			LoadDocument(state, path, true, startOfDocLocator)
+			defer state.documentBuffer.fileWatcher.Stop()
+
+			if tc.convertLineEnding != "" {
+				ConvertLineEndings(state, tc.convertLineEnding)
+			}
+			assert.Equal(t, tc.expectedStatusMsg.Style, state.statusMsg.Style)
+			assert.Contains(t, state.statusMsg.Text, tc.expectedStatusMsg.Text)
+
+			InsertRune(state, 'x')
+			SaveDocument(state)
+			defer state.documentBuffer.fileWatcher.Stop()
+
+			contents, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedContents, string(contents))
+			assert.Equal(t, tc.expectedLineEnding, state.documentBuffer.FileFormat().LineEnding)
+			assert.False(t, state.documentBuffer.FileFormat().MixedLineEndings)
+		})
+	}
+}
+
+func TestConvertLineEndingsUnsavedChanges(t *testing.T) {
+	path, cleanup := createTestFile(t, "ab\ncd\n")
+	defer cleanup()
+
+	state := NewEditorState(100, 100, nil, nil)
+	LoadDocument(state, path, true, startOfDocLocator)
+	defer state.documentBuffer.fileWatcher.Stop()
+
+	ConvertLineEndings(state, file.LineEndingLF)
+	assert.False(t, state.documentBuffer.hasUnsavedChanges())
+	assert.Equal(t, "Line endings are already LF", state.statusMsg.Text)
+
+	ConvertLineEndings(state, file.LineEndingCRLF)
+	assert.True(t, state.documentBuffer.hasUnsavedChanges())
+
+	SaveDocument(state)
+	defer state.documentBuffer.fileWatcher.Stop()
+	assert.False(t, state.documentBuffer.hasUnsavedChanges())
+}
+
+func TestLoadAndSaveDocumentEncoding(t *testing.T) {
+	testCases := []struct {
+		name             string
+		fallbackEncoding string
+		fileContents     string
+		convertEncoding  file.Encoding
+		convertBOM       bool
+		expectHex        bool
+		expectedText     string
+		expectedContents string
+	}{
+		{
+			name:             "preserve UTF-8 BOM",
+			fallbackEncoding: "none",
+			fileContents:     "\xef\xbb\xbfabc\n",
+			expectedText:     "abc",
+			expectedContents: "\xef\xbb\xbfxabc\n",
+		},
+		{
+			name:             "invalid UTF-8 without fallback",
+			fallbackEncoding: "none",
+			fileContents:     "\xe9t\xe9\n",
+			expectHex:        true,
+		},
+		{
+			name:             "preserve Latin-1 fallback",
+			fallbackEncoding: "latin-1",
+			fileContents:     "\xe9t\xe9\n",
+			expectedText:     "été",
+			expectedContents: "x\xe9t\xe9\n",
+		},
+		{
+			name:             "convert Latin-1 to UTF-8",
+			fallbackEncoding: "latin-1",
+			fileContents:     "\xe9t\xe9\n",
+			convertEncoding:  file.EncodingUTF8,
+			expectedText:     "été",
+			expectedContents: "xété\n",
+		},
+		{
+			name:             "convert UTF-8 to UTF-16LE",
+			fallbackEncoding: "none",
+			fileContents:     "ab\n",
+			convertEncoding:  file.EncodingUTF16LE,
+			convertBOM:       true,
+			expectedText:     "ab",
+			expectedContents: "\xff\xfex\x00a\x00b\x00\n\x00",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path, cleanup := createTestFile(t, tc.fileContents)
+			defer cleanup()
+
+			configRuleSet := config.RuleSet{
+				{
+					Name:    "fallbackEncoding",
+					Pattern: "**",
+					Config:  map[string]any{"fallbackEncoding": tc.fallbackEncoding},
+				},
+			}
+			state := NewEditorState(100, 100, configRuleSet, nil)
+			LoadDocument(state, path, true, startOfDocLocator)
+			defer state.documentBuffer.fileWatcher.Stop()
+
+			if tc.expectHex {
+				assert.True(t, state.documentBuffer.fileFormat.Hex)
+				assert.Contains(t, state.statusMsg.Text, "in hex mode")
+				return
+			}
+			assert.False(t, state.documentBuffer.fileFormat.Hex)
+			assert.Equal(t, tc.expectedText, state.documentBuffer.textTree.String())
+
+			if tc.convertEncoding != "" {
+				ConvertEncoding(state, tc.convertEncoding, tc.convertBOM)
+				assert.True(t, state.documentBuffer.hasUnsavedChanges())
+			}
+
+			InsertRune(state, 'x')
+			SaveDocument(state)
+			defer state.documentBuffer.fileWatcher.Stop()
+
+			contents, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedContents, string(contents))
+		})
+	}
+}
+
+func TestLoadAndSaveGzipDocument(t *testing.T) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := io.WriteString(w, "{\"a\": 1}\n")
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	path := filepath.Join(t.TempDir(), "test.json.gz")
+	err = os.WriteFile(path, buf.Bytes(), 0644)
+	require.NoError(t, err)
+
+	configRuleSet := config.RuleSet{
+		{
+			Name:    "json",
+			Pattern: "**/*.json",
+			Config:  map[string]any{"syntaxLanguage": "json"},
+		},
+	}
+	state := NewEditorState(100, 100, configRuleSet, nil)
+	LoadDocument(state, path, true, startOfDocLocator)
+	defer state.documentBuffer.fileWatcher.Stop()
+
+	// The document is decompressed, and the configuration for the uncompressed file type applies.
+	assert.Equal(t, "{\"a\": 1}", state.documentBuffer.textTree.String())
+	assert.Equal(t, file.CompressionGzip, state.documentBuffer.fileFormat.Compression)
+	assert.Equal(t, syntax.LanguageJson, state.documentBuffer.syntaxLanguage)
+
+	InsertRune(state, 'x')
+	SaveDocument(state)
+	defer state.documentBuffer.fileWatcher.Stop()
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	r, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	contents, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "x{\"a\": 1}\n", string(contents))
+}
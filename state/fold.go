@@ -0,0 +1,516 @@
+package state
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/aretext/aretext/locate"
+	"github.com/aretext/aretext/syntax/parser"
+	"github.com/aretext/aretext/text"
+)
+
+// foldRange is a closed fold, which hides the lines in the range [startPos, endPos).
+// The line before the range is the first line of the folded block, and it remains visible.
+type foldRange struct {
+	startPos uint64 // Start of the first hidden line.
+	endPos   uint64 // Start of the line after the last hidden line, or the end of the document.
+}
+
+// foldBlock is a block of lines that can be folded.
+// Folding the block hides every line after the first, up to and including the last line.
+type foldBlock struct {
+	firstLineNum uint64
+	lastLineNum  uint64
+}
+
+func (b foldBlock) containsLineNum(lineNum uint64) bool {
+	return lineNum >= b.firstLineNum && lineNum <= b.lastLineNum
+}
+
+func (b foldBlock) foldRange(tree *text.Tree) foldRange {
+	endPos := tree.NumChars()
+	if b.lastLineNum+1 < tree.NumLines() {
+		endPos = tree.LineStartPosition(b.lastLineNum + 1)
+	}
+	return foldRange{
+		startPos: tree.LineStartPosition(b.firstLineNum + 1),
+		endPos:   endPos,
+	}
+}
+
+// foldBracketPairs maps each closing bracket to its opening bracket.
+var foldBracketPairs = map[rune]rune{')': '(', ']': '[', '}': '{'}
+
+// foldableBlocks returns the blocks in the document that can be folded, sorted by first line.
+// A block is a line followed by lines with greater indentation.  If the document has a syntax language,
+// a line ending inside brackets starts a block that ends before the line with the closing bracket,
+// ignoring brackets in strings and comments.  Where both apply, the brackets take precedence.
+func foldableBlocks(buffer *BufferState) []foldBlock {
+	lastLineNums := make(map[uint64]uint64)
+	for _, b := range indentationBlocks(buffer.textTree, buffer.tabSize) {
+		lastLineNums[b.firstLineNum] = b.lastLineNum
+	}
+
+	if buffer.syntaxParser != nil {
+		tokens := buffer.SyntaxTokensIntersectingRange(0, buffer.textTree.NumChars())
+		for _, b := range bracketBlocks(buffer.textTree, tokens) {
+			lastLineNums[b.firstLineNum] = b.lastLineNum
+		}
+	}
+
+	blocks := make([]foldBlock, 0, len(lastLineNums))
+	for firstLineNum, lastLineNum := range lastLineNums {
+		blocks = append(blocks, foldBlock{firstLineNum, lastLineNum})
+	}
+	sort.Slice(blocks, func(i, j int) bool {
+		return blocks[i].firstLineNum < blocks[j].firstLineNum
+	})
+	return blocks
+}
+
+// indentationBlocks returns blocks of lines with greater indentation than the line before the block.
+// Blank lines within a block are included, but blank lines at the end of a block are not.
+func indentationBlocks(tree *text.Tree, tabSize uint64) []foldBlock {
+	type indentedLine struct {
+		lineNum uint64
+		indent  uint64
+	}
+
+	var blocks []foldBlock
+	var stack []indentedLine
+	var lastNonBlankLineNum uint64
+	popWhile := func(f func(indentedLine) bool) {
+		for len(stack) > 0 && f(stack[len(stack)-1]) {
+			line := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if lastNonBlankLineNum > line.lineNum {
+				blocks = append(blocks, foldBlock{line.lineNum, lastNonBlankLineNum})
+			}
+		}
+	}
+
+	for lineNum := uint64(0); lineNum < tree.NumLines(); lineNum++ {
+		indent, blank := lineIndentCols(tree, lineNum, tabSize)
+		if blank {
+			continue
+		}
+		popWhile(func(line indentedLine) bool { return line.indent >= indent })
+		stack = append(stack, indentedLine{lineNum, indent})
+		lastNonBlankLineNum = lineNum
+	}
+	popWhile(func(indentedLine) bool { return true })
+
+	return blocks
+}
+
+// lineIndentCols returns the number of cells occupied by the leading whitespace of a line,
+// and whether the line contains only whitespace.
+func lineIndentCols(tree *text.Tree, lineNum uint64, tabSize uint64) (uint64, bool) {
+	reader := tree.ReaderAtPosition(tree.LineStartPosition(lineNum))
+	var cols uint64
+	for {
+		r, _, err := reader.ReadRune()
+		if err == io.EOF {
+			return cols, true
+		} else if err != nil {
+			panic(err) // Should never happen because the tree is valid UTF-8.
+		}
+
+		switch r {
+		case ' ':
+			cols++
+		case '\t':
+			cols += tabSize - (cols % tabSize)
+		case '\r':
+			continue
+		case '\n':
+			return cols, true
+		default:
+			return cols, false
+		}
+	}
+}
+
+// bracketBlocks returns blocks that start on a line with an opening bracket and end
+// before the line with the matching closing bracket.  Brackets within string and comment tokens are ignored.
+func bracketBlocks(tree *text.Tree, tokens []parser.Token) []foldBlock {
+	type openBracket struct {
+		r       rune
+		lineNum uint64
+	}
+
+	lastLineNums := make(map[uint64]uint64)
+	var stack []openBracket
+	var pos, lineNum uint64
+	reader := tree.ReaderAtPosition(0)
+	for {
+		r, _, err := reader.ReadRune()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			panic(err) // Should never happen because the tree is valid UTF-8.
+		}
+
+		for len(tokens) > 0 && tokens[0].EndPos <= pos {
+			tokens = tokens[1:]
+		}
+		inStringOrComment := len(tokens) > 0 && tokens[0].StartPos <= pos &&
+			(tokens[0].Role == parser.TokenRoleString || tokens[0].Role == parser.TokenRoleComment)
+
+		if !inStringOrComment {
+			switch r {
+			case '(', '[', '{':
+				stack = append(stack, openBracket{r, lineNum})
+			case ')', ']', '}':
+				if n := len(stack); n > 0 && stack[n-1].r == foldBracketPairs[r] {
+					open := stack[n-1]
+					stack = stack[:n-1]
+					if lineNum > open.lineNum+1 && lineNum-1 > lastLineNums[open.lineNum] {
+						lastLineNums[open.lineNum] = lineNum - 1
+					}
+				}
+			}
+		}
+
+		if r == '\n' {
+			lineNum++
+		}
+		pos++
+	}
+
+	blocks := make([]foldBlock, 0, len(lastLineNums))
+	for firstLineNum, lastLineNum := range lastLineNums {
+		blocks = append(blocks, foldBlock{firstLineNum, lastLineNum})
+	}
+	return blocks
+}
+
+// FoldedRanges returns the ranges of lines hidden by closed folds, sorted by position.
+// Nested and overlapping folds are merged, so the ranges don't overlap.
+func (s *BufferState) FoldedRanges() []locate.FoldedRange {
+	if len(s.folds) == 0 {
+		return nil
+	}
+
+	folds := append([]foldRange(nil), s.folds...)
+	sort.Slice(folds, func(i, j int) bool {
+		return folds[i].startPos < folds[j].startPos
+	})
+
+	result := make([]locate.FoldedRange, 0, len(folds))
+	for _, f := range folds {
+		if n := len(result); n > 0 && f.startPos <= result[n-1].EndPos {
+			// A fold that starts within or immediately after another is hidden with it.
+			if f.endPos > result[n-1].EndPos {
+				result[n-1].EndPos = f.endPos
+			}
+			continue
+		}
+		result = append(result, locate.FoldedRange{StartPos: f.startPos, EndPos: f.endPos})
+	}
+
+	for i, r := range result {
+		firstLineNum := s.textTree.LineNumForPosition(r.StartPos)
+		lastLineNum := s.textTree.LineNumForPosition(r.EndPos - 1)
+		result[i].NumLines = lastLineNum - firstLineNum + 1
+	}
+
+	return result
+}
+
+// foldHidesPosition returns whether a position is within the lines hidden by a fold.
+// The end of the document is hidden if it is on the last hidden line.
+func foldHidesPosition(buffer *BufferState, startPos, endPos, pos uint64) bool {
+	if pos >= startPos && pos < endPos {
+		return true
+	}
+
+	if pos == endPos && pos == buffer.textTree.NumChars() {
+		r, ok := runeAtPosition(buffer, pos-1)
+		return ok && r != '\n'
+	}
+
+	return false
+}
+
+// foldedRangeHidingPosition returns the folded range that hides a position, if any.
+func foldedRangeHidingPosition(buffer *BufferState, pos uint64) (locate.FoldedRange, bool) {
+	for _, r := range buffer.FoldedRanges() {
+		if foldHidesPosition(buffer, r.StartPos, r.EndPos, pos) {
+			return r, true
+		}
+	}
+	return locate.FoldedRange{}, false
+}
+
+// visiblePosition returns the start of the line before the folded range hiding a position,
+// or the position itself if it isn't hidden.
+func visiblePosition(buffer *BufferState, pos uint64) uint64 {
+	if r, ok := foldedRangeHidingPosition(buffer, pos); ok {
+		return buffer.textTree.LineStartPosition(buffer.textTree.LineNumForPosition(r.StartPos - 1))
+	}
+	return pos
+}
+
+// unfoldAtPosition opens every closed fold that hides a position.
+func unfoldAtPosition(buffer *BufferState, pos uint64) {
+	folds := buffer.folds[:0]
+	for _, f := range buffer.folds {
+		if !foldHidesPosition(buffer, f.startPos, f.endPos, pos) {
+			folds = append(folds, f)
+		}
+	}
+	buffer.folds = folds
+}
+
+// updateFoldsAfterInsert shifts closed folds after inserted text.
+// A fold is opened if the text is inserted into its hidden lines.
+func updateFoldsAfterInsert(buffer *BufferState, pos uint64, n uint64) {
+	numCharsBeforeInsert := buffer.textTree.NumChars() - n
+	folds := buffer.folds[:0]
+	for _, f := range buffer.folds {
+		if pos < f.startPos {
+			f.startPos += n
+			f.endPos += n
+		} else if pos < f.endPos || (pos == f.endPos && pos == numCharsBeforeInsert) {
+			continue
+		}
+		folds = append(folds, f)
+	}
+	buffer.folds = folds
+}
+
+// updateFoldsAfterDelete shifts closed folds after deleted text.
+// A fold is opened if the deleted text includes its hidden lines or the line feed before them.
+func updateFoldsAfterDelete(buffer *BufferState, pos uint64, n uint64) {
+	folds := buffer.folds[:0]
+	for _, f := range buffer.folds {
+		if pos+n < f.startPos {
+			f.startPos -= n
+			f.endPos -= n
+		} else if pos < f.endPos {
+			continue
+		}
+		folds = append(folds, f)
+	}
+	buffer.folds = folds
+}
+
+// lineNumBelowSkippingFolds returns the line count lines below a line,
+// counting each closed fold as a single line. The result is at most the last line of the document.
+func lineNumBelowSkippingFolds(buffer *BufferState, lineNum uint64, count uint64) uint64 {
+	lastLineNum := locate.ClosestValidLineNum(buffer.textTree, buffer.textTree.NumLines())
+	folded := foldedLineBlocks(buffer)
+	if len(folded) == 0 {
+		return locate.ClosestValidLineNum(buffer.textTree, lineNum+count)
+	}
+
+	for i := uint64(0); i < count && lineNum < lastLineNum; i++ {
+		next := lineNum + 1
+		for _, b := range folded {
+			if b.containsLineNum(next) {
+				next = b.lastLineNum + 1
+			}
+		}
+		if next > lastLineNum {
+			break
+		}
+		lineNum = next
+	}
+	return lineNum
+}
+
+// lineNumAboveSkippingFolds returns the line count lines above a line,
+// counting each closed fold as a single line. The result is at least the first line of the document.
+func lineNumAboveSkippingFolds(buffer *BufferState, lineNum uint64, count uint64) uint64 {
+	folded := foldedLineBlocks(buffer)
+	if len(folded) == 0 {
+		if lineNum < count {
+			return 0
+		}
+		return lineNum - count
+	}
+
+	for i := uint64(0); i < count && lineNum > 0; i++ {
+		prev := lineNum - 1
+		for j := len(folded) - 1; j >= 0; j-- {
+			if b := folded[j]; b.containsLineNum(prev) {
+				prev = b.firstLineNum - 1
+			}
+		}
+		lineNum = prev
+	}
+	return lineNum
+}
+
+// numLinesBetweenSkippingFolds returns the number of lines after startLineNum up to and including endLineNum,
+// counting each closed fold as a single line.
+func numLinesBetweenSkippingFolds(buffer *BufferState, startLineNum, endLineNum uint64) uint64 {
+	if endLineNum <= startLineNum {
+		return 0
+	}
+
+	n := endLineNum - startLineNum
+	for _, b := range foldedLineBlocks(buffer) {
+		first, last := b.firstLineNum, b.lastLineNum
+		if first <= startLineNum {
+			first = startLineNum + 1
+		}
+		if last > endLineNum {
+			last = endLineNum
+		}
+		if first <= last {
+			n -= last - first + 1
+		}
+	}
+	return n
+}
+
+// foldedLineBlocks returns the lines hidden by closed folds, sorted by line number.
+// Unlike a foldBlock, the first line of each result is hidden.
+func foldedLineBlocks(buffer *BufferState) []foldBlock {
+	ranges := buffer.FoldedRanges()
+	blocks := make([]foldBlock, 0, len(ranges))
+	for _, r := range ranges {
+		firstLineNum := buffer.textTree.LineNumForPosition(r.StartPos)
+		blocks = append(blocks, foldBlock{firstLineNum, firstLineNum + r.NumLines - 1})
+	}
+	return blocks
+}
+
+// moveCursorOutOfFolds moves the cursor to the start of the visible line before a closed fold
+// if the cursor is hidden by the fold.
+func moveCursorOutOfFolds(state *EditorState) {
+	buffer := state.documentBuffer
+	if pos := visiblePosition(buffer, buffer.cursor.position); pos != buffer.cursor.position {
+		buffer.cursor = cursorState{position: pos}
+	}
+}
+
+func checkCanFold(buffer *BufferState) error {
+	if buffer.fileFormat.Hex {
+		return errors.New("Cannot fold a document in hex mode")
+	} else if buffer.pager != nil {
+		return errors.New("Cannot fold a large file")
+	}
+	return nil
+}
+
+// FoldBlockAtCursor closes a fold for the innermost block containing the cursor line that isn't already folded.
+// If the cursor is within the block, it moves to the first line of the block.
+func FoldBlockAtCursor(state *EditorState) {
+	buffer := state.documentBuffer
+	if err := checkCanFold(buffer); err != nil {
+		setFoldErrorStatus(state, err)
+		return
+	}
+
+	cursorLineNum := buffer.textTree.LineNumForPosition(buffer.cursor.position)
+	blocks := foldableBlocks(buffer)
+	for i := len(blocks) - 1; i >= 0; i-- {
+		b := blocks[i]
+		if !b.containsLineNum(cursorLineNum) {
+			continue
+		}
+
+		f := b.foldRange(buffer.textTree)
+		if isFoldClosed(buffer, f) {
+			continue
+		}
+
+		buffer.folds = append(buffer.folds, f)
+		moveCursorOutOfFolds(state)
+		return
+	}
+
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleError,
+		Text:  "No block to fold at the cursor",
+	})
+}
+
+func isFoldClosed(buffer *BufferState, f foldRange) bool {
+	for _, closed := range buffer.folds {
+		if closed == f {
+			return true
+		}
+	}
+	return false
+}
+
+// UnfoldBlockAtCursor opens the closed folds that start on the cursor line.
+// Folds nested within them remain closed.
+func UnfoldBlockAtCursor(state *EditorState) {
+	if !unfoldBlockAtCursor(state.documentBuffer) {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  "No fold at the cursor",
+		})
+	}
+}
+
+func unfoldBlockAtCursor(buffer *BufferState) bool {
+	cursorLineNum := buffer.textTree.LineNumForPosition(buffer.cursor.position)
+	if cursorLineNum+1 >= buffer.textTree.NumLines() {
+		return false
+	}
+
+	nextLineStartPos := buffer.textTree.LineStartPosition(cursorLineNum + 1)
+	folds := buffer.folds[:0]
+	for _, f := range buffer.folds {
+		if f.startPos != nextLineStartPos {
+			folds = append(folds, f)
+		}
+	}
+	didUnfold := len(folds) < len(buffer.folds)
+	buffer.folds = folds
+	return didUnfold
+}
+
+// ToggleFoldAtCursor opens the closed folds that start on the cursor line,
+// or, if there are none, folds the block containing the cursor.
+func ToggleFoldAtCursor(state *EditorState) {
+	if !unfoldBlockAtCursor(state.documentBuffer) {
+		FoldBlockAtCursor(state)
+	}
+}
+
+// FoldAllToLevel closes a fold for every block nested at the given level or deeper,
+// where top-level blocks are level one.  Folds for blocks at lower levels are opened.
+func FoldAllToLevel(state *EditorState, level uint64) {
+	buffer := state.documentBuffer
+	if err := checkCanFold(buffer); err != nil {
+		setFoldErrorStatus(state, err)
+		return
+	}
+
+	var folds []foldRange
+	var enclosing []foldBlock
+	for _, b := range foldableBlocks(buffer) {
+		for len(enclosing) > 0 && enclosing[len(enclosing)-1].lastLineNum < b.firstLineNum {
+			enclosing = enclosing[:len(enclosing)-1]
+		}
+		enclosing = append(enclosing, b)
+		if uint64(len(enclosing)) >= level {
+			folds = append(folds, b.foldRange(buffer.textTree))
+		}
+	}
+
+	buffer.folds = folds
+	moveCursorOutOfFolds(state)
+}
+
+// UnfoldAll opens every closed fold in the document.
+func UnfoldAll(state *EditorState) {
+	state.documentBuffer.folds = nil
+}
+
+func setFoldErrorStatus(state *EditorState, err error) {
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleError,
+		Text:  fmt.Sprintf("Could not fold: %s", err),
+	})
+}
@@ -0,0 +1,305 @@
+package state
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/locate"
+	"github.com/aretext/aretext/syntax"
+	"github.com/aretext/aretext/text"
+)
+
+func newFoldTestState(t *testing.T, s string) *EditorState {
+	textTree, err := text.NewTreeFromString(s)
+	require.NoError(t, err)
+	state := NewEditorState(100, 100, nil, nil)
+	state.documentBuffer.textTree = textTree
+	return state
+}
+
+func moveCursorToLineNum(state *EditorState, lineNum uint64) {
+	MoveCursor(state, func(p LocatorParams) uint64 {
+		return p.TextTree.LineStartPosition(lineNum)
+	})
+}
+
+func TestFoldableBlocks(t *testing.T) {
+	testCases := []struct {
+		name           string
+		inputString    string
+		language       syntax.Language
+		expectedBlocks []foldBlock
+	}{
+		{
+			name:           "empty",
+			inputString:    "",
+			language:       syntax.LanguagePlaintext,
+			expectedBlocks: []foldBlock{},
+		},
+		{
+			name:        "indentation",
+			inputString: "a\n  b\n  c\n\nd\n  e\n    f\n\n",
+			language:    syntax.LanguagePlaintext,
+			expectedBlocks: []foldBlock{
+				{firstLineNum: 0, lastLineNum: 2},
+				{firstLineNum: 4, lastLineNum: 6},
+				{firstLineNum: 5, lastLineNum: 6},
+			},
+		},
+		{
+			name:        "indentation with tabs",
+			inputString: "a\n\tb\n    c\nd",
+			language:    syntax.LanguagePlaintext,
+			expectedBlocks: []foldBlock{
+				{firstLineNum: 0, lastLineNum: 2},
+			},
+		},
+		{
+			name:           "brackets ignored without syntax language",
+			inputString:    "x := []int{\n1,\n2,\n}",
+			language:       syntax.LanguagePlaintext,
+			expectedBlocks: []foldBlock{},
+		},
+		{
+			name:        "brackets",
+			inputString: "x := []int{\n1,\n2,\n}\ny := f(a,\nb)",
+			language:    syntax.LanguageGo,
+			expectedBlocks: []foldBlock{
+				{firstLineNum: 0, lastLineNum: 2},
+			},
+		},
+		{
+			name:           "brackets in strings and comments",
+			inputString:    "x := \"{\"\n// {\ny\nz\n}",
+			language:       syntax.LanguageGo,
+			expectedBlocks: []foldBlock{},
+		},
+		{
+			name:        "brackets take precedence over indentation",
+			inputString: "func f() {\n\tx\n\ty\n\t}\n",
+			language:    syntax.LanguageGo,
+			expectedBlocks: []foldBlock{
+				{firstLineNum: 0, lastLineNum: 2},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			state := newFoldTestState(t, tc.inputString)
+			setSyntaxAndRetokenize(state.documentBuffer, tc.language)
+			blocks := foldableBlocks(state.documentBuffer)
+			assert.Equal(t, tc.expectedBlocks, blocks)
+		})
+	}
+}
+
+func TestFoldAndUnfoldBlockAtCursor(t *testing.T) {
+	state := newFoldTestState(t, "a\n  b\n    c\n  d\ne")
+	buffer := state.documentBuffer
+
+	// Fold the innermost block, which moves the cursor to the first line of the block.
+	moveCursorToLineNum(state, 2)
+	FoldBlockAtCursor(state)
+	assert.Equal(t, []locate.FoldedRange{{StartPos: 6, EndPos: 12, NumLines: 1}}, buffer.FoldedRanges())
+	assert.Equal(t, uint64(2), buffer.cursor.position)
+
+	// Fold again to fold the enclosing block.
+	FoldBlockAtCursor(state)
+	assert.Equal(t, []locate.FoldedRange{{StartPos: 2, EndPos: 16, NumLines: 3}}, buffer.FoldedRanges())
+	assert.Equal(t, uint64(0), buffer.cursor.position)
+
+	// Unfold the enclosing block, and the nested block remains folded.
+	UnfoldBlockAtCursor(state)
+	assert.Equal(t, []locate.FoldedRange{{StartPos: 6, EndPos: 12, NumLines: 1}}, buffer.FoldedRanges())
+
+	// Toggle the enclosing block.
+	ToggleFoldAtCursor(state)
+	assert.Equal(t, []locate.FoldedRange{{StartPos: 2, EndPos: 16, NumLines: 3}}, buffer.FoldedRanges())
+	ToggleFoldAtCursor(state)
+	assert.Equal(t, []locate.FoldedRange{{StartPos: 6, EndPos: 12, NumLines: 1}}, buffer.FoldedRanges())
+
+	UnfoldAll(state)
+	assert.Nil(t, buffer.FoldedRanges())
+
+	UnfoldBlockAtCursor(state)
+	assert.Equal(t, StatusMsg{Style: StatusMsgStyleError, Text: "No fold at the cursor"}, state.StatusMsg())
+
+	moveCursorToLineNum(state, 4)
+	FoldBlockAtCursor(state)
+	assert.Equal(t, StatusMsg{Style: StatusMsgStyleError, Text: "No block to fold at the cursor"}, state.StatusMsg())
+	assert.Nil(t, buffer.FoldedRanges())
+}
+
+func TestFoldAllToLevel(t *testing.T) {
+	testCases := []struct {
+		name           string
+		level          uint64
+		expectedRanges []locate.FoldedRange
+	}{
+		{
+			name:  "level one",
+			level: 1,
+			expectedRanges: []locate.FoldedRange{
+				{StartPos: 2, EndPos: 16, NumLines: 3},
+				{StartPos: 18, EndPos: 20, NumLines: 1},
+			},
+		},
+		{
+			name:  "level two",
+			level: 2,
+			expectedRanges: []locate.FoldedRange{
+				{StartPos: 6, EndPos: 12, NumLines: 1},
+			},
+		},
+		{
+			name:           "level three",
+			level:          3,
+			expectedRanges: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			state := newFoldTestState(t, "a\n  b\n    c\n  d\ne\n f")
+			moveCursorToLineNum(state, 2)
+			FoldAllToLevel(state, tc.level)
+			assert.Equal(t, tc.expectedRanges, state.documentBuffer.FoldedRanges())
+
+			// The cursor moves out of the folds.
+			cursorPos := state.documentBuffer.cursor.position
+			for _, r := range state.documentBuffer.FoldedRanges() {
+				assert.False(t, r.ContainsPosition(cursorPos))
+			}
+		})
+	}
+}
+
+func TestCursorMovementWithFolds(t *testing.T) {
+	state := newFoldTestState(t, "a\nb\n c\n d\ne\nf")
+	buffer := state.documentBuffer
+	cursorLineNum := func() uint64 {
+		return buffer.textTree.LineNumForPosition(buffer.cursor.position)
+	}
+
+	moveCursorToLineNum(state, 1)
+	FoldBlockAtCursor(state)
+	require.Equal(t, []locate.FoldedRange{{StartPos: 4, EndPos: 10, NumLines: 2}}, buffer.FoldedRanges())
+
+	// Moving up and down skips the hidden lines.
+	MoveCursorToLineBelow(state, 1)
+	assert.Equal(t, uint64(4), cursorLineNum())
+	MoveCursorToLineAbove(state, 1)
+	assert.Equal(t, uint64(1), cursorLineNum())
+	MoveCursorToLineBelow(state, 2)
+	assert.Equal(t, uint64(5), cursorLineNum())
+	MoveCursorToStartOfLineAbove(state, 3)
+	assert.Equal(t, uint64(0), cursorLineNum())
+	MoveCursorToStartOfLineBelow(state, 2)
+	assert.Equal(t, uint64(4), cursorLineNum())
+
+	// Other movements open the fold if the cursor is hidden.
+	MoveCursor(state, func(LocatorParams) uint64 { return 5 })
+	ScrollViewToCursor(state)
+	assert.Nil(t, buffer.FoldedRanges())
+}
+
+func TestRelativeLineNumbersWithFolds(t *testing.T) {
+	state := newFoldTestState(t, "a\nb\n c\n d\ne\nf")
+	buffer := state.documentBuffer
+	buffer.lineNumMode = LineNumModeRelative
+	moveCursorToLineNum(state, 1)
+	FoldBlockAtCursor(state)
+	moveCursorToLineNum(state, 0)
+
+	// Each closed fold counts as a single line, so the displayed number matches a counted movement.
+	assert.Equal(t, uint64(0), buffer.LineNumForDisplay(0))
+	assert.Equal(t, uint64(1), buffer.LineNumForDisplay(1))
+	assert.Equal(t, uint64(2), buffer.LineNumForDisplay(4))
+	assert.Equal(t, uint64(3), buffer.LineNumForDisplay(5))
+	MoveCursorToLineBelow(state, 2)
+	assert.Equal(t, uint64(4), buffer.textTree.LineNumForPosition(buffer.cursor.position))
+
+	moveCursorToLineNum(state, 5)
+	assert.Equal(t, uint64(1), buffer.LineNumForDisplay(4))
+	assert.Equal(t, uint64(2), buffer.LineNumForDisplay(1))
+	assert.Equal(t, uint64(3), buffer.LineNumForDisplay(0))
+	MoveCursorToLineAbove(state, 2)
+	assert.Equal(t, uint64(1), buffer.textTree.LineNumForPosition(buffer.cursor.position))
+}
+
+func TestScrollViewByNumLinesWithFolds(t *testing.T) {
+	state := newFoldTestState(t, "0\n1\n 2\n 3\n 4\n 5\n 6\n 7\n8\n9\n10\n11\n12\n13\n14\n15")
+	buffer := state.documentBuffer
+	buffer.view = viewState{textOrigin: 0, height: 5, width: 100}
+	moveCursorToLineNum(state, 1)
+	FoldBlockAtCursor(state)
+
+	ScrollViewByNumLines(state, ScrollDirectionForward, 3)
+	assert.Equal(t, uint64(9), buffer.textTree.LineNumForPosition(buffer.view.textOrigin))
+
+	ScrollViewByNumLines(state, ScrollDirectionBackward, 2)
+	assert.Equal(t, uint64(1), buffer.textTree.LineNumForPosition(buffer.view.textOrigin))
+
+	// Scrolling to the end keeps the last lines visible, counting the fold as one line.
+	buffer.view.height = 12
+	ScrollViewByNumLines(state, ScrollDirectionForward, 100)
+	assert.Equal(t, uint64(1), buffer.textTree.LineNumForPosition(buffer.view.textOrigin))
+}
+
+func TestFoldsAfterEdits(t *testing.T) {
+	state := newFoldTestState(t, "a\n b\nc")
+	buffer := state.documentBuffer
+	FoldBlockAtCursor(state)
+	require.Equal(t, []locate.FoldedRange{{StartPos: 2, EndPos: 5, NumLines: 1}}, buffer.FoldedRanges())
+
+	// Insert before the fold.
+	mustInsertTextAtPosition(state, "xy", 0, true)
+	assert.Equal(t, []locate.FoldedRange{{StartPos: 4, EndPos: 7, NumLines: 1}}, buffer.FoldedRanges())
+
+	// Insert after the fold.
+	mustInsertTextAtPosition(state, "z", 7, true)
+	assert.Equal(t, []locate.FoldedRange{{StartPos: 4, EndPos: 7, NumLines: 1}}, buffer.FoldedRanges())
+
+	// Delete before the fold.
+	deleteRunes(state, 0, 1, true)
+	assert.Equal(t, []locate.FoldedRange{{StartPos: 3, EndPos: 6, NumLines: 1}}, buffer.FoldedRanges())
+
+	// Deleting the line feed before the hidden lines opens the fold.
+	deleteRunes(state, 2, 1, true)
+	assert.Equal(t, "ya b\nzc", buffer.textTree.String())
+	assert.Nil(t, buffer.FoldedRanges())
+}
+
+func TestReloadDocumentClearsFolds(t *testing.T) {
+	path, cleanup := createTestFile(t, "a\n  b\n  c\n  d\ne")
+	defer cleanup()
+	state := NewEditorState(100, 100, nil, nil)
+	LoadDocument(state, path, true, startOfDocLocator)
+	defer state.documentBuffer.fileWatcher.Stop()
+
+	FoldBlockAtCursor(state)
+	require.Equal(t, []locate.FoldedRange{{StartPos: 2, EndPos: 14, NumLines: 3}}, state.documentBuffer.FoldedRanges())
+
+	// Reload the document with different text, and expect that no lines are hidden.
+	err := os.WriteFile(path, []byte("x\ny\nz\nw"), 0644)
+	require.NoError(t, err)
+	ReloadDocument(state)
+	assert.Equal(t, "x\ny\nz\nw", state.documentBuffer.textTree.String())
+	assert.Nil(t, state.documentBuffer.FoldedRanges())
+}
+
+func TestSearchOpensFold(t *testing.T) {
+	state := newFoldTestState(t, "a\n b\nc")
+	FoldBlockAtCursor(state)
+	require.NotNil(t, state.documentBuffer.FoldedRanges())
+
+	StartSearch(state, SearchDirectionForward)
+	AppendRuneToSearchQuery(state, 'b')
+	CompleteSearch(state, true)
+	assert.Equal(t, uint64(3), state.documentBuffer.cursor.position)
+	assert.Nil(t, state.documentBuffer.FoldedRanges())
+}
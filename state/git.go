@@ -0,0 +1,201 @@
+package state
+
+import (
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aretext/aretext/config"
+	"github.com/aretext/aretext/file"
+	"github.com/aretext/aretext/text"
+)
+
+// GitChange is the change to a line since the HEAD commit of the file's git repository.
+type GitChange int
+
+const (
+	GitChangeNone = GitChange(iota)
+	GitChangeAdded
+	GitChangeModified
+	GitChangeRemoved // Lines were removed after this line, or before the first line.
+)
+
+// gitChangesDebounceInterval is how long to wait after the last edit before recalculating git changes,
+// so typing doesn't diff the entire document after every keystroke.
+// This is a variable so tests can disable the delay.
+var gitChangesDebounceInterval = 500 * time.Millisecond
+
+// gitChangesState tracks the lines of a document that changed since the git HEAD commit.
+// Edits mark the changes stale, and they are recalculated once the user stops editing.
+// Until then, the display shows the changes from before the edits.
+type gitChangesState struct {
+	headText     string
+	ranges       []gitChangeRange
+	stale        bool
+	lastEditTime time.Time
+	refreshChan  chan func(*EditorState)
+	refreshTimer *time.Timer
+}
+
+// gitChangeRange is a range of lines [startLineNum, endLineNum) in the document with the same change.
+type gitChangeRange struct {
+	startLineNum, endLineNum uint64
+	change                   GitChange
+}
+
+// loadGitChangesInBackground loads the file's version in the git HEAD commit to compare against the document.
+// Git can be slow in large repositories, so this runs in a goroutine to avoid blocking user input.
+// The buffer has no git changes until the load completes, or if the file isn't tracked in a git repository.
+func loadGitChangesInBackground(state *EditorState, buffer *BufferState, cfg config.Config) {
+	path := buffer.fileWatcher.Path()
+	if path == "" {
+		return
+	}
+
+	var fallbackEncoding file.Encoding
+	if cfg.FallbackEncoding != config.FallbackEncodingNone {
+		fallbackEncoding = file.Encoding(cfg.FallbackEncoding)
+	}
+
+	// The undo log is replaced whenever the document is loaded, so it identifies this load.
+	undoLog := buffer.undoLog
+	resultChan := state.backgroundResultChan
+	go func() {
+		tree, err := file.LoadGitHead(path, fallbackEncoding)
+		resultChan <- func(state *EditorState) {
+			if buffer.undoLog != undoLog {
+				// The document was reloaded while git was running, so discard the result.
+				return
+			}
+
+			if err != nil {
+				log.Printf("Could not load git HEAD for '%s', so git changes are disabled: %v\n", path, err)
+				return
+			}
+
+			// Every line ends with a line feed when comparing to the document, so appending
+			// a line to the end of the document doesn't also change the previous last line.
+			log.Printf("Loaded git HEAD for '%s'\n", path)
+			buffer.gitChanges = &gitChangesState{
+				headText:    tree.String() + "\n",
+				stale:       true,
+				refreshChan: resultChan,
+			}
+		}
+	}()
+}
+
+// markStale indicates that the document was edited, so the changes must be recalculated.
+// This schedules a redraw once the debounce interval elapses, restarting the interval on each edit.
+func (g *gitChangesState) markStale() {
+	if g == nil {
+		return
+	}
+
+	g.stale = true
+	g.lastEditTime = time.Now()
+	if g.refreshTimer == nil {
+		g.refreshTimer = time.AfterFunc(gitChangesDebounceInterval, g.requestRedraw)
+	} else {
+		g.refreshTimer.Reset(gitChangesDebounceInterval)
+	}
+}
+
+// requestRedraw sends an empty action to the editor's main loop, which redraws after every action.
+// Drawing the git change markers recalculates the changes.
+// If another action is already pending, it will trigger the redraw instead.
+func (g *gitChangesState) requestRedraw() {
+	select {
+	case g.refreshChan <- func(*EditorState) {}:
+	default:
+	}
+}
+
+// update recalculates the changes if the document was edited since the last update.
+// Unless force is true, this waits until the debounce interval has elapsed since the last edit.
+func (g *gitChangesState) update(tree *text.Tree, force bool) {
+	if !g.stale || (!force && time.Since(g.lastEditTime) < gitChangesDebounceInterval) {
+		return
+	}
+
+	g.stale = false
+	treeReader := tree.ReaderAtPosition(0)
+	hunks, err := text.Diff(strings.NewReader(g.headText), io.MultiReader(&treeReader, strings.NewReader("\n")))
+	if err != nil {
+		log.Printf("Error calculating git changes: %v\n", err)
+		g.ranges = nil
+		return
+	}
+
+	g.ranges = g.ranges[:0]
+	for _, h := range hunks {
+		if h.RightStartLineNum == h.RightEndLineNum {
+			// Mark the line before the removed lines, or the first line if removed from the start.
+			lineNum := h.RightStartLineNum
+			if lineNum > 0 {
+				lineNum--
+			}
+			g.ranges = append(g.ranges, gitChangeRange{lineNum, lineNum + 1, GitChangeRemoved})
+		} else if h.LeftStartLineNum == h.LeftEndLineNum {
+			g.ranges = append(g.ranges, gitChangeRange{h.RightStartLineNum, h.RightEndLineNum, GitChangeAdded})
+		} else {
+			g.ranges = append(g.ranges, gitChangeRange{h.RightStartLineNum, h.RightEndLineNum, GitChangeModified})
+		}
+	}
+}
+
+// GitChangeMarginWidth returns the width of the margin for git change markers.
+// This is zero if the file isn't tracked in a git repository.
+func (s *BufferState) GitChangeMarginWidth() uint64 {
+	if s.gitChanges == nil || s.LineNumMarginWidth()+1 >= s.view.width {
+		return 0
+	}
+	return 1
+}
+
+// GitChangeForLine returns the change to a line since the git HEAD commit.
+func (s *BufferState) GitChangeForLine(lineNum uint64) GitChange {
+	if s.gitChanges == nil {
+		return GitChangeNone
+	}
+
+	s.gitChanges.update(s.textTree, false)
+	ranges := s.gitChanges.ranges
+	idx := sort.Search(len(ranges), func(i int) bool {
+		return ranges[i].endLineNum > lineNum
+	})
+	if idx < len(ranges) && ranges[idx].startLineNum <= lineNum {
+		return ranges[idx].change
+	}
+	return GitChangeNone
+}
+
+// nextGitChangeLineNum returns the first line of the next changed hunk after the cursor.
+func nextGitChangeLineNum(state *EditorState) (uint64, bool) {
+	buffer := state.documentBuffer
+	buffer.gitChanges.update(buffer.textTree, true)
+	cursorLineNum := buffer.textTree.LineNumForPosition(buffer.cursor.position)
+	for _, r := range buffer.gitChanges.ranges {
+		if r.startLineNum > cursorLineNum {
+			return r.startLineNum, true
+		}
+	}
+	return 0, false
+}
+
+// prevGitChangeLineNum returns the first line of the previous changed hunk before the cursor.
+// If the cursor is within a hunk, this is the first line of that hunk.
+func prevGitChangeLineNum(state *EditorState) (uint64, bool) {
+	buffer := state.documentBuffer
+	buffer.gitChanges.update(buffer.textTree, true)
+	cursorLineNum := buffer.textTree.LineNumForPosition(buffer.cursor.position)
+	ranges := buffer.gitChanges.ranges
+	for i := len(ranges) - 1; i >= 0; i-- {
+		if ranges[i].startLineNum < cursorLineNum {
+			return ranges[i].startLineNum, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,189 @@
+package state
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/clipboard"
+)
+
+func createTestFileInGitRepo(t *testing.T, contents string) string {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.txt")
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0644))
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "."},
+		{"commit", "-q", "-m", "initial commit"},
+	} {
+		args = append([]string{"-C", dir, "-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+		out, err := exec.Command("git", args...).CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	return path
+}
+
+// applyBackgroundResult waits for a goroutine loading data for the document, then executes its result action.
+func applyBackgroundResult(t *testing.T, state *EditorState) {
+	select {
+	case action := <-state.BackgroundResultChan():
+		action(state)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "Timed out")
+	}
+}
+
+func withGitChangesDebounceInterval(t *testing.T, interval time.Duration) {
+	oldInterval := gitChangesDebounceInterval
+	gitChangesDebounceInterval = interval
+	t.Cleanup(func() { gitChangesDebounceInterval = oldInterval })
+}
+
+func gitChangesForAllLines(buffer *BufferState) []GitChange {
+	var changes []GitChange
+	for n := uint64(0); n < buffer.textTree.NumLines(); n++ {
+		changes = append(changes, buffer.GitChangeForLine(n))
+	}
+	return changes
+}
+
+func TestGitChangesUntrackedFile(t *testing.T) {
+	path, cleanup := createTestFile(t, "abc\ndef\n")
+	defer cleanup()
+
+	state := NewEditorState(100, 100, nil, nil)
+	defer Quit(state)
+	LoadDocument(state, path, true, startOfDocLocator)
+	applyBackgroundResult(t, state)
+	assert.Equal(t, uint64(0), state.documentBuffer.GitChangeMarginWidth())
+	assert.Equal(t, GitChangeNone, state.documentBuffer.GitChangeForLine(0))
+}
+
+func TestGitChangesAfterEdits(t *testing.T) {
+	withGitChangesDebounceInterval(t, 0)
+	path := createTestFileInGitRepo(t, "abc\ndef\nghi\njkl\n")
+
+	state := NewEditorState(100, 100, nil, nil)
+	defer Quit(state)
+	LoadDocument(state, path, true, startOfDocLocator)
+	buffer := state.documentBuffer
+
+	// The git HEAD version loads in the background.
+	assert.Equal(t, uint64(0), buffer.GitChangeMarginWidth())
+	applyBackgroundResult(t, state)
+	assert.Equal(t, uint64(1), buffer.GitChangeMarginWidth())
+	assert.Equal(t, []GitChange{GitChangeNone, GitChangeNone, GitChangeNone, GitChangeNone}, gitChangesForAllLines(buffer))
+
+	// Modify the first line.
+	InsertRune(state, 'x')
+	assert.Equal(t, []GitChange{GitChangeModified, GitChangeNone, GitChangeNone, GitChangeNone}, gitChangesForAllLines(buffer))
+
+	// Add a line at the end of the document.
+	MoveCursor(state, func(p LocatorParams) uint64 { return p.TextTree.NumChars() })
+	InsertNewline(state)
+	InsertRune(state, 'y')
+	assert.Equal(t, []GitChange{GitChangeModified, GitChangeNone, GitChangeNone, GitChangeNone, GitChangeAdded}, gitChangesForAllLines(buffer))
+
+	// Remove the third line, which marks the line before it.
+	MoveCursor(state, func(LocatorParams) uint64 { return 9 })
+	DeleteRunes(state, func(LocatorParams) uint64 { return 13 }, clipboard.PageDefault)
+	assert.Equal(t, "xabc\ndef\njkl\ny", buffer.textTree.String())
+	assert.Equal(t, []GitChange{GitChangeModified, GitChangeRemoved, GitChangeNone, GitChangeAdded}, gitChangesForAllLines(buffer))
+
+}
+
+func TestGitChangesDebounce(t *testing.T) {
+	withGitChangesDebounceInterval(t, time.Hour)
+	path := createTestFileInGitRepo(t, "abc\ndef\n")
+
+	state := NewEditorState(100, 100, nil, nil)
+	defer Quit(state)
+	LoadDocument(state, path, true, startOfDocLocator)
+	applyBackgroundResult(t, state)
+	buffer := state.documentBuffer
+	assert.Equal(t, []GitChange{GitChangeNone, GitChangeNone}, gitChangesForAllLines(buffer))
+
+	// The changes aren't recalculated until the interval elapses after the last edit.
+	InsertRune(state, 'x')
+	assert.Equal(t, []GitChange{GitChangeNone, GitChangeNone}, gitChangesForAllLines(buffer))
+
+	// Navigating to a hunk always recalculates the changes.
+	MoveCursor(state, func(p LocatorParams) uint64 { return p.TextTree.NumChars() })
+	PrevDiffHunk(state)
+	assert.Equal(t, uint64(0), buffer.cursor.position)
+	assert.Equal(t, []GitChange{GitChangeModified, GitChangeNone}, gitChangesForAllLines(buffer))
+}
+
+func TestGitChangesRedrawAfterDebounce(t *testing.T) {
+	withGitChangesDebounceInterval(t, 10*time.Millisecond)
+	path := createTestFileInGitRepo(t, "abc\ndef\n")
+
+	state := NewEditorState(100, 100, nil, nil)
+	defer Quit(state)
+	LoadDocument(state, path, true, startOfDocLocator)
+	applyBackgroundResult(t, state)
+	buffer := state.documentBuffer
+
+	// After the interval elapses, the editor receives an action so it redraws with the recalculated changes.
+	InsertRune(state, 'x')
+	applyBackgroundResult(t, state)
+	assert.Equal(t, []GitChange{GitChangeModified, GitChangeNone}, gitChangesForAllLines(buffer))
+}
+
+func TestGitChangesReloadWhileLoading(t *testing.T) {
+	path := createTestFileInGitRepo(t, "abc\ndef\n")
+
+	state := NewEditorState(100, 100, nil, nil)
+	defer Quit(state)
+	LoadDocument(state, path, true, startOfDocLocator)
+	ReloadDocument(state)
+
+	// The result from the first load is discarded, and the result from the reload is applied.
+	applyBackgroundResult(t, state)
+	applyBackgroundResult(t, state)
+	assert.Equal(t, uint64(1), state.documentBuffer.GitChangeMarginWidth())
+}
+
+func TestGitChangeNavigation(t *testing.T) {
+	path := createTestFileInGitRepo(t, "a\nb\nc\nd\ne\nf\n")
+
+	state := NewEditorState(100, 100, nil, nil)
+	defer Quit(state)
+	LoadDocument(state, path, true, startOfDocLocator)
+	applyBackgroundResult(t, state)
+
+	cursorLineNum := func() uint64 {
+		return state.documentBuffer.textTree.LineNumForPosition(state.documentBuffer.cursor.position)
+	}
+
+	// Change lines 1-2 and 4.
+	for _, pos := range []uint64{2, 5, 10} {
+		MoveCursor(state, func(LocatorParams) uint64 { return pos })
+		InsertRune(state, 'x')
+	}
+	MoveCursor(state, startOfDocLocator)
+
+	NextDiffHunk(state)
+	assert.Equal(t, uint64(1), cursorLineNum())
+	NextDiffHunk(state)
+	assert.Equal(t, uint64(4), cursorLineNum())
+	NextDiffHunk(state)
+	assert.Equal(t, uint64(4), cursorLineNum())
+	assert.Equal(t, StatusMsg{Style: StatusMsgStyleError, Text: "No next hunk"}, state.StatusMsg())
+
+	PrevDiffHunk(state)
+	assert.Equal(t, uint64(1), cursorLineNum())
+	PrevDiffHunk(state)
+	assert.Equal(t, uint64(1), cursorLineNum())
+	assert.Equal(t, StatusMsg{Style: StatusMsgStyleError, Text: "No previous hunk"}, state.StatusMsg())
+}
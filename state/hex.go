@@ -0,0 +1,261 @@
+package state
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/aretext/aretext/file"
+	"github.com/aretext/aretext/locate"
+	"github.com/aretext/aretext/text"
+)
+
+// ToggleHexMode reloads the document as a hex dump, or as text if it is already a hex dump.
+func ToggleHexMode(state *EditorState) {
+	path := state.documentBuffer.fileWatcher.Path()
+	hex := !state.documentBuffer.fileFormat.Hex
+	_, err := loadDocumentAndResetState(state, path, false, hex)
+	if err != nil {
+		reportLoadError(state, err, path)
+		return
+	}
+
+	setCursorAfterLoad(state, func(LocatorParams) uint64 { return 0 })
+
+	if hex != state.documentBuffer.fileFormat.Hex {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  fmt.Sprintf("Could not open %s as text, so opened in hex mode", file.RelativePathCwd(path)),
+		})
+		return
+	}
+
+	reportOpenSuccess(state, path)
+}
+
+// overwriteHexDigit replaces the hex digit at or after the cursor, then moves the cursor to the next hex digit.
+// Other characters are ignored, since inserting them would make the hex dump invalid.
+func overwriteHexDigit(state *EditorState, r rune) {
+	buffer := state.documentBuffer
+	if !isHexDigit(r) {
+		return
+	}
+
+	pos, ok := nextHexDigitPos(buffer.textTree, buffer.cursor.position, true)
+	if !ok {
+		return
+	}
+
+	replaceHexDigit(state, pos, r)
+
+	if nextPos, ok := nextHexDigitPos(buffer.textTree, pos, false); ok {
+		buffer.cursor = cursorState{position: nextPos}
+	} else {
+		buffer.cursor = cursorState{position: pos + 1}
+	}
+}
+
+// replaceHexDigitAtCursor replaces the hex digit under the cursor without moving the cursor.
+func replaceHexDigitAtCursor(state *EditorState, r rune) {
+	buffer := state.documentBuffer
+	pos := buffer.cursor.position
+	if !isHexDigit(r) {
+		return
+	}
+
+	if nextPos, ok := nextHexDigitPos(buffer.textTree, pos, true); !ok || nextPos != pos {
+		return
+	}
+
+	replaceHexDigit(state, pos, r)
+	buffer.cursor = cursorState{position: pos}
+}
+
+// BackspaceInHexMode moves the cursor to the previous hex digit if the document is a hex dump.
+// Hex mode edits bytes by overwriting them, so backspace moves the cursor instead of deleting.
+// It returns true if the document is a hex dump.
+func BackspaceInHexMode(state *EditorState) bool {
+	buffer := state.documentBuffer
+	if !buffer.fileFormat.Hex {
+		return false
+	}
+
+	if pos, ok := prevHexDigitPos(buffer.textTree, buffer.cursor.position); ok {
+		buffer.cursor = cursorState{position: pos}
+	}
+	return true
+}
+
+func replaceHexDigit(state *EditorState, pos uint64, r rune) {
+	deleteRunes(state, pos, 1, true)
+	mustInsertRuneAtPosition(state, unicode.ToLower(r), pos, true)
+	updateHexASCIIColumn(state, pos)
+}
+
+// updateHexASCIIColumn updates the ASCII character for the byte with a hex digit at the given position.
+// This does nothing if the line doesn't match the layout of the hex dump (for example, if the user deleted bytes).
+func updateHexASCIIColumn(state *EditorState, pos uint64) {
+	tree := state.documentBuffer.textTree
+	lineStartPos, line := hexDumpLine(tree, tree.LineNumForPosition(pos))
+	data, cols, err := file.ParseHexDumpLine(line)
+	if err != nil {
+		return
+	}
+
+	runes := []rune(line)
+	asciiEnd := -1
+	for i, r := range runes {
+		if r == '|' {
+			asciiEnd = i
+		}
+	}
+	for i, col := range cols {
+		if pos != lineStartPos+col && pos != lineStartPos+col+1 {
+			continue
+		}
+
+		asciiCol := file.HexASCIICol(i)
+		if i >= file.HexBytesPerLine || col != file.HexByteCol(i) || asciiEnd < 0 || asciiCol >= uint64(asciiEnd) {
+			return
+		}
+
+		if r := file.HexASCIIRune(data[i]); runes[asciiCol] != r {
+			deleteRunes(state, lineStartPos+asciiCol, 1, true)
+			mustInsertRuneAtPosition(state, r, lineStartPos+asciiCol, true)
+		}
+		return
+	}
+}
+
+// nextHexDigitPos finds the position of the next hex digit of a byte in the hex dump.
+// If includeStartPos is true, this may return the start position.
+func nextHexDigitPos(tree *text.Tree, startPos uint64, includeStartPos bool) (uint64, bool) {
+	lineNum := tree.LineNumForPosition(startPos)
+	for ; lineNum < tree.NumLines(); lineNum++ {
+		for _, pos := range hexDigitPositionsInLine(tree, lineNum) {
+			if pos > startPos || (includeStartPos && pos == startPos) {
+				return pos, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// prevHexDigitPos finds the position of the previous hex digit of a byte in the hex dump.
+func prevHexDigitPos(tree *text.Tree, startPos uint64) (uint64, bool) {
+	lineNum := tree.LineNumForPosition(startPos)
+	for {
+		positions := hexDigitPositionsInLine(tree, lineNum)
+		for i := len(positions) - 1; i >= 0; i-- {
+			if positions[i] < startPos {
+				return positions[i], true
+			}
+		}
+
+		if lineNum == 0 {
+			return 0, false
+		}
+		lineNum--
+	}
+}
+
+func hexDigitPositionsInLine(tree *text.Tree, lineNum uint64) []uint64 {
+	lineStartPos, line := hexDumpLine(tree, lineNum)
+	_, cols, err := file.ParseHexDumpLine(line)
+	if err != nil {
+		return nil
+	}
+
+	positions := make([]uint64, 0, len(cols)*2)
+	for _, col := range cols {
+		positions = append(positions, lineStartPos+col, lineStartPos+col+1)
+	}
+	return positions
+}
+
+// hexDumpLine returns the start position and text of a line in the hex dump.
+// If file.ParseHexDumpLine succeeds, every character before the ASCII column is ASCII,
+// so the byte offsets it returns are also offsets in runes from the start of the line.
+func hexDumpLine(tree *text.Tree, lineNum uint64) (uint64, string) {
+	lineStartPos := locate.StartOfLineNum(tree, lineNum)
+	lineEndPos := locate.NextLineBoundary(tree, true, lineStartPos)
+	return lineStartPos, copyText(tree, lineStartPos, lineEndPos-lineStartPos)
+}
+
+// hexDumpBytes returns the bytes in the hex dump and the position of the first hex digit for each byte.
+// Lines that aren't valid in the hex dump are skipped.
+func hexDumpBytes(tree *text.Tree) ([]byte, []uint64) {
+	var data []byte
+	var positions []uint64
+	for lineNum := uint64(0); lineNum < tree.NumLines(); lineNum++ {
+		lineStartPos, line := hexDumpLine(tree, lineNum)
+		lineData, cols, err := file.ParseHexDumpLine(line)
+		if err != nil {
+			continue
+		}
+		data = append(data, lineData...)
+		for _, col := range cols {
+			positions = append(positions, lineStartPos+col)
+		}
+	}
+	return data, positions
+}
+
+// parseHexQuery interprets a search query as a sequence of bytes, like "4865" or "48 65".
+// It returns false if the query isn't an even number of hex digits.
+func parseHexQuery(q string) ([]byte, bool) {
+	s := strings.ReplaceAll(q, " ", "")
+	if len(s) == 0 || len(s)%2 != 0 {
+		return nil, false
+	}
+
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// searchHex finds a sequence of bytes in a hex dump.
+// Searching forward finds the first match with a byte at or after the start position.
+// Searching backward finds the last match that starts before the start position.
+// Both directions wrap around to the other end of the document.
+func searchHex(tree *text.Tree, startPos uint64, direction SearchDirection, query []byte) *SearchMatch {
+	data, positions := hexDumpBytes(tree)
+	startIdx := sort.Search(len(positions), func(i int) bool {
+		return positions[i] >= startPos
+	})
+
+	idx := -1
+	if direction == SearchDirectionForward {
+		if i := bytes.Index(data[startIdx:], query); i >= 0 {
+			idx = startIdx + i
+		} else {
+			idx = bytes.Index(data, query)
+		}
+	} else {
+		limit := startIdx + len(query) - 1
+		if limit > len(data) {
+			limit = len(data)
+		}
+		if idx = bytes.LastIndex(data[:limit], query); idx < 0 {
+			idx = bytes.LastIndex(data, query)
+		}
+	}
+
+	if idx < 0 {
+		return nil
+	}
+
+	return &SearchMatch{
+		StartPos: positions[idx],
+		EndPos:   positions[idx+len(query)-1] + 2,
+	}
+}
+
+func isHexDigit(r rune) bool {
+	return (r >= '0' && r <= '9') || (r >= 'a' && r <= 'f') || (r >= 'A' && r <= 'F')
+}
@@ -0,0 +1,316 @@
+package state
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/file"
+)
+
+// hexTestContents is 20 bytes, so the hex dump has one full line and one partial line.
+const hexTestContents = "Hello, world!\n\x00\xffabcd"
+
+const hexTestDump = "00000000  48 65 6c 6c 6f 2c 20 77  6f 72 6c 64 21 0a 00 ff  |Hello, world!...|\n" +
+	"00000010  61 62 63 64                                       |abcd|"
+
+func loadHexTestDocument(t *testing.T, contents string, hex bool) (*EditorState, string, func()) {
+	path, cleanup := createTestFile(t, contents)
+	state := NewEditorState(100, 100, nil, nil)
+	if hex {
+		LoadDocumentAsHex(state, path, true, startOfDocLocator)
+	} else {
+		LoadDocument(state, path, true, startOfDocLocator)
+	}
+	return state, path, func() {
+		state.documentBuffer.fileWatcher.Stop()
+		cleanup()
+	}
+}
+
+func TestLoadDocumentInvalidUtf8AsHex(t *testing.T) {
+	state, _, cleanup := loadHexTestDocument(t, hexTestContents, false)
+	defer cleanup()
+
+	assert.True(t, state.documentBuffer.FileFormat().Hex)
+	assert.Equal(t, hexTestDump, state.documentBuffer.textTree.String())
+	assert.Equal(t, StatusMsgStyleSuccess, state.statusMsg.Style)
+	assert.Contains(t, state.statusMsg.Text, "in hex mode")
+}
+
+func TestToggleHexMode(t *testing.T) {
+	state, _, cleanup := loadHexTestDocument(t, "abc\n", false)
+	defer cleanup()
+	assert.False(t, state.documentBuffer.FileFormat().Hex)
+	assert.Equal(t, "abc", state.documentBuffer.textTree.String())
+
+	ToggleHexMode(state)
+	assert.True(t, state.documentBuffer.FileFormat().Hex)
+	assert.Equal(t, "00000000  61 62 63 0a                                       |abc.|", state.documentBuffer.textTree.String())
+
+	ToggleHexMode(state)
+	assert.False(t, state.documentBuffer.FileFormat().Hex)
+	assert.Equal(t, "abc", state.documentBuffer.textTree.String())
+}
+
+func TestToggleHexModeInvalidUtf8(t *testing.T) {
+	state, _, cleanup := loadHexTestDocument(t, hexTestContents, false)
+	defer cleanup()
+	assert.True(t, state.documentBuffer.FileFormat().Hex)
+
+	ToggleHexMode(state)
+	assert.True(t, state.documentBuffer.FileFormat().Hex)
+	assert.Equal(t, StatusMsgStyleError, state.statusMsg.Style)
+	assert.Contains(t, state.statusMsg.Text, "Could not open")
+}
+
+func TestInsertRuneInHexMode(t *testing.T) {
+	testCases := []struct {
+		name           string
+		cursorPos      uint64
+		insertRunes    string
+		expectedLine   string
+		expectedCursor uint64
+	}{
+		{
+			name:           "overwrite first digit",
+			cursorPos:      10,
+			insertRunes:    "5",
+			expectedLine:   "00000000  58 65 6c 6c 6f 2c 20 77  6f 72 6c 64 21 0a 00 ff  |Xello, world!...|",
+			expectedCursor: 11,
+		},
+		{
+			name:           "overwrite byte and move to next byte",
+			cursorPos:      10,
+			insertRunes:    "4A",
+			expectedLine:   "00000000  4a 65 6c 6c 6f 2c 20 77  6f 72 6c 64 21 0a 00 ff  |Jello, world!...|",
+			expectedCursor: 13,
+		},
+		{
+			name:           "skip offset",
+			cursorPos:      0,
+			insertRunes:    "00",
+			expectedLine:   "00000000  00 65 6c 6c 6f 2c 20 77  6f 72 6c 64 21 0a 00 ff  |.ello, world!...|",
+			expectedCursor: 13,
+		},
+		{
+			name:           "skip group separator",
+			cursorPos:      33,
+			insertRunes:    "21",
+			expectedLine:   "00000000  48 65 6c 6c 6f 2c 20 77  21 72 6c 64 21 0a 00 ff  |Hello, w!rld!...|",
+			expectedCursor: 38,
+		},
+		{
+			name:           "ignore non-hex characters",
+			cursorPos:      10,
+			insertRunes:    "xyz \t\n",
+			expectedLine:   "00000000  48 65 6c 6c 6f 2c 20 77  6f 72 6c 64 21 0a 00 ff  |Hello, world!...|",
+			expectedCursor: 10,
+		},
+		{
+			name:           "move to next line",
+			cursorPos:      56,
+			insertRunes:    "7e",
+			expectedLine:   "00000000  48 65 6c 6c 6f 2c 20 77  6f 72 6c 64 21 0a 00 7e  |Hello, world!..~|",
+			expectedCursor: 89,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			state, _, cleanup := loadHexTestDocument(t, hexTestContents, true)
+			defer cleanup()
+
+			state.documentBuffer.cursor = cursorState{position: tc.cursorPos}
+			for _, r := range tc.insertRunes {
+				InsertRune(state, r)
+			}
+			InsertNewline(state)
+			InsertTab(state)
+
+			tree := state.documentBuffer.textTree
+			_, line := hexDumpLine(tree, 0)
+			assert.Equal(t, tc.expectedLine, line)
+			assert.Equal(t, tc.expectedCursor, state.documentBuffer.cursor.position)
+			assert.Equal(t, uint64(2), tree.NumLines())
+		})
+	}
+}
+
+func TestReplaceCharInHexMode(t *testing.T) {
+	state, _, cleanup := loadHexTestDocument(t, hexTestContents, true)
+	defer cleanup()
+
+	// Not on a hex digit, so nothing changes.
+	state.documentBuffer.cursor = cursorState{position: 9}
+	ReplaceChar(state, 'f')
+	assert.Equal(t, hexTestDump, state.documentBuffer.textTree.String())
+
+	// Replace the second digit of the first byte.
+	state.documentBuffer.cursor = cursorState{position: 11}
+	ReplaceChar(state, 'F')
+	_, line := hexDumpLine(state.documentBuffer.textTree, 0)
+	assert.Equal(t, "00000000  4f 65 6c 6c 6f 2c 20 77  6f 72 6c 64 21 0a 00 ff  |Oello, world!...|", line)
+	assert.Equal(t, uint64(11), state.documentBuffer.cursor.position)
+}
+
+func TestBackspaceInHexMode(t *testing.T) {
+	state, _, cleanup := loadHexTestDocument(t, hexTestContents, true)
+	defer cleanup()
+
+	state.documentBuffer.cursor = cursorState{position: 89}
+	assert.True(t, BackspaceInHexMode(state))
+	assert.Equal(t, uint64(57), state.documentBuffer.cursor.position)
+	assert.True(t, BackspaceInHexMode(state))
+	assert.Equal(t, uint64(56), state.documentBuffer.cursor.position)
+	assert.Equal(t, hexTestDump, state.documentBuffer.textTree.String())
+}
+
+func TestBackspaceNotInHexMode(t *testing.T) {
+	state := NewEditorState(100, 100, nil, nil)
+	assert.False(t, BackspaceInHexMode(state))
+}
+
+func TestSaveAndUndoInHexMode(t *testing.T) {
+	state, path, cleanup := loadHexTestDocument(t, hexTestContents, true)
+	defer cleanup()
+
+	// Overwrite the last byte on the first line and the first byte on the second line.
+	CheckpointUndoLog(state)
+	state.documentBuffer.cursor = cursorState{position: 56}
+	for _, r := range "0d0a" {
+		InsertRune(state, r)
+	}
+	CheckpointUndoLog(state)
+
+	SaveDocument(state)
+	contents, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Hello, world!\n\x00\r\nbcd", string(contents))
+
+	Undo(state)
+	assert.Equal(t, hexTestDump, state.documentBuffer.textTree.String())
+
+	SaveDocument(state)
+	contents, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, hexTestContents, string(contents))
+}
+
+func TestSearchInHexMode(t *testing.T) {
+	testCases := []struct {
+		name          string
+		cursorPos     uint64
+		direction     SearchDirection
+		query         string
+		expectedMatch *SearchMatch
+	}{
+		{
+			name:          "single byte",
+			query:         "6c",
+			expectedMatch: &SearchMatch{StartPos: 16, EndPos: 18},
+		},
+		{
+			name:          "byte sequence with spaces",
+			query:         "6c 6C 6f",
+			expectedMatch: &SearchMatch{StartPos: 16, EndPos: 24},
+		},
+		{
+			name:          "byte sequence across lines",
+			query:         "00ff6162",
+			expectedMatch: &SearchMatch{StartPos: 53, EndPos: 94},
+		},
+		{
+			name:          "forward from cursor",
+			cursorPos:     17,
+			query:         "6c",
+			expectedMatch: &SearchMatch{StartPos: 19, EndPos: 21},
+		},
+		{
+			name:          "forward wraparound",
+			cursorPos:     60,
+			query:         "48",
+			expectedMatch: &SearchMatch{StartPos: 10, EndPos: 12},
+		},
+		{
+			name:          "backward from cursor",
+			cursorPos:     19,
+			direction:     SearchDirectionBackward,
+			query:         "6c",
+			expectedMatch: &SearchMatch{StartPos: 16, EndPos: 18},
+		},
+		{
+			name:          "backward wraparound",
+			cursorPos:     10,
+			direction:     SearchDirectionBackward,
+			query:         "6c",
+			expectedMatch: &SearchMatch{StartPos: 41, EndPos: 43},
+		},
+		{
+			name:      "no match",
+			query:     "6c6c6c",
+			cursorPos: 0,
+		},
+		{
+			name:          "text search for non-hex query",
+			query:         "world",
+			expectedMatch: &SearchMatch{StartPos: 68, EndPos: 73},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			state, _, cleanup := loadHexTestDocument(t, hexTestContents, true)
+			defer cleanup()
+
+			state.documentBuffer.cursor = cursorState{position: tc.cursorPos}
+			StartSearch(state, tc.direction)
+			for _, r := range tc.query {
+				AppendRuneToSearchQuery(state, r)
+			}
+			assert.Equal(t, tc.expectedMatch, state.documentBuffer.search.match)
+
+			CompleteSearch(state, true)
+			if tc.expectedMatch != nil {
+				assert.Equal(t, tc.expectedMatch.StartPos, state.documentBuffer.cursor.position)
+			}
+		})
+	}
+}
+
+func TestFindNextMatchInHexMode(t *testing.T) {
+	state, _, cleanup := loadHexTestDocument(t, hexTestContents, true)
+	defer cleanup()
+
+	StartSearch(state, SearchDirectionForward)
+	for _, r := range "6c" {
+		AppendRuneToSearchQuery(state, r)
+	}
+	CompleteSearch(state, true)
+	assert.Equal(t, uint64(16), state.documentBuffer.cursor.position)
+
+	FindNextMatch(state, false)
+	assert.Equal(t, uint64(19), state.documentBuffer.cursor.position)
+
+	FindNextMatch(state, false)
+	assert.Equal(t, uint64(41), state.documentBuffer.cursor.position)
+
+	FindNextMatch(state, false)
+	assert.Equal(t, uint64(16), state.documentBuffer.cursor.position)
+
+	FindNextMatch(state, true)
+	assert.Equal(t, uint64(41), state.documentBuffer.cursor.position)
+}
+
+func TestConvertFileFormatInHexMode(t *testing.T) {
+	state, _, cleanup := loadHexTestDocument(t, hexTestContents, true)
+	defer cleanup()
+
+	ConvertLineEndings(state, file.LineEndingCRLF)
+	assert.Equal(t, StatusMsgStyleError, state.statusMsg.Style)
+	ConvertEncoding(state, file.EncodingLatin1, false)
+	assert.Equal(t, StatusMsgStyleError, state.statusMsg.Style)
+	assert.Equal(t, file.HexFormat, state.documentBuffer.FileFormat())
+}
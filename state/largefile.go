@@ -0,0 +1,226 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/aretext/aretext/config"
+	"github.com/aretext/aretext/file"
+	"github.com/aretext/aretext/locate"
+	"github.com/aretext/aretext/text"
+)
+
+// largeFilePageSize is the maximum number of bytes loaded at once in large file mode.
+// This is a variable so tests can use smaller pages.
+var largeFilePageSize = int64(file.DefaultPageSize)
+
+// isLargeFile returns whether a file should be opened in large file mode.
+func isLargeFile(path string, cfg config.Config) bool {
+	if cfg.LargeFileThreshold <= 0 {
+		return false
+	}
+
+	fileInfo, err := os.Stat(path)
+	if err != nil {
+		// Let the caller report the error when loading the file.
+		return false
+	}
+
+	if fileInfo.Size() <= int64(cfg.LargeFileThreshold)<<20 {
+		return false
+	}
+
+	// Compressed files must be decompressed from the start, so they can't be loaded in pages.
+	compression, err := file.DetectCompression(path)
+	return err == nil && compression == file.CompressionNone
+}
+
+// loadLargeFile opens a large file and loads its first page.
+// The rest of the file is loaded incrementally as the user navigates the document.
+// Calculating the checksum would require reading the entire file, so the watcher does not detect changes.
+func loadLargeFile(path string) (*text.Tree, *file.Watcher, file.Format, *file.Pager, error) {
+	pager, err := file.OpenPager(path, largeFilePageSize)
+	if err != nil {
+		return nil, nil, file.Format{}, nil, errors.Wrap(err, "file.OpenPager")
+	}
+
+	page, err := pager.NextPage()
+	if err != nil && err != io.EOF {
+		pager.Close()
+		return nil, nil, file.Format{}, nil, errors.Wrap(err, "pager.NextPage")
+	}
+
+	tree, err := text.NewTreeFromString(page)
+	if err != nil {
+		pager.Close()
+		return nil, nil, file.Format{}, nil, errors.Wrap(err, "text.NewTreeFromString")
+	}
+
+	log.Printf("Opened '%s' in large file mode, loaded %d of %d bytes\n", path, pager.Offset(), pager.Size())
+	watcher := file.NewWatcherWithoutChecksum(pager.Path())
+	format := file.DefaultFormat
+	format.LineEnding = pager.LineEnding()
+	format.MixedLineEndings = pager.MixedLineEndings()
+	return tree, watcher, format, pager, nil
+}
+
+// LoadLargeFilePagesNearView loads pages of a large file until the document extends
+// at least one screen past the view and the cursor.  This keeps navigation responsive,
+// since only the part of the file the user has scrolled to is loaded into memory.
+func LoadLargeFilePagesNearView(state *EditorState) {
+	buffer := state.documentBuffer
+	if buffer.pager == nil || state.task != nil {
+		// Don't change the text tree while a task might be reading it.
+		return
+	}
+
+	for buffer.pager != nil && needsMoreLargeFilePages(buffer) {
+		if err := loadNextLargeFilePage(state); err != nil {
+			log.Printf("Error loading page of large file: %v\n", err)
+			SetStatusMsg(state, StatusMsg{
+				Style: StatusMsgStyleError,
+				Text:  fmt.Sprintf("Could not load file: %s", errors.Cause(err)),
+			})
+			return
+		}
+	}
+}
+
+func needsMoreLargeFilePages(buffer *BufferState) bool {
+	tree := buffer.textTree
+	lastLineNum := tree.LineNumForPosition(buffer.cursor.position)
+	if viewLastLineNum := tree.LineNumForPosition(buffer.view.textOrigin) + buffer.view.height; viewLastLineNum > lastLineNum {
+		lastLineNum = viewLastLineNum
+	}
+	return tree.NumLines() <= lastLineNum+buffer.view.height
+}
+
+func loadNextLargeFilePage(state *EditorState) error {
+	buffer := state.documentBuffer
+	page, err := buffer.pager.NextPage()
+	if err != nil && err != io.EOF {
+		return errors.Wrap(err, "pager.NextPage")
+	}
+
+	// Appending to the end of the document doesn't change any positions before the page,
+	// so the cursor, view, selection, and undo log remain valid.
+	if err := buffer.textTree.AppendString(page); err != nil {
+		return errors.Wrap(err, "textTree.AppendString")
+	}
+
+	buffer.fileFormat.LineEnding = buffer.pager.LineEnding()
+	buffer.fileFormat.MixedLineEndings = buffer.pager.MixedLineEndings()
+	log.Printf("Loaded %d of %d bytes from large file\n", buffer.pager.Offset(), buffer.pager.Size())
+
+	if buffer.pager.Done() {
+		finishLoadingLargeFile(state)
+	}
+
+	return nil
+}
+
+// LoadEntireLargeFile loads every remaining page of a large file.
+// This runs as a task, so the user can cancel it if it takes too long.
+func LoadEntireLargeFile(state *EditorState) {
+	buffer := state.documentBuffer
+	pager := buffer.pager
+	if pager == nil {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleSuccess,
+			Text:  "Entire file is already loaded",
+		})
+		return
+	}
+
+	StartTask(state, func(ctx context.Context) func(*EditorState) {
+		// Load the rest of the file into a separate tree, so the task doesn't read the document.
+		remainingTree, err := text.NewTreeFromReader(pager.RemainingReader(ctx))
+		if err != nil {
+			return func(state *EditorState) {
+				reportLoadError(state, err, pager.Path())
+			}
+		}
+
+		return func(state *EditorState) {
+			if state.documentBuffer.pager != pager {
+				// A different document was loaded while the task was running.
+				return
+			}
+			state.documentBuffer.textTree.AppendTree(remainingTree)
+			pager.SkipToEnd()
+			finishLoadingLargeFile(state)
+			SetStatusMsg(state, StatusMsg{
+				Style: StatusMsgStyleSuccess,
+				Text:  fmt.Sprintf("Loaded entire file %s", file.RelativePathCwd(pager.Path())),
+			})
+		}
+	})
+}
+
+// finishLoadingLargeFile updates the document format once every page has been loaded.
+// The document remains read-only, since the pager doesn't preserve the exact bytes of the file.
+func finishLoadingLargeFile(state *EditorState) {
+	buffer := state.documentBuffer
+	pager := buffer.pager
+	if err := pager.Close(); err != nil {
+		log.Printf("Error closing large file: %v\n", err)
+	}
+	buffer.pager = nil
+
+	// Remove the POSIX end-of-file indicator, the same as when loading a file all at once.
+	format := buffer.fileFormat
+	format.LineEnding = pager.LineEnding()
+	format.MixedLineEndings = pager.MixedLineEndings()
+	format.EndsWithNewline = false
+	tree := buffer.textTree
+	if n := tree.NumChars(); n > 0 {
+		if r, ok := runeAtPosition(buffer, n-1); ok && r == '\n' {
+			tree.DeleteAtPosition(n - 1)
+			format.EndsWithNewline = true
+		}
+	}
+
+	if n := tree.NumChars(); buffer.cursor.position >= n {
+		buffer.cursor = cursorState{position: locate.ClosestCharOnLine(tree, n)}
+	}
+	if n := tree.NumChars(); buffer.view.textOrigin > n {
+		buffer.view.textOrigin = tree.LineStartPosition(tree.LineNumForPosition(n))
+	}
+	ScrollViewToCursor(state)
+
+	cfg := state.configRuleSet.ConfigForPath(pager.Path())
+	buffer.fileFormat = fileFormatForConfig(format, cfg)
+	log.Printf("Finished loading large file '%s'\n", pager.Path())
+}
+
+// ToggleReadOnly enables or disables edits to the document.
+// A file opened in large file mode cannot be made editable, since the pager replaces
+// invalid UTF-8 and ignores the file's encoding, so saving the document could corrupt the file.
+func ToggleReadOnly(state *EditorState) {
+	if state.documentBuffer.largeFile {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  "Cannot edit a file opened in large file mode",
+		})
+		return
+	}
+	toggleFlagAndSetStatus(state, &state.documentBuffer.readOnly, "Enabled read-only", "Disabled read-only")
+}
+
+// abortIfReadOnly shows an error status msg and returns true if the document is read-only.
+func abortIfReadOnly(state *EditorState) bool {
+	if !state.documentBuffer.readOnly {
+		return false
+	}
+	log.Printf("Aborting edit because document is read-only\n")
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleError,
+		Text:  "Document is read-only",
+	})
+	return true
+}
@@ -0,0 +1,151 @@
+package state
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/config"
+	"github.com/aretext/aretext/locate"
+)
+
+func createLargeTestFile(t *testing.T) (path string, contents string, cleanup func()) {
+	// Slightly larger than one megabyte, the smallest configurable threshold.
+	var sb strings.Builder
+	for i := 0; i < 100000; i++ {
+		fmt.Fprintf(&sb, "line %05d\n", i)
+	}
+	contents = sb.String()
+	path, cleanup = createTestFile(t, contents)
+	return path, contents, cleanup
+}
+
+func withSmallLargeFilePages(t *testing.T) {
+	oldPageSize := largeFilePageSize
+	largeFilePageSize = 64 << 10
+	t.Cleanup(func() { largeFilePageSize = oldPageSize })
+}
+
+func largeFileConfigRuleSet(threshold int) config.RuleSet {
+	return config.RuleSet{
+		{
+			Name:    "largeFileThreshold",
+			Pattern: "**",
+			Config:  map[string]any{"largeFileThreshold": threshold},
+		},
+	}
+}
+
+func TestLoadLargeFile(t *testing.T) {
+	withSmallLargeFilePages(t)
+	path, contents, cleanup := createLargeTestFile(t)
+	defer cleanup()
+
+	state := NewEditorState(100, 100, largeFileConfigRuleSet(1), nil)
+	LoadDocument(state, path, true, startOfDocLocator)
+	defer state.documentBuffer.fileWatcher.Stop()
+
+	buffer := state.documentBuffer
+	assert.True(t, buffer.ReadOnly())
+	assert.Contains(t, state.StatusMsg().Text, "in large file mode (read-only)")
+	assert.True(t, strings.HasPrefix(contents, buffer.textTree.String()))
+	assert.Less(t, buffer.textTree.NumChars(), uint64(len(contents)))
+	assert.Equal(t, 6, buffer.LargeFileLoadedPercent())
+
+	// Edits are disabled.
+	InsertRune(state, 'x')
+	assert.True(t, strings.HasPrefix(buffer.textTree.String(), "line 00000\n"))
+	assert.Equal(t, StatusMsg{Style: StatusMsgStyleError, Text: "Document is read-only"}, state.StatusMsg())
+
+	SetInputMode(state, InputModeInsert)
+	assert.Equal(t, InputModeNormal, state.InputMode())
+
+	// Saving would truncate the file, and it can't be made editable.
+	SaveDocument(state)
+	assert.Equal(t, "Cannot save a file opened in large file mode", state.StatusMsg().Text)
+	ToggleReadOnly(state)
+	assert.Equal(t, "Cannot edit a file opened in large file mode", state.StatusMsg().Text)
+	assert.True(t, buffer.ReadOnly())
+}
+
+func TestLoadLargeFilePagesNearView(t *testing.T) {
+	withSmallLargeFilePages(t)
+	path, contents, cleanup := createLargeTestFile(t)
+	defer cleanup()
+
+	state := NewEditorState(100, 100, largeFileConfigRuleSet(1), nil)
+	LoadDocument(state, path, true, startOfDocLocator)
+	defer state.documentBuffer.fileWatcher.Stop()
+
+	// The view is near the start of the document, so no pages are loaded.
+	buffer := state.documentBuffer
+	numChars := buffer.textTree.NumChars()
+	LoadLargeFilePagesNearView(state)
+	assert.Equal(t, numChars, buffer.textTree.NumChars())
+
+	// Move the cursor to the end of the loaded text to load the next page.
+	for i := 0; buffer.pager != nil; i++ {
+		require.Less(t, i, 100, "too many pages")
+		MoveCursor(state, func(p LocatorParams) uint64 {
+			return locate.StartOfLastLine(p.TextTree)
+		})
+		LoadLargeFilePagesNearView(state)
+		assert.Greater(t, buffer.textTree.NumChars(), numChars)
+		numChars = buffer.textTree.NumChars()
+	}
+
+	assert.Equal(t, strings.TrimSuffix(contents, "\n"), buffer.textTree.String())
+	assert.True(t, buffer.fileFormat.EndsWithNewline)
+	assert.Equal(t, 0, buffer.LargeFileLoadedPercent())
+	assert.Less(t, buffer.cursor.position, buffer.textTree.NumChars())
+
+	// Even once loaded entirely, the document remains read-only.
+	assert.True(t, buffer.ReadOnly())
+	ToggleReadOnly(state)
+	assert.True(t, buffer.ReadOnly())
+	SaveDocument(state)
+	assert.Equal(t, "Cannot save a file opened in large file mode", state.StatusMsg().Text)
+}
+
+func TestLoadEntireLargeFile(t *testing.T) {
+	withSmallLargeFilePages(t)
+	path, contents, cleanup := createLargeTestFile(t)
+	defer cleanup()
+
+	state := NewEditorState(100, 100, largeFileConfigRuleSet(1), nil)
+	LoadDocument(state, path, true, startOfDocLocator)
+	defer state.documentBuffer.fileWatcher.Stop()
+
+	LoadEntireLargeFile(state)
+	select {
+	case action := <-state.TaskResultChan():
+		action(state)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "Timed out")
+	}
+
+	buffer := state.documentBuffer
+	assert.Nil(t, buffer.pager)
+	assert.Equal(t, strings.TrimSuffix(contents, "\n"), buffer.textTree.String())
+	assert.True(t, buffer.fileFormat.EndsWithNewline)
+	assert.Contains(t, state.StatusMsg().Text, "Loaded entire file")
+	assert.True(t, buffer.ReadOnly())
+}
+
+func TestLoadLargeFileThresholdDisabled(t *testing.T) {
+	path, contents, cleanup := createLargeTestFile(t)
+	defer cleanup()
+
+	state := NewEditorState(100, 100, largeFileConfigRuleSet(0), nil)
+	LoadDocument(state, path, true, startOfDocLocator)
+	defer state.documentBuffer.fileWatcher.Stop()
+
+	buffer := state.documentBuffer
+	assert.False(t, buffer.ReadOnly())
+	assert.Nil(t, buffer.pager)
+	assert.Equal(t, strings.TrimSuffix(contents, "\n"), buffer.textTree.String())
+}
@@ -17,6 +17,10 @@ const (
 	MenuStyleCommand = MenuStyle(iota)
 	MenuStyleFilePath
 	MenuStyleFileLocation
+	MenuStyleUndoHistory
+	MenuStyleRecovery
+	MenuStyleBuffer
+	MenuStyleTheme
 )
 
 // MenuState represents the menu for searching and selecting items.
@@ -60,7 +64,7 @@ func (m *MenuState) SearchResults() (results []menu.Item, selectedResultIdx int)
 
 // ShowMenu displays the menu with the specified style and items.
 func ShowMenu(state *EditorState, style MenuStyle, items []menu.Item) {
-	emptyQueryShowAll := bool(style == MenuStyleFilePath || style == MenuStyleFileLocation)
+	emptyQueryShowAll := bool(style == MenuStyleFilePath || style == MenuStyleFileLocation || style == MenuStyleUndoHistory || style == MenuStyleRecovery || style == MenuStyleBuffer || style == MenuStyleTheme)
 	if style == MenuStyleCommand {
 		items = append(items, state.customMenuItems...)
 	}
@@ -104,8 +108,9 @@ func loadFileMenuItems(ctx context.Context, dirPatternsToHide []string) []menu.I
 		items = append(items, menu.Item{
 			Name: file.RelativePath(menuPath, dir),
 			Action: func(s *EditorState) {
-				LoadDocument(s, menuPath, true, func(LocatorParams) uint64 {
-					return 0
+				// If the document is already loaded, keep the cursor where it was.
+				LoadDocument(s, menuPath, true, func(p LocatorParams) uint64 {
+					return p.CursorPos
 				})
 			},
 		})
@@ -136,8 +141,13 @@ func ExecuteSelectedMenuItem(state *EditorState) {
 
 	idx := state.menu.selectedResultIdx
 	selectedItem := results[idx]
+	query := search.Query()
 	HideMenu(state)
-	executeMenuItemAction(state, selectedItem)
+	if arg, ok := selectedItem.ArgFromQuery(query); ok {
+		executeMenuItemArgAction(state, selectedItem, arg)
+	} else {
+		executeMenuItemAction(state, selectedItem)
+	}
 	ScrollViewToCursor(state)
 }
 
@@ -151,6 +161,16 @@ func executeMenuItemAction(state *EditorState, item menu.Item) {
 	actionFunc(state)
 }
 
+func executeMenuItemArgAction(state *EditorState, item menu.Item, arg string) {
+	log.Printf("Executing menu item '%s' with argument '%s'\n", item.Name, arg)
+	actionFunc, ok := item.ArgAction.(func(*EditorState, string))
+	if !ok {
+		log.Printf("Invalid argument action for menu item '%s'\n", item.Name)
+		return
+	}
+	actionFunc(state, arg)
+}
+
 // MoveMenuSelection moves the menu selection up or down with wraparound.
 func MoveMenuSelection(state *EditorState, delta int) {
 	numResults := len(state.menu.search.Results())
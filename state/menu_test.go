@@ -67,6 +67,44 @@ func TestSelectAndExecuteMenuItem(t *testing.T) {
 	assert.True(t, state.QuitFlag())
 }
 
+func TestExecuteMenuItemWithArg(t *testing.T) {
+	testCases := []struct {
+		name        string
+		query       string
+		expectedArg string
+	}{
+		{name: "no arg", query: "earl", expectedArg: "<none>"},
+		{name: "arg", query: "earlier 10m", expectedArg: "10m"},
+		{name: "arg with extra spaces", query: "earlier  1h ", expectedArg: "1h"},
+		{name: "uppercase name", query: "Earlier 5m", expectedArg: "5m"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var arg string
+			state := NewEditorState(100, 100, nil, nil)
+			items := []menu.Item{
+				{
+					Name:      "earlier",
+					Action:    func(*EditorState) { arg = "<none>" },
+					ArgAction: func(_ *EditorState, a string) { arg = a },
+				},
+				{
+					Name:   "quit",
+					Action: Quit,
+				},
+			}
+			ShowMenu(state, MenuStyleCommand, items)
+			for _, r := range tc.query {
+				AppendRuneToMenuSearch(state, r)
+			}
+			ExecuteSelectedMenuItem(state)
+			assert.False(t, state.Menu().Visible())
+			assert.Equal(t, tc.expectedArg, arg)
+		})
+	}
+}
+
 func TestMoveMenuSelection(t *testing.T) {
 	testCases := []struct {
 		name              string
@@ -0,0 +1,216 @@
+package state
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/aretext/aretext/file"
+	"github.com/aretext/aretext/text"
+)
+
+const (
+	mergeLabelBuffer = "unsaved changes"
+	mergeLabelDisk   = "file on disk"
+)
+
+// setBaseText stores the current text of the buffer as the base for merging changes on disk.
+// The text should match the file on disk, so this should be called only after loading or saving.
+// Large files and hex dumps aren't merged, so the text isn't stored.
+func setBaseText(buffer *BufferState) {
+	if buffer.pager != nil || buffer.fileFormat.Hex {
+		buffer.baseText, buffer.hasBaseText = "", false
+		return
+	}
+	buffer.baseText, buffer.hasBaseText = buffer.textTree.String(), true
+}
+
+// MergeOrReloadDocument updates the document after its file changed on disk.
+// If the document has no unsaved changes, it is reloaded.
+// Otherwise, the changes on disk are merged with the unsaved changes, using the text
+// from the last load or save as the base.  If both changed the same lines,
+// the document contains both versions between conflict markers.
+func MergeOrReloadDocument(state *EditorState) {
+	if !state.documentBuffer.hasUnsavedChanges() {
+		ReloadDocument(state)
+		return
+	}
+
+	path := state.documentBuffer.fileWatcher.Path()
+	numConflicts, err := mergeChangesFromDisk(state)
+	if err != nil {
+		log.Printf("Error merging changes from disk for '%s': %v\n", path, err)
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  fmt.Sprintf("%s changed on disk and could not be merged: %s", file.RelativePathCwd(path), errors.Cause(err)),
+		})
+		return
+	}
+
+	if numConflicts == 0 {
+		log.Printf("Merged changes from disk for '%s'\n", path)
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleSuccess,
+			Text:  fmt.Sprintf("Merged changes from disk into %s", file.RelativePathCwd(path)),
+		})
+		return
+	}
+
+	log.Printf("Merged changes from disk for '%s' with %d conflicts\n", path, numConflicts)
+	conflictsText := "1 conflict"
+	if numConflicts > 1 {
+		conflictsText = fmt.Sprintf("%d conflicts", numConflicts)
+	}
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleError,
+		Text:  fmt.Sprintf("Merged changes from disk with %s - use ]n and [n to find them", conflictsText),
+	})
+}
+
+// mergeChangesFromDisk merges the file on disk into the document and returns the number of conflicts.
+func mergeChangesFromDisk(state *EditorState) (int, error) {
+	buffer := state.documentBuffer
+	if !buffer.hasBaseText {
+		return 0, errors.New("Document cannot be merged")
+	} else if buffer.readOnly {
+		return 0, errors.New("Document is read-only")
+	}
+
+	path := buffer.fileWatcher.Path()
+	tree, watcher, format, err := loadFile(path, state.configRuleSet.ConfigForPath(path), false)
+	if err != nil {
+		return 0, err
+	}
+
+	if format.Hex {
+		watcher.Stop()
+		return 0, errors.New("File on disk is not valid text")
+	}
+
+	diskText := tree.String()
+	bufferText := buffer.textTree.String()
+	result, err := text.Merge(buffer.baseText, bufferText, diskText, mergeLabelBuffer, mergeLabelDisk)
+	if err != nil {
+		watcher.Stop()
+		return 0, errors.Wrap(err, "text.Merge")
+	}
+
+	if state.inputMode == InputModeInsert || state.inputMode == InputModeVisual {
+		SetInputMode(state, InputModeNormal)
+	}
+	replaceChangedText(state, bufferText, result.Text)
+
+	// The document now includes the changes on disk, so watch for the next change.
+	buffer.fileWatcher.Stop()
+	buffer.fileWatcher = watcher
+	buffer.baseText = diskText
+
+	if len(result.Conflicts) > 0 {
+		firstConflictLineNum := result.Conflicts[0].StartLineNum
+		buffer.cursor = cursorState{position: buffer.textTree.LineStartPosition(firstConflictLineNum)}
+		ScrollViewToCursor(state)
+	}
+
+	return len(result.Conflicts), nil
+}
+
+// replaceChangedText replaces the document text with newText as a single undoable edit.
+// Only the text between the common prefix and suffix of oldText and newText is replaced,
+// so the cursor stays on the same text if it is outside the changed region.
+func replaceChangedText(state *EditorState, oldText, newText string) {
+	oldRunes, newRunes := []rune(oldText), []rune(newText)
+	var prefixLen int
+	for prefixLen < len(oldRunes) && prefixLen < len(newRunes) && oldRunes[prefixLen] == newRunes[prefixLen] {
+		prefixLen++
+	}
+
+	var suffixLen int
+	for suffixLen < len(oldRunes)-prefixLen && suffixLen < len(newRunes)-prefixLen &&
+		oldRunes[len(oldRunes)-1-suffixLen] == newRunes[len(newRunes)-1-suffixLen] {
+		suffixLen++
+	}
+
+	if prefixLen == len(oldRunes) && prefixLen == len(newRunes) {
+		return
+	}
+
+	pos := uint64(prefixLen)
+	deleteCount := uint64(len(oldRunes) - prefixLen - suffixLen)
+	insertText := string(newRunes[prefixLen : len(newRunes)-suffixLen])
+
+	buffer := state.documentBuffer
+	cursorPos := buffer.cursor.position
+	if cursorPos >= pos+deleteCount {
+		cursorPos = cursorPos - deleteCount + uint64(len(newRunes)-prefixLen-suffixLen)
+	} else if cursorPos > pos {
+		cursorPos = pos
+	}
+
+	CheckpointUndoLog(state)
+	deleteRunes(state, pos, deleteCount, true)
+	if err := insertTextAtPosition(state, insertText, pos, true); err != nil {
+		log.Printf("Error inserting merged text: %v\n", err)
+	}
+	CheckpointUndoLog(state)
+
+	if n := buffer.textTree.NumChars(); cursorPos > n {
+		cursorPos = n
+	}
+	buffer.cursor = cursorState{position: cursorPos}
+	ScrollViewToCursor(state)
+}
+
+// NextMergeConflict moves the cursor to the start of the next merge conflict.
+func NextMergeConflict(state *EditorState) {
+	tree := state.documentBuffer.textTree
+	lineNum := tree.LineNumForPosition(state.documentBuffer.cursor.position)
+	for n := lineNum + 1; n < tree.NumLines(); n++ {
+		if lineStartsWithConflictMarker(tree, n) {
+			moveCursorToLineStart(state, n)
+			return
+		}
+	}
+
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleError,
+		Text:  "No next conflict",
+	})
+}
+
+// PrevMergeConflict moves the cursor to the start of the previous merge conflict.
+func PrevMergeConflict(state *EditorState) {
+	tree := state.documentBuffer.textTree
+	lineNum := tree.LineNumForPosition(state.documentBuffer.cursor.position)
+	for n := lineNum; n > 0; n-- {
+		if lineStartsWithConflictMarker(tree, n-1) {
+			moveCursorToLineStart(state, n-1)
+			return
+		}
+	}
+
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleError,
+		Text:  "No previous conflict",
+	})
+}
+
+func lineStartsWithConflictMarker(tree *text.Tree, lineNum uint64) bool {
+	reader := tree.ReaderAtPosition(tree.LineStartPosition(lineNum))
+	var sb strings.Builder
+	for sb.Len() < len(text.MergeConflictStartMarker) {
+		r, _, err := reader.ReadRune()
+		if err != nil || r == '\n' {
+			break
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String() == text.MergeConflictStartMarker
+}
+
+func moveCursorToLineStart(state *EditorState, lineNum uint64) {
+	buffer := state.documentBuffer
+	buffer.cursor = cursorState{position: buffer.textTree.LineStartPosition(lineNum)}
+	ScrollViewToCursor(state)
+}
@@ -0,0 +1,108 @@
+package state
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeOrReloadDocumentWithoutUnsavedChanges(t *testing.T) {
+	path, cleanup := createTestFile(t, "abc")
+	defer cleanup()
+
+	state := NewEditorState(100, 100, nil, nil)
+	defer Quit(state)
+	LoadDocument(state, path, true, startOfDocLocator)
+
+	err := os.WriteFile(path, []byte("xyz"), 0644)
+	require.NoError(t, err)
+	MergeOrReloadDocument(state)
+	assert.Equal(t, "xyz", state.documentBuffer.textTree.String())
+	assert.False(t, state.documentBuffer.hasUnsavedChanges())
+}
+
+func TestMergeOrReloadDocumentCleanMerge(t *testing.T) {
+	path, cleanup := createTestFile(t, "abc\ndef\nghi\njkl\n")
+	defer cleanup()
+
+	state := NewEditorState(100, 100, nil, nil)
+	defer Quit(state)
+	LoadDocument(state, path, true, startOfDocLocator)
+	InsertRune(state, 'x')
+
+	// Another program changes a different line.
+	err := os.WriteFile(path, []byte("abc\ndef\nghi\nJKL\n"), 0644)
+	require.NoError(t, err)
+	MergeOrReloadDocument(state)
+	assert.Equal(t, "xabc\ndef\nghi\nJKL", state.documentBuffer.textTree.String())
+	assert.Equal(t, uint64(1), state.documentBuffer.cursor.position)
+	assert.Equal(t, StatusMsgStyleSuccess, state.StatusMsg().Style)
+	assert.True(t, state.documentBuffer.hasUnsavedChanges())
+
+	// The file on disk is now the base, so saving doesn't report a conflicting change.
+	var saved bool
+	AbortIfFileExistsWithChangedContent(state, func(state *EditorState) {
+		SaveDocument(state)
+		saved = true
+	})
+	assert.True(t, saved)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "xabc\ndef\nghi\nJKL\n", string(data))
+
+	// The merge can be undone.
+	Undo(state)
+	assert.Equal(t, "xabc\ndef\nghi\njkl", state.documentBuffer.textTree.String())
+}
+
+func TestMergeOrReloadDocumentWithConflict(t *testing.T) {
+	path, cleanup := createTestFile(t, "abc\ndef\nghi\n")
+	defer cleanup()
+
+	state := NewEditorState(100, 100, nil, nil)
+	defer Quit(state)
+	LoadDocument(state, path, true, startOfDocLocator)
+	MoveCursor(state, func(LocatorParams) uint64 { return 4 })
+	InsertRune(state, 'x')
+
+	// Another program changes the same line.
+	err := os.WriteFile(path, []byte("abc\ndey\nghi\n"), 0644)
+	require.NoError(t, err)
+	MergeOrReloadDocument(state)
+	expectedText := "abc\n<<<<<<< unsaved changes\nxdef\n=======\ndey\n>>>>>>> file on disk\nghi"
+	assert.Equal(t, expectedText, state.documentBuffer.textTree.String())
+	assert.Equal(t, uint64(4), state.documentBuffer.cursor.position)
+	assert.Equal(t, StatusMsg{
+		Style: StatusMsgStyleError,
+		Text:  "Merged changes from disk with 1 conflict - use ]n and [n to find them",
+	}, state.StatusMsg())
+}
+
+func TestMergeConflictNavigation(t *testing.T) {
+	path, cleanup := createTestFile(t, "a\n<<<<<<< x\nb\n=======\nc\n>>>>>>> y\nd\n<<<<<<< x\ne\n=======\nf\n>>>>>>> y\n")
+	defer cleanup()
+
+	state := NewEditorState(100, 100, nil, nil)
+	defer Quit(state)
+	LoadDocument(state, path, true, startOfDocLocator)
+
+	cursorLineNum := func() uint64 {
+		return state.documentBuffer.textTree.LineNumForPosition(state.documentBuffer.cursor.position)
+	}
+
+	NextMergeConflict(state)
+	assert.Equal(t, uint64(1), cursorLineNum())
+	NextMergeConflict(state)
+	assert.Equal(t, uint64(7), cursorLineNum())
+	NextMergeConflict(state)
+	assert.Equal(t, uint64(7), cursorLineNum())
+	assert.Equal(t, StatusMsg{Style: StatusMsgStyleError, Text: "No next conflict"}, state.StatusMsg())
+
+	PrevMergeConflict(state)
+	assert.Equal(t, uint64(1), cursorLineNum())
+	PrevMergeConflict(state)
+	assert.Equal(t, uint64(1), cursorLineNum())
+	assert.Equal(t, StatusMsg{Style: StatusMsgStyleError, Text: "No previous conflict"}, state.StatusMsg())
+}
@@ -36,7 +36,12 @@ func (im InputMode) String() string {
 }
 
 // SetInputMode sets the editor input mode.
+// Insert mode is disabled if the document is read-only.
 func SetInputMode(state *EditorState, mode InputMode) {
+	if mode == InputModeInsert && abortIfReadOnly(state) {
+		return
+	}
+
 	if state.inputMode != mode && mode == InputModeNormal && !state.macroState.isReplayingUserMacro {
 		// Transition back to normal mode should set an undo checkpoint.
 		// For example, suppose a user adds text in insert mode, then returns to normal mode,
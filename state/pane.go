@@ -0,0 +1,417 @@
+package state
+
+import (
+	"log"
+	"math"
+
+	"github.com/aretext/aretext/selection"
+)
+
+// SplitDirection controls how a split divides the screen between two panes.
+type SplitDirection int
+
+const (
+	SplitHorizontal = SplitDirection(iota) // One pane above the other.
+	SplitVertical                          // Panes side-by-side.
+)
+
+// PaneDirection is the direction from the active pane to a neighboring pane.
+type PaneDirection int
+
+const (
+	PaneDirectionLeft = PaneDirection(iota)
+	PaneDirectionRight
+	PaneDirectionUp
+	PaneDirectionDown
+)
+
+// paneState represents a region of the screen that displays a buffer.
+// The cursor and view of the active pane are stored in the current document buffer,
+// so these fields are used only for inactive panes.
+type paneState struct {
+	buffer *BufferState
+	cursor cursorState
+	view   viewState
+}
+
+// paneLayout is a node in the tree of panes.
+// Each leaf node has a pane, and every other node splits its region between two children.
+type paneLayout struct {
+	pane          *paneState
+	direction     SplitDirection
+	ratio         float64 // Fraction of the region allocated to the first child.
+	first, second *paneLayout
+	parent        *paneLayout
+
+	// x, y, width, and height are the screen region of the node, set by layoutPanes.
+	x, y, width, height uint64
+}
+
+func (l *paneLayout) isLeaf() bool {
+	return l.pane != nil
+}
+
+// leaves returns the leaf nodes in the subtree, ordered from top-left to bottom-right.
+func (l *paneLayout) leaves() []*paneLayout {
+	if l.isLeaf() {
+		return []*paneLayout{l}
+	}
+	return append(l.first.leaves(), l.second.leaves()...)
+}
+
+// InactivePanes returns buffers for the panes other than the active pane.
+// Each buffer has the cursor and view for its pane, and it should be used only to display the pane.
+func (s *EditorState) InactivePanes() []*BufferState {
+	var buffers []*BufferState
+	for _, node := range s.paneLayout.leaves() {
+		if node != s.activePane {
+			buffers = append(buffers, node.pane.displayBuffer())
+		}
+	}
+	return buffers
+}
+
+// NumPanes returns the number of panes on the screen.
+func (s *EditorState) NumPanes() int {
+	return len(s.paneLayout.leaves())
+}
+
+// displayBuffer returns a copy of the pane's buffer with the pane's cursor and view.
+// Selections and search matches belong to the active pane, so these are cleared.
+func (p *paneState) displayBuffer() *BufferState {
+	buffer := *p.buffer
+	buffer.cursor = p.cursor
+	buffer.view = p.view
+	buffer.selector = &selection.Selector{}
+	buffer.search = searchState{}
+
+	// The document may have been reloaded with shorter text since the pane was last updated.
+	n := buffer.textTree.NumChars()
+	if buffer.cursor.position > n {
+		buffer.cursor.position = n
+	}
+	if buffer.view.textOrigin > n {
+		buffer.view.textOrigin = buffer.textTree.LineStartPosition(buffer.textTree.LineNumForPosition(n))
+	}
+	return &buffer
+}
+
+// SplitPane divides the active pane in two, with both panes displaying the current document.
+// The active pane moves to the top or left of the split.
+func SplitPane(state *EditorState, direction SplitDirection) {
+	node := state.activePane
+	buffer := state.documentBuffer
+	node.first = &paneLayout{pane: node.pane, parent: node}
+	node.second = &paneLayout{
+		pane: &paneState{
+			buffer: buffer,
+			cursor: buffer.cursor,
+			view:   buffer.view,
+		},
+		parent: node,
+	}
+	node.pane = nil
+	node.direction = direction
+	node.ratio = 0.5
+	state.activePane = node.first
+	layoutPanes(state)
+	log.Printf("Split pane, now %d panes\n", state.NumPanes())
+}
+
+// ClosePane closes the active pane and activates a neighboring pane.
+// The document remains loaded in a buffer, even if no other pane displays it.
+func ClosePane(state *EditorState) {
+	node := state.activePane
+	parent := node.parent
+	if parent == nil {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  "Cannot close the only pane",
+		})
+		return
+	}
+
+	// Replace the parent with the sibling of the closed pane.
+	sibling := parent.first
+	if sibling == node {
+		sibling = parent.second
+	}
+	sibling.parent = parent.parent
+	if parent.parent == nil {
+		state.paneLayout = sibling
+	} else if parent.parent.first == parent {
+		parent.parent.first = sibling
+	} else {
+		parent.parent.second = sibling
+	}
+
+	activatePane(state, sibling.leaves()[0])
+	layoutPanes(state)
+	log.Printf("Closed pane, now %d panes\n", state.NumPanes())
+}
+
+// CloseOtherPanes closes every pane except the active pane.
+func CloseOtherPanes(state *EditorState) {
+	node := state.activePane
+	node.parent = nil
+	state.paneLayout = node
+	layoutPanes(state)
+}
+
+// FocusNextPane activates the pane after the active pane, wrapping around from the last pane to the first.
+func FocusNextPane(state *EditorState) {
+	focusPaneByOffset(state, 1)
+}
+
+// FocusPrevPane activates the pane before the active pane, wrapping around from the first pane to the last.
+func FocusPrevPane(state *EditorState) {
+	focusPaneByOffset(state, -1)
+}
+
+func focusPaneByOffset(state *EditorState, offset int) {
+	leaves := state.paneLayout.leaves()
+	for i, node := range leaves {
+		if node == state.activePane {
+			j := (i + offset + len(leaves)) % len(leaves)
+			focusPane(state, leaves[j])
+			return
+		}
+	}
+}
+
+// FocusPaneInDirection activates the pane adjacent to the active pane in the specified direction.
+// If several panes are adjacent, this chooses the one sharing the most rows or columns with the active pane.
+func FocusPaneInDirection(state *EditorState, direction PaneDirection) {
+	active := state.activePane
+	var bestNode *paneLayout
+	var bestOverlap uint64
+	for _, node := range state.paneLayout.leaves() {
+		var adjacent bool
+		var overlap uint64
+		switch direction {
+		case PaneDirectionLeft:
+			adjacent = node.x+node.width+1 == active.x
+			overlap = rangeOverlap(node.y, node.height, active.y, active.height)
+		case PaneDirectionRight:
+			adjacent = active.x+active.width+1 == node.x
+			overlap = rangeOverlap(node.y, node.height, active.y, active.height)
+		case PaneDirectionUp:
+			adjacent = node.y+node.height+1 == active.y
+			overlap = rangeOverlap(node.x, node.width, active.x, active.width)
+		case PaneDirectionDown:
+			adjacent = active.y+active.height+1 == node.y
+			overlap = rangeOverlap(node.x, node.width, active.x, active.width)
+		}
+
+		if adjacent && overlap > bestOverlap {
+			bestNode, bestOverlap = node, overlap
+		}
+	}
+
+	if bestNode != nil {
+		focusPane(state, bestNode)
+	}
+}
+
+func rangeOverlap(start1, length1, start2, length2 uint64) uint64 {
+	start, end := start1, start1+length1
+	if start2 > start {
+		start = start2
+	}
+	if start2+length2 < end {
+		end = start2 + length2
+	}
+	if end <= start {
+		return 0
+	}
+	return end - start
+}
+
+// ResizePane grows (or shrinks, if delta is negative) the active pane by delta rows or columns.
+// This changes the nearest split in the specified direction that contains the active pane.
+func ResizePane(state *EditorState, direction SplitDirection, delta int) {
+	child := state.activePane
+	node := child.parent
+	for node != nil && node.direction != direction {
+		child, node = node, node.parent
+	}
+	if node == nil {
+		return
+	}
+
+	size := node.height
+	if direction == SplitVertical {
+		size = node.width
+	}
+	if size < 3 {
+		// Not enough space to resize, since each pane needs at least one row or column plus the separator.
+		return
+	}
+
+	available := int(size) - 1 // Leave space for the separator.
+	firstSize := int(math.Round(node.ratio * float64(available)))
+	if child == node.first {
+		firstSize += delta
+	} else {
+		firstSize -= delta
+	}
+
+	if firstSize < 1 {
+		firstSize = 1
+	} else if firstSize > available-1 {
+		firstSize = available - 1
+	}
+	node.ratio = float64(firstSize) / float64(available)
+	layoutPanes(state)
+}
+
+// focusPane activates a pane, saving the cursor and view of the previously active pane.
+func focusPane(state *EditorState, node *paneLayout) {
+	if node == state.activePane {
+		return
+	}
+
+	buffer := state.documentBuffer
+	oldPane := state.activePane.pane
+	oldPane.buffer = buffer
+	oldPane.cursor = buffer.cursor
+	oldPane.view = buffer.view
+	activatePane(state, node)
+}
+
+// activatePane makes a pane active, replacing the current document with the pane's buffer.
+// The caller is responsible for saving the state of the previously active pane, if it is still displayed.
+func activatePane(state *EditorState, node *paneLayout) {
+	CancelTaskIfRunning(state)
+	state.activePane = node
+
+	pane := node.pane
+	buffer := pane.buffer
+	if buffer != state.documentBuffer {
+		for i, b := range state.backgroundBuffers {
+			if b == buffer {
+				state.backgroundBuffers = append(state.backgroundBuffers[:i], state.backgroundBuffers[i+1:]...)
+				break
+			}
+		}
+		moveCurrentBufferToBackground(state)
+		state.documentBuffer = buffer
+		resetStateForCurrentDocument(state, state.configRuleSet.ConfigForPath(buffer.fileWatcher.Path()))
+	} else {
+		SetInputMode(state, InputModeNormal)
+	}
+
+	displayBuffer := pane.displayBuffer()
+	buffer.cursor = displayBuffer.cursor
+	buffer.view = displayBuffer.view
+	ScrollViewToCursor(state)
+}
+
+// isBufferInInactivePane returns whether an inactive pane displays the buffer.
+func isBufferInInactivePane(state *EditorState, buffer *BufferState) bool {
+	for _, node := range state.paneLayout.leaves() {
+		if node != state.activePane && node.pane.buffer == buffer {
+			return true
+		}
+	}
+	return false
+}
+
+// layoutPanes assigns a region of the screen to each pane.
+// Splits leave one row or column between panes for a separator.
+func layoutPanes(state *EditorState) {
+	var height uint64
+	if state.screenHeight > 0 {
+		// Leave one line for the status bar at the bottom.
+		height = state.screenHeight - 1
+	}
+	layoutNode(state.paneLayout, 0, 0, state.screenWidth, height)
+
+	for _, node := range state.paneLayout.leaves() {
+		view := &node.pane.view
+		if node == state.activePane {
+			view = &state.documentBuffer.view
+		}
+		view.x, view.y = node.x, node.y
+		view.width, view.height = node.width, node.height
+
+		if node != state.activePane {
+			// Keep the cursor visible in the resized pane.
+			displayBuffer := node.pane.displayBuffer()
+			scrollViewToPosition(displayBuffer, displayBuffer.cursor.position)
+			view.textOrigin = displayBuffer.view.textOrigin
+		}
+	}
+}
+
+func layoutNode(node *paneLayout, x, y, width, height uint64) {
+	node.x, node.y, node.width, node.height = x, y, width, height
+	if node.isLeaf() {
+		return
+	}
+
+	size := height
+	if node.direction == SplitVertical {
+		size = width
+	}
+
+	var firstSize, secondSize uint64
+	if size > 0 {
+		available := size - 1 // Leave space for the separator.
+		firstSize = uint64(math.Round(node.ratio * float64(available)))
+		if firstSize > available {
+			firstSize = available
+		}
+		secondSize = available - firstSize
+	}
+
+	if node.direction == SplitVertical {
+		layoutNode(node.first, x, y, firstSize, height)
+		layoutNode(node.second, x+firstSize+1, y, secondSize, height)
+	} else {
+		layoutNode(node.first, x, y, width, firstSize)
+		layoutNode(node.second, x, y+firstSize+1, width, secondSize)
+	}
+}
+
+// updateInactivePanesAfterInsert shifts the cursor and view of inactive panes
+// displaying the current document, so they stay on the same text after an insertion.
+func updateInactivePanesAfterInsert(state *EditorState, pos uint64, n uint64) {
+	updateInactivePanes(state, func(p uint64, isCursor bool) uint64 {
+		if p > pos || (isCursor && p == pos) {
+			return p + n
+		}
+		return p
+	})
+}
+
+// updateInactivePanesAfterDelete shifts the cursor and view of inactive panes
+// displaying the current document, so they stay on the same text after a deletion.
+func updateInactivePanesAfterDelete(state *EditorState, pos uint64, n uint64) {
+	updateInactivePanes(state, func(p uint64, isCursor bool) uint64 {
+		if p >= pos+n {
+			return p - n
+		} else if p > pos {
+			return pos
+		}
+		return p
+	})
+}
+
+func updateInactivePanes(state *EditorState, f func(p uint64, isCursor bool) uint64) {
+	if state.paneLayout.isLeaf() {
+		return
+	}
+
+	buffer := state.documentBuffer
+	for _, node := range state.paneLayout.leaves() {
+		pane := node.pane
+		if node == state.activePane || pane.buffer != buffer {
+			continue
+		}
+
+		pane.cursor.position = f(pane.cursor.position, true)
+		pane.view.textOrigin = f(pane.view.textOrigin, false)
+		pane.view.textOrigin = buffer.textTree.LineStartPosition(buffer.textTree.LineNumForPosition(pane.view.textOrigin))
+	}
+}
@@ -0,0 +1,154 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func viewRegion(buffer *BufferState) [4]uint64 {
+	x, y := buffer.ViewOrigin()
+	width, height := buffer.ViewSize()
+	return [4]uint64{x, y, width, height}
+}
+
+func TestSplitPaneLayout(t *testing.T) {
+	state := NewEditorState(21, 12, nil, nil)
+	SplitPane(state, SplitVertical)
+	SplitPane(state, SplitHorizontal)
+	assert.Equal(t, 3, state.NumPanes())
+
+	// The active pane is in the top-left, and each split leaves space for a border.
+	assert.Equal(t, [4]uint64{0, 0, 10, 5}, viewRegion(state.documentBuffer))
+	inactivePanes := state.InactivePanes()
+	require.Equal(t, 2, len(inactivePanes))
+	assert.Equal(t, [4]uint64{0, 6, 10, 5}, viewRegion(inactivePanes[0]))
+	assert.Equal(t, [4]uint64{11, 0, 10, 11}, viewRegion(inactivePanes[1]))
+
+	// Resizing the screen resizes every pane.
+	ResizeView(state, 31, 22)
+	assert.Equal(t, [4]uint64{0, 0, 15, 10}, viewRegion(state.documentBuffer))
+	inactivePanes = state.InactivePanes()
+	assert.Equal(t, [4]uint64{0, 11, 15, 10}, viewRegion(inactivePanes[0]))
+	assert.Equal(t, [4]uint64{16, 0, 15, 21}, viewRegion(inactivePanes[1]))
+}
+
+func TestFocusPane(t *testing.T) {
+	state := NewEditorState(21, 12, nil, nil)
+	SplitPane(state, SplitVertical)
+	SplitPane(state, SplitHorizontal)
+
+	FocusPaneInDirection(state, PaneDirectionDown)
+	assert.Equal(t, [4]uint64{0, 6, 10, 5}, viewRegion(state.documentBuffer))
+
+	FocusPaneInDirection(state, PaneDirectionRight)
+	assert.Equal(t, [4]uint64{11, 0, 10, 11}, viewRegion(state.documentBuffer))
+
+	// There is no pane further right, so the active pane doesn't change.
+	FocusPaneInDirection(state, PaneDirectionRight)
+	assert.Equal(t, [4]uint64{11, 0, 10, 11}, viewRegion(state.documentBuffer))
+
+	// Next pane wraps around to the first pane.
+	FocusNextPane(state)
+	assert.Equal(t, [4]uint64{0, 0, 10, 5}, viewRegion(state.documentBuffer))
+
+	FocusPrevPane(state)
+	assert.Equal(t, [4]uint64{11, 0, 10, 11}, viewRegion(state.documentBuffer))
+}
+
+func TestResizePane(t *testing.T) {
+	state := NewEditorState(21, 12, nil, nil)
+	SplitPane(state, SplitVertical)
+
+	ResizePane(state, SplitVertical, 3)
+	assert.Equal(t, [4]uint64{0, 0, 13, 11}, viewRegion(state.documentBuffer))
+	assert.Equal(t, [4]uint64{14, 0, 7, 11}, viewRegion(state.InactivePanes()[0]))
+
+	// Growing the right pane shrinks the left pane.
+	FocusNextPane(state)
+	ResizePane(state, SplitVertical, 5)
+	assert.Equal(t, [4]uint64{9, 0, 12, 11}, viewRegion(state.documentBuffer))
+
+	// Each pane keeps at least one column.
+	ResizePane(state, SplitVertical, 100)
+	assert.Equal(t, [4]uint64{2, 0, 19, 11}, viewRegion(state.documentBuffer))
+
+	// No horizontal split contains the pane, so this has no effect.
+	ResizePane(state, SplitHorizontal, 1)
+	assert.Equal(t, [4]uint64{2, 0, 19, 11}, viewRegion(state.documentBuffer))
+}
+
+func TestClosePane(t *testing.T) {
+	state := NewEditorState(21, 12, nil, nil)
+	ClosePane(state)
+	assert.Equal(t, StatusMsgStyleError, state.StatusMsg().Style)
+	assert.Equal(t, 1, state.NumPanes())
+
+	SplitPane(state, SplitVertical)
+	SplitPane(state, SplitHorizontal)
+	ClosePane(state)
+	assert.Equal(t, 2, state.NumPanes())
+	assert.Equal(t, [4]uint64{0, 0, 10, 11}, viewRegion(state.documentBuffer))
+
+	CloseOtherPanes(state)
+	assert.Equal(t, 1, state.NumPanes())
+	assert.Equal(t, [4]uint64{0, 0, 21, 11}, viewRegion(state.documentBuffer))
+}
+
+func TestPanesSameBufferStayInSync(t *testing.T) {
+	path, cleanup := createTestFile(t, "abc\ndef\nghi")
+	defer cleanup()
+
+	state := NewEditorState(21, 12, nil, nil)
+	defer Quit(state)
+	LoadDocument(state, path, true, startOfDocLocator)
+	SplitPane(state, SplitHorizontal)
+
+	// Move the cursor in the bottom pane to the start of the last line.
+	FocusNextPane(state)
+	MoveCursor(state, func(LocatorParams) uint64 { return 8 })
+	FocusPrevPane(state)
+	assert.Equal(t, uint64(0), state.documentBuffer.cursor.position)
+
+	// Insert and delete text before the cursor in the bottom pane.
+	InsertRune(state, 'x')
+	InsertNewline(state)
+	assert.Equal(t, uint64(10), state.InactivePanes()[0].CursorPosition())
+	deleteRunes(state, 5, 4, true)
+	assert.Equal(t, "x\nabc\nghi", state.documentBuffer.textTree.String())
+	assert.Equal(t, uint64(6), state.InactivePanes()[0].CursorPosition())
+
+	// The bottom pane has the same cursor after switching to it.
+	FocusNextPane(state)
+	assert.Equal(t, uint64(6), state.documentBuffer.cursor.position)
+}
+
+func TestPanesDifferentBuffers(t *testing.T) {
+	path, cleanup := createTestFile(t, "abc")
+	defer cleanup()
+	path2, cleanup2 := createTestFile(t, "xyz")
+	defer cleanup2()
+
+	state := NewEditorState(21, 12, nil, nil)
+	defer Quit(state)
+	LoadDocument(state, path, true, startOfDocLocator)
+	SplitPane(state, SplitVertical)
+	LoadDocument(state, path2, true, startOfDocLocator)
+	assert.Equal(t, "xyz", state.documentBuffer.textTree.String())
+	assert.Equal(t, "abc", state.InactivePanes()[0].TextTree().String())
+
+	// Each pane keeps its own document.
+	FocusNextPane(state)
+	assert.Equal(t, path, state.documentBuffer.fileWatcher.Path())
+	assert.Equal(t, [4]uint64{11, 0, 10, 11}, viewRegion(state.documentBuffer))
+	assert.Equal(t, "xyz", state.InactivePanes()[0].TextTree().String())
+	require.Equal(t, 1, len(state.backgroundBuffers))
+	assert.Equal(t, path2, state.backgroundBuffers[0].fileWatcher.Path())
+
+	// Closing the pane keeps the document loaded.
+	ClosePane(state)
+	assert.Equal(t, path2, state.documentBuffer.fileWatcher.Path())
+	require.Equal(t, 1, len(state.backgroundBuffers))
+	assert.Equal(t, path, state.backgroundBuffers[0].fileWatcher.Path())
+}
@@ -1,7 +1,25 @@
 package state
 
 // Quit sets a flag that terminates the program.
+// The undo history for each loaded document is saved first, so it can be restored when the file is reopened.
+// Any unsaved changes are discarded, so this also removes the recovery snapshots for the documents.
 func Quit(state *EditorState) {
-	state.fileWatcher.Stop()
+	for _, buffer := range allBuffers(state) {
+		persistUndoLog(state, buffer)
+		removeRecoverySnapshot(state, buffer)
+		buffer.fileWatcher.Stop()
+	}
+	state.quitFlag = true
+}
+
+// QuitWithRecoverySnapshot sets a flag that terminates the program after saving a snapshot of any unsaved changes.
+// This is used when the program receives a signal to terminate, so the user can recover the changes
+// the next time the document is opened.
+func QuitWithRecoverySnapshot(state *EditorState) {
+	for _, buffer := range allBuffers(state) {
+		persistUndoLog(state, buffer)
+		writeRecoverySnapshot(state, buffer)
+		buffer.fileWatcher.Stop()
+	}
 	state.quitFlag = true
 }
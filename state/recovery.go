@@ -0,0 +1,259 @@
+package state
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+
+	"github.com/aretext/aretext/config"
+	"github.com/aretext/aretext/file"
+	"github.com/aretext/aretext/locate"
+	"github.com/aretext/aretext/menu"
+	"github.com/aretext/aretext/undo"
+)
+
+// recoverySnapshotState tracks the recovery snapshot for a buffer.
+type recoverySnapshotState struct {
+	// owned indicates that the snapshot for the document was written or recovered by this editor,
+	// so it should be removed once the changes are saved or discarded.
+	owned bool
+
+	// undoLog, undoSeq, and undoNumOps identify the document contents in the last snapshot.
+	// These are used to avoid writing a snapshot if the document hasn't changed.
+	// The undo log is replaced whenever the document is loaded, so it identifies the load.
+	undoLog    *undo.Log
+	undoSeq    int
+	undoNumOps int
+}
+
+const (
+	recoverMenuItemName = "recover unsaved changes"
+	diffMenuItemName    = "diff unsaved changes"
+	discardMenuItemName = "discard unsaved changes"
+)
+
+// SetRecoveryStore sets the store used to save snapshots of unsaved changes.
+// If the store is nil, no snapshots are saved.
+func SetRecoveryStore(state *EditorState, store *file.RecoveryStore) {
+	state.recoveryStore = store
+}
+
+// WriteRecoverySnapshot saves a snapshot of the document if it has unsaved changes.
+// The editor calls this periodically, so the changes can be recovered if the editor exits unexpectedly.
+// If the document changes have since been undone, this removes the snapshot instead.
+func WriteRecoverySnapshot(state *EditorState) {
+	writeRecoverySnapshot(state, state.documentBuffer)
+}
+
+func writeRecoverySnapshot(state *EditorState, buffer *BufferState) {
+	store := state.recoveryStore
+	path := buffer.fileWatcher.Path()
+	if store == nil || path == "" || buffer.fileFormat.Hex || buffer.pager != nil {
+		return
+	}
+
+	if !buffer.hasUnsavedChanges() {
+		removeRecoverySnapshot(state, buffer)
+		return
+	}
+
+	undoSeq, undoNumOps := buffer.undoLog.Position()
+	snapshot := &buffer.recoverySnapshot
+	if snapshot.owned && snapshot.undoLog == buffer.undoLog && snapshot.undoSeq == undoSeq && snapshot.undoNumOps == undoNumOps {
+		// The document hasn't changed since the last snapshot.
+		return
+	}
+
+	if !snapshot.owned {
+		// Never overwrite a snapshot from another session, since the user might still want to recover it.
+		// This can happen if the user dismisses the recovery menu and continues editing.
+		existing, err := store.Load(path)
+		if err != nil {
+			log.Printf("Error loading recovery snapshot for '%s': %v\n", path, err)
+			return
+		} else if existing != nil {
+			log.Printf("Skipped writing recovery snapshot for '%s' to keep the snapshot from %s\n", path, existing.Time)
+			return
+		}
+	}
+
+	if err := store.Save(path, buffer.textTree); err != nil {
+		log.Printf("Error writing recovery snapshot for '%s': %v\n", path, err)
+		return
+	}
+
+	log.Printf("Wrote recovery snapshot for '%s'\n", path)
+	*snapshot = recoverySnapshotState{
+		owned:      true,
+		undoLog:    buffer.undoLog,
+		undoSeq:    undoSeq,
+		undoNumOps: undoNumOps,
+	}
+}
+
+// removeRecoverySnapshot removes the snapshot for the buffer's document, if this editor wrote it.
+// Snapshots from another session are kept until the user recovers or discards them.
+func removeRecoverySnapshot(state *EditorState, buffer *BufferState) {
+	store := state.recoveryStore
+	path := buffer.fileWatcher.Path()
+	if store == nil || !buffer.recoverySnapshot.owned {
+		return
+	}
+
+	if err := store.Remove(path); err != nil {
+		log.Printf("Error removing recovery snapshot for '%s': %v\n", path, err)
+		return
+	}
+
+	log.Printf("Removed recovery snapshot for '%s'\n", path)
+	buffer.recoverySnapshot = recoverySnapshotState{}
+}
+
+// showRecoveryMenuIfSnapshotExists offers to recover unsaved changes to the document from a previous session.
+func showRecoveryMenuIfSnapshotExists(state *EditorState) {
+	store := state.recoveryStore
+	buffer := state.documentBuffer
+	path := buffer.fileWatcher.Path()
+	if store == nil || path == "" || buffer.fileFormat.Hex || buffer.pager != nil || buffer.recoverySnapshot.owned {
+		// If this editor owns the snapshot, it was written for a buffer that was already loaded.
+		return
+	}
+
+	snapshot, err := store.Load(path)
+	if err != nil {
+		log.Printf("Error loading recovery snapshot for '%s': %v\n", path, err)
+		return
+	} else if snapshot == nil {
+		return
+	}
+
+	log.Printf("Found recovery snapshot for '%s' from %s\n", path, snapshot.Time)
+	ShowMenu(state, MenuStyleRecovery, []menu.Item{
+		{
+			Name: recoverMenuItemName,
+			Action: func(state *EditorState) {
+				recoverFromSnapshot(state, snapshot)
+			},
+		},
+		{
+			Name: diffMenuItemName,
+			Action: func(state *EditorState) {
+				diffRecoverySnapshot(state, snapshot)
+			},
+		},
+		{
+			Name: discardMenuItemName,
+			Action: func(state *EditorState) {
+				discardRecoverySnapshot(state, snapshot)
+			},
+		},
+	})
+
+	// Select recover by default, since it's the only option that can't lose changes.
+	results, _ := state.menu.SearchResults()
+	for i, item := range results {
+		if item.Name == recoverMenuItemName {
+			state.menu.selectedResultIdx = i
+		}
+	}
+
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleError,
+		Text:  fmt.Sprintf("Found unsaved changes from %s", snapshot.Time.Format("Jan 2 15:04")),
+	})
+}
+
+// recoverFromSnapshot replaces the document contents with the contents of the snapshot.
+// This is tracked in the undo log, so the user can undo the recovery.
+func recoverFromSnapshot(state *EditorState, snapshot *file.RecoverySnapshot) {
+	data, err := os.ReadFile(snapshot.TextPath)
+	if err != nil {
+		reportRecoveryError(state, errors.Wrap(err, "os.ReadFile"))
+		return
+	} else if !utf8.Valid(data) {
+		reportRecoveryError(state, errors.New("Recovered text is not valid UTF-8"))
+		return
+	}
+
+	if abortIfReadOnly(state) {
+		return
+	}
+
+	buffer := state.documentBuffer
+	lineNum, col := locate.PosToLineNumAndCol(buffer.textTree, buffer.cursor.position)
+	CheckpointUndoLog(state)
+	deleteRunes(state, 0, buffer.textTree.NumChars(), true)
+	if err := insertTextAtPosition(state, string(data), 0, true); err != nil {
+		reportRecoveryError(state, err)
+		return
+	}
+	CheckpointUndoLog(state)
+
+	buffer.cursor = cursorState{
+		position: locate.LineNumAndColToPos(buffer.textTree, lineNum, col),
+	}
+	ScrollViewToCursor(state)
+
+	// The snapshot now belongs to this editor, so it will be removed once the changes are saved or discarded.
+	buffer.recoverySnapshot = recoverySnapshotState{owned: true}
+
+	log.Printf("Recovered unsaved changes for '%s'\n", snapshot.Path)
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  fmt.Sprintf("Recovered unsaved changes from %s", snapshot.Time.Format("Jan 2 15:04")),
+	})
+}
+
+// diffRecoverySnapshot shows the differences between the document and the snapshot using diff and a pager.
+// Afterwards, it shows the recovery menu again so the user can recover or discard the changes.
+func diffRecoverySnapshot(state *EditorState, snapshot *file.RecoverySnapshot) {
+	f, err := os.CreateTemp("", "aretext-recovery-")
+	if err != nil {
+		reportRecoveryError(state, errors.Wrap(err, "os.CreateTemp"))
+		return
+	}
+	defer os.Remove(f.Name())
+
+	treeReader := state.documentBuffer.textTree.ReaderAtPosition(0)
+	_, err = f.ReadFrom(&treeReader)
+	f.Close()
+	if err != nil {
+		reportRecoveryError(state, errors.Wrap(err, "f.ReadFrom"))
+		return
+	}
+
+	shellCmd := fmt.Sprintf("diff -u %s %s | ${PAGER:-less}", shellQuote(f.Name()), shellQuote(snapshot.TextPath))
+	RunShellCmd(state, shellCmd, config.CmdModeTerminal)
+	showRecoveryMenuIfSnapshotExists(state)
+}
+
+// discardRecoverySnapshot removes the snapshot without changing the document.
+func discardRecoverySnapshot(state *EditorState, snapshot *file.RecoverySnapshot) {
+	if err := state.recoveryStore.Remove(snapshot.Path); err != nil {
+		reportRecoveryError(state, err)
+		return
+	}
+
+	log.Printf("Discarded unsaved changes for '%s'\n", snapshot.Path)
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  "Discarded unsaved changes",
+	})
+}
+
+func reportRecoveryError(state *EditorState, err error) {
+	log.Printf("Error recovering unsaved changes: %v\n", err)
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleError,
+		Text:  fmt.Sprintf("Could not recover unsaved changes: %s", errors.Cause(err)),
+	})
+}
+
+// shellQuote quotes a string so the shell interprets it as a single word.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,190 @@
+package state
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/file"
+)
+
+func TestWriteRecoverySnapshot(t *testing.T) {
+	path, cleanup := createTestFile(t, "abc")
+	defer cleanup()
+	store := file.NewRecoveryStore(t.TempDir())
+
+	state := NewEditorState(100, 100, nil, nil)
+	SetRecoveryStore(state, store)
+	LoadDocument(state, path, true, startOfDocLocator)
+	defer state.documentBuffer.fileWatcher.Stop()
+
+	// No snapshot without unsaved changes.
+	WriteRecoverySnapshot(state)
+	snapshot, err := store.Load(path)
+	require.NoError(t, err)
+	assert.Nil(t, snapshot)
+
+	// Write a snapshot once the document has unsaved changes.
+	InsertRune(state, 'x')
+	WriteRecoverySnapshot(state)
+	snapshot, err = store.Load(path)
+	require.NoError(t, err)
+	require.NotNil(t, snapshot)
+
+	// Saving the document removes the snapshot.
+	SaveDocument(state)
+	snapshot, err = store.Load(path)
+	require.NoError(t, err)
+	assert.Nil(t, snapshot)
+
+	// Undoing the changes back to the saved state also removes the snapshot.
+	CheckpointUndoLog(state)
+	InsertRune(state, 'y')
+	CheckpointUndoLog(state)
+	WriteRecoverySnapshot(state)
+	snapshot, err = store.Load(path)
+	require.NoError(t, err)
+	require.NotNil(t, snapshot)
+	Undo(state)
+	WriteRecoverySnapshot(state)
+	snapshot, err = store.Load(path)
+	require.NoError(t, err)
+	assert.Nil(t, snapshot)
+}
+
+func TestRecoverUnsavedChanges(t *testing.T) {
+	testCases := []struct {
+		name                 string
+		menuItemName         string
+		expectedText         string
+		expectSnapshot       bool
+		expectUnsavedChanges bool
+	}{
+		{
+			name:                 "recover",
+			menuItemName:         recoverMenuItemName,
+			expectedText:         "abc\nxdef",
+			expectSnapshot:       true,
+			expectUnsavedChanges: true,
+		},
+		{
+			name:                 "discard",
+			menuItemName:         discardMenuItemName,
+			expectedText:         "abc\ndef",
+			expectSnapshot:       false,
+			expectUnsavedChanges: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path, cleanup := createTestFile(t, "abc\ndef")
+			defer cleanup()
+			store := file.NewRecoveryStore(t.TempDir())
+
+			// Make an unsaved change, then quit after a signal.
+			state := NewEditorState(100, 100, nil, nil)
+			SetRecoveryStore(state, store)
+			LoadDocument(state, path, true, startOfDocLocator)
+			MoveCursor(state, func(p LocatorParams) uint64 { return 4 })
+			InsertRune(state, 'x')
+			QuitWithRecoverySnapshot(state)
+			assert.True(t, state.QuitFlag())
+
+			// Reopen the document, which should offer to recover the changes.
+			state = NewEditorState(100, 100, nil, nil)
+			SetRecoveryStore(state, store)
+			LoadDocument(state, path, true, startOfDocLocator)
+			defer state.documentBuffer.fileWatcher.Stop()
+			assert.Equal(t, "abc\ndef", state.documentBuffer.textTree.String())
+			assert.True(t, state.Menu().Visible())
+			assert.Equal(t, MenuStyleRecovery, state.Menu().Style())
+			assert.Contains(t, state.StatusMsg().Text, "Found unsaved changes from")
+
+			// Recover is selected by default.
+			results, selectedIdx := state.Menu().SearchResults()
+			require.Equal(t, 3, len(results))
+			assert.Equal(t, recoverMenuItemName, results[selectedIdx].Name)
+
+			for results[selectedIdx].Name != tc.menuItemName {
+				MoveMenuSelection(state, 1)
+				results, selectedIdx = state.Menu().SearchResults()
+			}
+			ExecuteSelectedMenuItem(state)
+			assert.Equal(t, tc.expectedText, state.documentBuffer.textTree.String())
+			assert.Equal(t, tc.expectUnsavedChanges, state.documentBuffer.hasUnsavedChanges())
+
+			snapshot, err := store.Load(path)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectSnapshot, snapshot != nil)
+		})
+	}
+}
+
+func TestWriteRecoverySnapshotAfterDismissingRecoveryMenu(t *testing.T) {
+	path, cleanup := createTestFile(t, "abc\ndef")
+	defer cleanup()
+	store := file.NewRecoveryStore(t.TempDir())
+
+	// Make an unsaved change, then quit after a signal.
+	state := NewEditorState(100, 100, nil, nil)
+	SetRecoveryStore(state, store)
+	LoadDocument(state, path, true, startOfDocLocator)
+	InsertRune(state, 'x')
+	QuitWithRecoverySnapshot(state)
+
+	// Reopen the document, dismiss the recovery menu, and make a different change.
+	state = NewEditorState(100, 100, nil, nil)
+	SetRecoveryStore(state, store)
+	LoadDocument(state, path, true, startOfDocLocator)
+	defer state.documentBuffer.fileWatcher.Stop()
+	require.True(t, state.Menu().Visible())
+	HideMenu(state)
+	InsertRune(state, 'y')
+	WriteRecoverySnapshot(state)
+
+	// The snapshot from the previous session is preserved.
+	snapshot, err := store.Load(path)
+	require.NoError(t, err)
+	require.NotNil(t, snapshot)
+	data, err := os.ReadFile(snapshot.TextPath)
+	require.NoError(t, err)
+	assert.Equal(t, "xabc\ndef", string(data))
+
+	// Saving the document doesn't remove the snapshot, since this editor doesn't own it.
+	SaveDocument(state)
+	snapshot, err = store.Load(path)
+	require.NoError(t, err)
+	assert.NotNil(t, snapshot)
+}
+
+func TestRecoverUnsavedChangesUndo(t *testing.T) {
+	path, cleanup := createTestFile(t, "abc")
+	defer cleanup()
+	store := file.NewRecoveryStore(t.TempDir())
+
+	state := NewEditorState(100, 100, nil, nil)
+	SetRecoveryStore(state, store)
+	LoadDocument(state, path, true, startOfDocLocator)
+	InsertRune(state, 'x')
+	QuitWithRecoverySnapshot(state)
+
+	state = NewEditorState(100, 100, nil, nil)
+	SetRecoveryStore(state, store)
+	LoadDocument(state, path, true, startOfDocLocator)
+	defer state.documentBuffer.fileWatcher.Stop()
+	ExecuteSelectedMenuItem(state)
+	assert.Equal(t, "xabc", state.documentBuffer.textTree.String())
+
+	// The recovery can be undone.
+	Undo(state)
+	assert.Equal(t, "abc", state.documentBuffer.textTree.String())
+
+	// Quitting discards the changes, so the snapshot is removed.
+	Quit(state)
+	snapshot, err := store.Load(path)
+	require.NoError(t, err)
+	assert.Nil(t, snapshot)
+}
@@ -107,6 +107,18 @@ func DeleteRuneFromSearchQuery(state *EditorState) {
 func runTextSearchQuery(state *EditorState, q string) {
 	buffer := state.documentBuffer
 	buffer.search.query = q
+
+	// In hex mode, a query consisting of hex digits searches for a sequence of bytes.
+	if hexQuery, ok := parseHexQuery(q); ok && buffer.fileFormat.Hex {
+		buffer.search.match = searchHex(buffer.textTree, buffer.cursor.position, buffer.search.direction, hexQuery)
+		if buffer.search.match == nil {
+			ScrollViewToCursor(state)
+			return
+		}
+		scrollViewToPosition(buffer, buffer.search.match.StartPos)
+		return
+	}
+
 	foundMatch, matchStartPos := false, uint64(0)
 	parsedQuery := parseQuery(q)
 	if buffer.search.direction == SearchDirectionForward {
@@ -144,6 +156,17 @@ func FindNextMatch(state *EditorState, reverse bool) {
 		direction = direction.Reverse()
 	}
 
+	if hexQuery, ok := parseHexQuery(buffer.search.query); ok && buffer.fileFormat.Hex {
+		startPos := buffer.cursor.position
+		if direction == SearchDirectionForward {
+			startPos++
+		}
+		if match := searchHex(buffer.textTree, startPos, direction, hexQuery); match != nil {
+			buffer.cursor = cursorState{position: match.StartPos}
+		}
+		return
+	}
+
 	foundMatch, newCursorPos := false, uint64(0)
 	if direction == SearchDirectionForward {
 		foundMatch, newCursorPos = searchTextForward(
@@ -95,7 +95,7 @@ func envVars(state *EditorState) []string {
 	env := os.Environ()
 
 	// $FILEPATH is the path to the current file.
-	filePath := state.fileWatcher.Path()
+	filePath := state.documentBuffer.fileWatcher.Path()
 	env = append(env, fmt.Sprintf("FILEPATH=%s", filePath))
 
 	// $WORD is the current word under the cursor (excluding whitespace).
@@ -190,11 +190,9 @@ func menuItemsFromFileLocations(locations []shellcmd.FileLocation) ([]menu.Item,
 		menuItems = append(menuItems, menu.Item{
 			Name: name,
 			Action: func(s *EditorState) {
-				AbortIfUnsavedChanges(s, func(s *EditorState) {
-					LoadDocument(s, path, true, func(p LocatorParams) uint64 {
-						return locate.StartOfLineNum(p.TextTree, lineNum)
-					})
-				}, true)
+				LoadDocument(s, path, true, func(p LocatorParams) uint64 {
+					return locate.StartOfLineNum(p.TextTree, lineNum)
+				})
 			},
 		})
 	}
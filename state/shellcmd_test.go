@@ -270,7 +270,7 @@ func TestRunShellCmdFileLocationsMenu(t *testing.T) {
 
 		// Execute the menu item and verify that the document loads.
 		ExecuteSelectedMenuItem(state)
-		assert.Equal(t, p, state.fileWatcher.Path())
+		assert.Equal(t, p, state.documentBuffer.fileWatcher.Path())
 		assert.Equal(t, uint64(3), state.documentBuffer.cursor.position)
 		text := state.documentBuffer.textTree.String()
 		assert.Equal(t, "ab\ncd\nef\ngh", text)
@@ -22,15 +22,26 @@ type EditorState struct {
 	inputMode                 InputMode
 	prevInputMode             InputMode
 	documentBuffer            *BufferState
+	backgroundBuffers         []*BufferState // Loaded documents other than the current document.
+	paneLayout                *paneLayout
+	activePane                *paneLayout // Leaf of paneLayout that displays the current document.
 	clipboard                 *clipboard.C
-	fileWatcher               *file.Watcher
 	fileTimeline              *file.Timeline
+	undoHistoryCache          *undo.HistoryCache
+	recoveryStore             *file.RecoveryStore
 	menu                      *MenuState
 	task                      *TaskState
 	macroState                MacroState
 	customMenuItems           []menu.Item
 	dirPatternsToHide         []string
 	styles                    map[string]config.StyleConfig
+	themes                    []config.Theme // Sorted by name.
+	configThemeName           string
+	selectedThemeName         string // Set when the user selects a theme from the menu.
+	themeChangeCount          int
+	statusBar                 config.StatusBarConfig
+	statusBarShellCmd         statusBarShellCmdState
+	backgroundResultChan      chan func(*EditorState)
 	statusMsg                 StatusMsg
 	suspendScreenFunc         SuspendScreenFunc
 	quitFlag                  bool
@@ -43,17 +54,47 @@ func NewEditorState(screenWidth, screenHeight uint64, configRuleSet config.RuleS
 		documentBufferHeight = screenHeight - 1
 	}
 
-	buffer := &BufferState{
-		textTree: text.NewTree(),
-		cursor:   cursorState{},
-		selector: &selection.Selector{},
-		view: viewState{
-			textOrigin: 0,
-			x:          0,
-			y:          0,
-			width:      screenWidth,
-			height:     documentBufferHeight,
-		},
+	buffer := newBufferState(viewState{
+		textOrigin: 0,
+		x:          0,
+		y:          0,
+		width:      screenWidth,
+		height:     documentBufferHeight,
+	})
+
+	pane := &paneLayout{
+		pane:   &paneState{buffer: buffer},
+		width:  screenWidth,
+		height: documentBufferHeight,
+	}
+
+	return &EditorState{
+		screenWidth:          screenWidth,
+		screenHeight:         screenHeight,
+		configRuleSet:        configRuleSet,
+		documentBuffer:       buffer,
+		paneLayout:           pane,
+		activePane:           pane,
+		clipboard:            clipboard.New(),
+		fileTimeline:         file.NewTimeline(),
+		menu:                 &MenuState{},
+		customMenuItems:      nil,
+		dirPatternsToHide:    nil,
+		statusMsg:            StatusMsg{},
+		styles:               nil,
+		statusBarShellCmd:    statusBarShellCmdState{resultChan: make(chan func(*EditorState), 1)},
+		backgroundResultChan: make(chan func(*EditorState), 1),
+		suspendScreenFunc:    suspendScreenFunc,
+	}
+}
+
+// newBufferState returns an empty buffer with the default configuration.
+func newBufferState(view viewState) *BufferState {
+	return &BufferState{
+		textTree:       text.NewTree(),
+		cursor:         cursorState{},
+		selector:       &selection.Selector{},
+		view:           view,
 		search:         searchState{},
 		undoLog:        undo.NewLog(),
 		syntaxLanguage: syntax.LanguagePlaintext,
@@ -63,22 +104,10 @@ func NewEditorState(screenWidth, screenHeight uint64, configRuleSet config.RuleS
 		showSpaces:     config.DefaultShowSpaces,
 		showTabs:       config.DefaultShowTabs,
 		autoIndent:     config.DefaultAutoIndent,
-	}
-
-	return &EditorState{
-		screenWidth:       screenWidth,
-		screenHeight:      screenHeight,
-		configRuleSet:     configRuleSet,
-		documentBuffer:    buffer,
-		clipboard:         clipboard.New(),
-		fileWatcher:       file.NewEmptyWatcher(),
-		fileTimeline:      file.NewTimeline(),
-		menu:              &MenuState{},
-		customMenuItems:   nil,
-		dirPatternsToHide: nil,
-		statusMsg:         StatusMsg{},
-		styles:            nil,
-		suspendScreenFunc: suspendScreenFunc,
+		autoPair:       config.DefaultAutoPair,
+		autoPairs:      autoPairsFromConfig(config.DefaultAutoPairs),
+		fileFormat:     file.DefaultFormat,
+		fileWatcher:    file.NewEmptyWatcher(),
 	}
 }
 
@@ -114,6 +143,12 @@ func (s *EditorState) TaskResultChan() chan func(*EditorState) {
 	return s.task.resultChan
 }
 
+// BackgroundResultChan receives actions from goroutines that load data for a document without blocking user input,
+// like the version of the file in git HEAD.  The main event loop should execute each action once received.
+func (s *EditorState) BackgroundResultChan() chan func(*EditorState) {
+	return s.backgroundResultChan
+}
+
 func (s *EditorState) IsRecordingUserMacro() bool {
 	return s.macroState.isRecordingUserMacro
 }
@@ -126,12 +161,9 @@ func (s *EditorState) StatusMsg() StatusMsg {
 	return s.statusMsg
 }
 
-func (s *EditorState) Styles() map[string]config.StyleConfig {
-	return s.styles
-}
-
+// FileWatcher returns the watcher for the current document's file.
 func (s *EditorState) FileWatcher() *file.Watcher {
-	return s.fileWatcher
+	return s.documentBuffer.fileWatcher
 }
 
 func (s *EditorState) QuitFlag() bool {
@@ -152,9 +184,34 @@ type BufferState struct {
 	tabExpand               bool
 	showTabs                bool
 	showSpaces              bool
+	showIndentGuides        bool
 	autoIndent              bool
+	autoPair                bool
+	autoPairs               []autoPair
 	showLineNum             bool
+	lineNumMode             LineNumMode
+	highlightCursorLine     bool
+	rulers                  []uint64
 	lineWrapAllowCharBreaks bool
+	trimTrailingWhitespace  bool
+	trimTrailingBlankLines  bool
+	fileFormat              file.Format
+	fileFormatChanged       bool
+	readOnly                bool
+	pager                   *file.Pager // Set only while loading a large file.
+	largeFile               bool        // Opened in large file mode, so the text might not match the bytes in the file.
+	indentRulesOverride     syntax.IndentRules
+	commentSyntaxOverride   syntax.CommentSyntax
+	fileWatcher             *file.Watcher
+	recoverySnapshot        recoverySnapshotState
+	baseText                string // Text of the file at the last load or save, used to merge changes on disk.
+	hasBaseText             bool
+	gitChanges              *gitChangesState // Nil if the file isn't tracked in a git repository.
+	showBlame               bool
+	blame                   *blameState     // Nil until the blame is loaded.
+	blameView               *blameViewState // Set only for a buffer that displays a blame.
+	diffView                *diffViewState  // Set only for a buffer that displays a diff.
+	folds                   []foldRange     // Closed folds, which may be nested.
 }
 
 func (s *BufferState) TextTree() *text.Tree {
@@ -221,6 +278,67 @@ func (s *BufferState) ShowSpaces() bool {
 	return s.showSpaces
 }
 
+func (s *BufferState) ShowIndentGuides() bool {
+	return s.showIndentGuides
+}
+
+func (s *BufferState) HighlightCursorLine() bool {
+	return s.highlightCursorLine
+}
+
+// Rulers returns the columns to highlight, measured in cells from the start of each line.
+// The first column is one.
+func (s *BufferState) Rulers() []uint64 {
+	return s.rulers
+}
+
+// FileFormat returns the format used when saving the buffer to a file.
+func (s *BufferState) FileFormat() file.Format {
+	return s.fileFormat
+}
+
+// ReadOnly returns whether edits to the document are disabled.
+func (s *BufferState) ReadOnly() bool {
+	return s.readOnly
+}
+
+// LargeFileLoadedPercent returns the percentage of a large file that has been loaded into the buffer.
+// This is between 1 and 99 while loading a large file, and zero otherwise.
+func (s *BufferState) LargeFileLoadedPercent() int {
+	if s.pager == nil || s.pager.Done() {
+		return 0
+	}
+	// Round up so a partially loaded file never reports zero percent.
+	percent := int((s.pager.Offset()*100 + s.pager.Size() - 1) / s.pager.Size())
+	if percent > 99 {
+		percent = 99
+	}
+	return percent
+}
+
+// hasUnsavedChanges returns whether the document or its file format changed since the last load or save.
+func (s *BufferState) hasUnsavedChanges() bool {
+	return s.fileFormatChanged || s.undoLog.HasUnsavedChanges()
+}
+
+// IndentRules returns the auto-indent rules for the buffer.
+// These are determined by the syntax language, unless overridden by configuration.
+func (s *BufferState) IndentRules() syntax.IndentRules {
+	if len(s.indentRulesOverride.IndentAfter) > 0 || len(s.indentRulesOverride.DedentOn) > 0 {
+		return s.indentRulesOverride
+	}
+	return syntax.IndentRulesForLanguage(s.syntaxLanguage)
+}
+
+// CommentSyntax returns the comment syntax for the buffer.
+// This is determined by the syntax language, unless overridden by configuration.
+func (s *BufferState) CommentSyntax() syntax.CommentSyntax {
+	if s.commentSyntaxOverride.Prefix != "" {
+		return s.commentSyntaxOverride
+	}
+	return syntax.CommentSyntaxForLanguage(s.syntaxLanguage)
+}
+
 func (s *BufferState) LineNumMarginWidth() uint64 {
 	if !s.showLineNum {
 		return 0
@@ -247,8 +365,48 @@ func (s *BufferState) LineNumMarginWidth() uint64 {
 	return width
 }
 
+// LineNumMode controls how line numbers are displayed in the left margin.
+type LineNumMode int
+
+const (
+	LineNumModeAbsolute = LineNumMode(iota) // Number lines from the start of the document.
+	LineNumModeRelative                     // Number lines by their distance from the cursor line.
+	LineNumModeHybrid                       // Relative, except the cursor line shows its absolute number.
+)
+
+func lineNumModeFromConfig(mode string) LineNumMode {
+	switch mode {
+	case config.LineNumberModeRelative:
+		return LineNumModeRelative
+	case config.LineNumberModeHybrid:
+		return LineNumModeHybrid
+	default:
+		return LineNumModeAbsolute
+	}
+}
+
+// LineNumForDisplay returns the number displayed in the left margin for a line.
+// Absolute line numbers start from one.
+// Relative line numbers count each closed fold as a single line, matching counted cursor movements.
+func (s *BufferState) LineNumForDisplay(lineNum uint64) uint64 {
+	if s.lineNumMode == LineNumModeAbsolute {
+		return lineNum + 1
+	}
+
+	cursorLineNum := s.textTree.LineNumForPosition(s.cursor.position)
+	if lineNum > cursorLineNum {
+		return numLinesBetweenSkippingFolds(s, cursorLineNum, lineNum)
+	} else if lineNum < cursorLineNum {
+		return numLinesBetweenSkippingFolds(s, lineNum, cursorLineNum)
+	} else if s.lineNumMode == LineNumModeHybrid {
+		return lineNum + 1
+	} else {
+		return 0
+	}
+}
+
 func (s *BufferState) LineWrapConfig() segment.LineWrapConfig {
-	width := s.view.width - s.LineNumMarginWidth()
+	width := s.view.width - s.LineNumMarginWidth() - s.GitChangeMarginWidth()
 	tabSize := s.tabSize
 	gcWidthFunc := func(gc []rune, offsetInLine uint64) uint64 {
 		return cellwidth.GraphemeClusterWidth(gc, offsetInLine, tabSize)
@@ -0,0 +1,118 @@
+package state
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/aretext/aretext/config"
+	"github.com/aretext/aretext/shellcmd"
+)
+
+// StatusBarInfo describes the current document for segments of a status bar template.
+type StatusBarInfo struct {
+	Modified       bool   // Whether the document has unsaved changes.
+	LineNum        uint64 // Line of the cursor, starting from one.
+	Col            uint64 // Column of the cursor in characters, starting from one.
+	NumLines       uint64 // Number of lines in the document.
+	Language       string // Syntax language.
+	SelectedChars  uint64 // Number of characters selected in visual mode.
+	SelectedLines  uint64 // Number of lines with selected characters in visual mode.
+	ShellCmdOutput string // First line of output from the status bar shell command.
+}
+
+// statusBarShellCmdState tracks the shell command displayed in the status bar.
+// The command runs periodically in the background, without blocking user input.
+type statusBarShellCmdState struct {
+	output      string
+	lastRunTime time.Time
+	running     bool
+	resultChan  chan func(*EditorState)
+}
+
+// StatusBarConfig returns the layout of the status bar for the current document.
+func (s *EditorState) StatusBarConfig() config.StatusBarConfig {
+	return s.statusBar
+}
+
+// StatusBarInfo returns information about the current document displayed by the status bar template.
+func (s *EditorState) StatusBarInfo() StatusBarInfo {
+	buffer := s.documentBuffer
+	tree := buffer.textTree
+	cursorPos := buffer.cursor.position
+	lineNum := tree.LineNumForPosition(cursorPos)
+	info := StatusBarInfo{
+		Modified:       buffer.hasUnsavedChanges(),
+		LineNum:        lineNum + 1,
+		Col:            cursorPos - tree.LineStartPosition(lineNum) + 1,
+		NumLines:       tree.NumLines(),
+		Language:       string(buffer.syntaxLanguage),
+		ShellCmdOutput: s.statusBarShellCmd.output,
+	}
+
+	if s.inputMode == InputModeVisual {
+		region := buffer.SelectedRegion()
+		if region.EndPos > region.StartPos {
+			info.SelectedChars = region.EndPos - region.StartPos
+			info.SelectedLines = tree.LineNumForPosition(region.EndPos-1) - tree.LineNumForPosition(region.StartPos) + 1
+		}
+	}
+
+	return info
+}
+
+// StatusBarShellCmdResultChan receives the output of the status bar shell command.
+// The main event loop should execute each result action to update the displayed output.
+func (s *EditorState) StatusBarShellCmdResultChan() chan func(*EditorState) {
+	return s.statusBarShellCmd.resultChan
+}
+
+// RunStatusBarShellCmdIfDue runs the status bar shell command in the background
+// if the configured interval has elapsed since the last run.
+func RunStatusBarShellCmdIfDue(state *EditorState, now time.Time) {
+	shellCmd := state.statusBar.ShellCmd
+	interval := time.Duration(state.statusBar.ShellCmdInterval) * time.Second
+	cmdState := &state.statusBarShellCmd
+	if shellCmd == "" || cmdState.running || now.Sub(cmdState.lastRunTime) < interval {
+		return
+	}
+
+	cmdState.running = true
+	cmdState.lastRunTime = now
+	env := envVars(state) // Read-only copy of env vars is safe to pass to other goroutines.
+	resultChan := cmdState.resultChan
+	go func() {
+		// Abort the command if it doesn't complete before the next run.
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		defer cancel()
+		output, err := shellcmd.RunAndCaptureOutput(ctx, shellCmd, env)
+		resultChan <- func(state *EditorState) {
+			state.statusBarShellCmd.running = false
+			if state.statusBar.ShellCmd != shellCmd {
+				// The configuration changed while the command was running, so discard the output.
+				return
+			}
+			if err != nil {
+				log.Printf("Error running status bar shell command '%s': %v\n", shellCmd, err)
+				output = ""
+			}
+			state.statusBarShellCmd.output = firstLineOfShellCmdOutput(output)
+		}
+	}()
+}
+
+func firstLineOfShellCmdOutput(output string) string {
+	line, _, _ := strings.Cut(output, "\n")
+	return strings.TrimSpace(line)
+}
+
+// setStatusBarConfig updates the status bar layout when the current document changes.
+func setStatusBarConfig(state *EditorState, statusBar config.StatusBarConfig) {
+	if statusBar.ShellCmd != state.statusBar.ShellCmd {
+		// Run the new command on the next refresh, and hide output from the previous command.
+		state.statusBarShellCmd.output = ""
+		state.statusBarShellCmd.lastRunTime = time.Time{}
+	}
+	state.statusBar = statusBar
+}
@@ -0,0 +1,82 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/config"
+	"github.com/aretext/aretext/selection"
+	"github.com/aretext/aretext/syntax"
+	"github.com/aretext/aretext/text"
+)
+
+func TestStatusBarInfo(t *testing.T) {
+	textTree, err := text.NewTreeFromString("abc\ndef\nghi")
+	require.NoError(t, err)
+	state := NewEditorState(100, 100, nil, nil)
+	state.documentBuffer.textTree = textTree
+	setSyntaxAndRetokenize(state.documentBuffer, syntax.LanguageGo)
+
+	state.documentBuffer.cursor.position = 5
+	assert.Equal(t, StatusBarInfo{
+		LineNum:  2,
+		Col:      2,
+		NumLines: 3,
+		Language: "go",
+	}, state.StatusBarInfo())
+
+	ToggleVisualMode(state, selection.ModeChar)
+	state.documentBuffer.cursor.position = 9
+	info := state.StatusBarInfo()
+	assert.Equal(t, uint64(5), info.SelectedChars)
+	assert.Equal(t, uint64(2), info.SelectedLines)
+
+	SetInputMode(state, InputModeInsert)
+	InsertRune(state, 'x')
+	info = state.StatusBarInfo()
+	assert.True(t, info.Modified)
+	assert.Equal(t, uint64(0), info.SelectedChars)
+	assert.Equal(t, uint64(0), info.SelectedLines)
+}
+
+func TestRunStatusBarShellCmdIfDue(t *testing.T) {
+	setupShellCmdTest(t, func(state *EditorState, dir string) {
+		setStatusBarConfig(state, config.StatusBarConfig{
+			Right:            "{shellCmd}",
+			ShellCmd:         "printf 'hello\nworld'",
+			ShellCmdInterval: 5,
+		})
+
+		runAndApplyResult := func(now time.Time) {
+			RunStatusBarShellCmdIfDue(state, now)
+			select {
+			case action := <-state.StatusBarShellCmdResultChan():
+				action(state)
+			case <-time.After(5 * time.Second):
+				require.Fail(t, "Timed out")
+			}
+		}
+
+		now := time.Now()
+		runAndApplyResult(now)
+		assert.Equal(t, "hello", state.StatusBarInfo().ShellCmdOutput)
+
+		// The command doesn't run again until the interval elapses.
+		RunStatusBarShellCmdIfDue(state, now.Add(time.Second))
+		assert.False(t, state.statusBarShellCmd.running)
+
+		// Changing the command clears the output from the previous command.
+		setStatusBarConfig(state, config.StatusBarConfig{
+			Right:            "{shellCmd}",
+			ShellCmd:         "exit 1",
+			ShellCmdInterval: 5,
+		})
+		assert.Equal(t, "", state.StatusBarInfo().ShellCmdOutput)
+		runAndApplyResult(now.Add(2 * time.Second))
+		assert.Equal(t, "", state.StatusBarInfo().ShellCmdOutput)
+		assert.False(t, state.statusBarShellCmd.running)
+	})
+}
@@ -0,0 +1,117 @@
+package state
+
+import (
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/aretext/aretext/config"
+	"github.com/aretext/aretext/menu"
+)
+
+// SetThemes sets the themes that the user can select.
+// If two themes have the same name, the later theme replaces the earlier one.
+func SetThemes(state *EditorState, themes []config.Theme) {
+	themeMap := make(map[string]config.Theme, len(themes))
+	for _, theme := range themes {
+		themeMap[theme.Name] = theme
+	}
+
+	state.themes = make([]config.Theme, 0, len(themeMap))
+	for _, theme := range themeMap {
+		state.themes = append(state.themes, theme)
+	}
+	sort.Slice(state.themes, func(i, j int) bool {
+		return state.themes[i].Name < state.themes[j].Name
+	})
+	state.themeChangeCount++
+}
+
+// ThemeChangeCount is incremented whenever the theme changes without loading a document.
+// The editor uses this to detect when it needs to update the displayed styles.
+func (s *EditorState) ThemeChangeCount() int {
+	return s.themeChangeCount
+}
+
+// ThemeName returns the name of the current theme.
+// A theme selected from the menu takes precedence over the theme from configuration.
+func (s *EditorState) ThemeName() string {
+	if s.selectedThemeName != "" {
+		return s.selectedThemeName
+	}
+	return s.configThemeName
+}
+
+// Styles returns the styles from configuration, replaced by any styles from the current theme.
+func (s *EditorState) Styles() map[string]config.StyleConfig {
+	theme, ok := findTheme(s, s.ThemeName())
+	if !ok || len(theme.Styles) == 0 {
+		return s.styles
+	}
+
+	styles := make(map[string]config.StyleConfig, len(s.styles)+len(theme.Styles))
+	for k, v := range s.styles {
+		styles[k] = v
+	}
+	for k, v := range theme.Styles {
+		styles[k] = v
+	}
+	return styles
+}
+
+func findTheme(state *EditorState, name string) (config.Theme, bool) {
+	for _, theme := range state.themes {
+		if theme.Name == name {
+			return theme, true
+		}
+	}
+	return config.Theme{}, false
+}
+
+// setConfigTheme updates the theme from configuration when the current document changes.
+func setConfigTheme(state *EditorState, name string) {
+	if _, ok := findTheme(state, name); !ok && name != config.DefaultTheme {
+		log.Printf("Could not find theme '%s'\n", name)
+	}
+	state.configThemeName = name
+}
+
+// ShowThemeMenu displays a menu for switching the theme.
+func ShowThemeMenu(state *EditorState) {
+	currentThemeName := state.ThemeName()
+	items := make([]menu.Item, 0, len(state.themes))
+	for _, theme := range state.themes {
+		themeName := theme.Name
+		name := themeName
+		if themeName == currentThemeName {
+			name += " [current]"
+		}
+		items = append(items, menu.Item{
+			Name: name,
+			Action: func(state *EditorState) {
+				SetTheme(state, themeName)
+			},
+		})
+	}
+
+	ShowMenu(state, MenuStyleTheme, items)
+}
+
+// SetTheme switches to a theme for the rest of the session, replacing the theme from configuration.
+func SetTheme(state *EditorState, name string) {
+	if _, ok := findTheme(state, name); !ok {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  fmt.Sprintf("Could not find theme %q", name),
+		})
+		return
+	}
+
+	log.Printf("Switching to theme '%s'\n", name)
+	state.selectedThemeName = name
+	state.themeChangeCount++
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  fmt.Sprintf("Switched to theme %s", name),
+	})
+}
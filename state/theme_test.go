@@ -0,0 +1,83 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/config"
+)
+
+func TestThemeStyles(t *testing.T) {
+	state := NewEditorState(100, 100, nil, nil)
+	state.styles = map[string]config.StyleConfig{
+		config.StyleLineNum:      {Color: "olive"},
+		config.StyleTokenKeyword: {Color: "olive"},
+	}
+	SetThemes(state, []config.Theme{
+		{Name: "default", Styles: map[string]config.StyleConfig{}},
+		{Name: "dark", Styles: map[string]config.StyleConfig{config.StyleTokenKeyword: {Color: "purple"}}},
+		{Name: "light", Styles: map[string]config.StyleConfig{config.StyleSelection: {Reverse: true}}},
+		{Name: "dark", Styles: map[string]config.StyleConfig{config.StyleTokenKeyword: {Color: "fuchsia"}}},
+	})
+
+	// The default theme uses the styles from configuration.
+	setConfigTheme(state, "default")
+	assert.Equal(t, "default", state.ThemeName())
+	assert.Equal(t, state.styles, state.Styles())
+
+	// The theme from configuration replaces styles with the same name.
+	// The later theme with the same name replaces the earlier one.
+	setConfigTheme(state, "dark")
+	assert.Equal(t, map[string]config.StyleConfig{
+		config.StyleLineNum:      {Color: "olive"},
+		config.StyleTokenKeyword: {Color: "fuchsia"},
+	}, state.Styles())
+
+	// A missing theme uses the styles from configuration.
+	setConfigTheme(state, "missing")
+	assert.Equal(t, state.styles, state.Styles())
+
+	// A theme selected by the user takes precedence over the theme from configuration.
+	themeChangeCount := state.ThemeChangeCount()
+	SetTheme(state, "light")
+	assert.Equal(t, themeChangeCount+1, state.ThemeChangeCount())
+	assert.Equal(t, "light", state.ThemeName())
+	assert.Equal(t, map[string]config.StyleConfig{
+		config.StyleLineNum:      {Color: "olive"},
+		config.StyleTokenKeyword: {Color: "olive"},
+		config.StyleSelection:    {Reverse: true},
+	}, state.Styles())
+	setConfigTheme(state, "dark")
+	assert.Equal(t, "light", state.ThemeName())
+
+	SetTheme(state, "missing")
+	assert.Equal(t, StatusMsg{Style: StatusMsgStyleError, Text: `Could not find theme "missing"`}, state.StatusMsg())
+	assert.Equal(t, "light", state.ThemeName())
+}
+
+func TestShowThemeMenu(t *testing.T) {
+	state := NewEditorState(100, 100, nil, nil)
+	SetThemes(state, []config.Theme{
+		{Name: "light"},
+		{Name: "default"},
+		{Name: "dark"},
+	})
+	setConfigTheme(state, "default")
+
+	ShowThemeMenu(state)
+	assert.Equal(t, InputModeMenu, state.InputMode())
+	assert.Equal(t, MenuStyleTheme, state.Menu().Style())
+	results, _ := state.Menu().SearchResults()
+	require.Equal(t, 3, len(results))
+	assert.Equal(t, "dark", results[0].Name)
+	assert.Equal(t, "default [current]", results[1].Name)
+	assert.Equal(t, "light", results[2].Name)
+
+	MoveMenuSelection(state, 2)
+	ExecuteSelectedMenuItem(state)
+	assert.Equal(t, InputModeNormal, state.InputMode())
+	assert.Equal(t, "light", state.ThemeName())
+	assert.Equal(t, StatusMsg{Style: StatusMsgStyleSuccess, Text: "Switched to theme light"}, state.StatusMsg())
+}
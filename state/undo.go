@@ -1,10 +1,17 @@
 package state
 
 import (
+	"fmt"
 	"log"
 	"math"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/aretext/aretext/config"
+	"github.com/aretext/aretext/file"
 	"github.com/aretext/aretext/locate"
+	"github.com/aretext/aretext/menu"
 	"github.com/aretext/aretext/undo"
 )
 
@@ -15,37 +22,177 @@ func CheckpointUndoLog(state *EditorState) {
 
 // Undo returns the document to its state at the last undo checkpoint.
 func Undo(state *EditorState) {
-	ops := state.documentBuffer.undoLog.UndoToLastCheckpoint()
-	if len(ops) == 0 {
+	if abortIfReadOnly(state) {
 		return
 	}
 
-	minPos := uint64(math.MaxUint64)
-	for _, op := range ops {
-		log.Printf("Undo operation: %#v\n", op)
-		if err := applyOpFromUndoLog(state, op); err != nil {
-			log.Printf("Could not apply undo op %v: %v\n", op, err)
-			continue
-		}
-		if pos := op.Position(); pos < minPos {
-			minPos = pos
-		}
-	}
-	MoveCursor(state, locateCursorAfterUndoOrRedo(minPos))
+	ops := state.documentBuffer.undoLog.UndoToLastCheckpoint()
+	applyOpsFromUndoLog(state, ops, "Undo")
 }
 
 // Redo reverses the last undo operation.
 func Redo(state *EditorState) {
+	if abortIfReadOnly(state) {
+		return
+	}
+
 	ops := state.documentBuffer.undoLog.RedoToNextCheckpoint()
+	applyOpsFromUndoLog(state, ops, "Redo")
+}
+
+// MoveToOlderUndoState returns the document to the state created before the current state.
+// Unlike undo, this can move to a state on a different branch of the undo history.
+func MoveToOlderUndoState(state *EditorState) {
+	moveInUndoHistory(state, func(l *undo.Log) []undo.Op { return l.MoveToOlderState() })
+}
+
+// MoveToNewerUndoState moves the document to the state created after the current state.
+// Unlike redo, this can move to a state on a different branch of the undo history.
+func MoveToNewerUndoState(state *EditorState) {
+	moveInUndoHistory(state, func(l *undo.Log) []undo.Op { return l.MoveToNewerState() })
+}
+
+// MoveUndoStateEarlier returns the document to its state from the specified duration before the current state.
+func MoveUndoStateEarlier(state *EditorState, d time.Duration) {
+	moveInUndoHistory(state, func(l *undo.Log) []undo.Op { return l.MoveEarlier(d) })
+}
+
+// MoveUndoStateLater moves the document to its state from the specified duration after the current state.
+func MoveUndoStateLater(state *EditorState, d time.Duration) {
+	moveInUndoHistory(state, func(l *undo.Log) []undo.Op { return l.MoveLater(d) })
+}
+
+// MoveUndoStateEarlierByArg is like MoveUndoStateEarlier, but parses a duration entered by the user, like "10m" or "1h30m".
+func MoveUndoStateEarlierByArg(state *EditorState, arg string) {
+	if d, ok := parseUndoDurationArg(state, "earlier", arg); ok {
+		MoveUndoStateEarlier(state, d)
+	}
+}
+
+// MoveUndoStateLaterByArg is like MoveUndoStateLater, but parses a duration entered by the user, like "10m" or "1h30m".
+func MoveUndoStateLaterByArg(state *EditorState, arg string) {
+	if d, ok := parseUndoDurationArg(state, "later", arg); ok {
+		MoveUndoStateLater(state, d)
+	}
+}
+
+func parseUndoDurationArg(state *EditorState, cmdName string, arg string) (time.Duration, bool) {
+	d, err := time.ParseDuration(arg)
+	if err != nil || d <= 0 {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  fmt.Sprintf("Expected a duration like \"%s 10m\" or \"%s 1h30m\"", cmdName, cmdName),
+		})
+		return 0, false
+	}
+	return d, true
+}
+
+func moveInUndoHistory(state *EditorState, f func(*undo.Log) []undo.Op) {
+	if abortIfReadOnly(state) {
+		return
+	}
+
+	undoLog := state.documentBuffer.undoLog
+	prevSeq := undoLog.CurrentSeq()
+	ops := f(undoLog)
+	applyOpsFromUndoLog(state, ops, "Undo history")
+
+	seq := undoLog.CurrentSeq()
+	if seq == prevSeq {
+		SetStatusMsg(state, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  "No other change in the undo history",
+		})
+		return
+	}
+
+	SetStatusMsg(state, StatusMsg{
+		Style: StatusMsgStyleSuccess,
+		Text:  undoStateStatusText(seq, undoLog.NumStates()),
+	})
+}
+
+func undoStateStatusText(seq int, numStates int) string {
+	if seq == 0 {
+		return "Returned to the original document"
+	}
+	return fmt.Sprintf("Moved to change %d of %d", seq, numStates-1)
+}
+
+// ShowUndoHistoryMenu displays a menu of every state in the undo history, including states on other branches.
+// Selecting a menu item moves the document to that state.
+func ShowUndoHistoryMenu(state *EditorState) {
+	undoLog := state.documentBuffer.undoLog
+	undoStates := undoLog.States()
+	seqWidth := len(strconv.Itoa(len(undoStates) - 1))
+	items := make([]menu.Item, 0, len(undoStates))
+	for _, s := range undoStates {
+		seq := s.Seq // reference seq in this iteration of the loop
+		items = append(items, menu.Item{
+			Name: undoStateMenuItemName(s, seqWidth),
+			Action: func(state *EditorState) {
+				moveInUndoHistory(state, func(l *undo.Log) []undo.Op { return l.MoveToState(seq) })
+			},
+		})
+	}
+
+	ShowMenu(state, MenuStyleUndoHistory, items)
+
+	// The menu shows items ordered by name, which starts with the sequence number,
+	// so select the current state.
+	state.menu.selectedResultIdx = undoLog.CurrentSeq()
+}
+
+func undoStateMenuItemName(s undo.State, seqWidth int) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "#%*d  %s  %s", seqWidth, s.Seq, s.Time.Format("15:04:05"), undoStatePreview(s))
+	if s.Current {
+		sb.WriteString(" [current]")
+	}
+	if s.Saved {
+		sb.WriteString(" [saved]")
+	}
+	return sb.String()
+}
+
+// undoStatePreview summarizes the changes from the previous state.
+func undoStatePreview(s undo.State) string {
+	if s.Seq == 0 {
+		return "original document"
+	}
+
+	if len(s.Ops) == 0 {
+		return "no changes"
+	}
+
+	const maxPreviewLen = 24
+	op := s.Ops[0]
+	verb, text := "insert", op.TextToInsert()
+	if text == "" {
+		verb, text = "delete", op.TextToDelete()
+	}
+	if runes := []rune(text); len(runes) > maxPreviewLen {
+		text = string(runes[:maxPreviewLen]) + "..."
+	}
+
+	preview := fmt.Sprintf("%s %q", verb, text)
+	if len(s.Ops) > 1 {
+		preview += fmt.Sprintf(" and %d more", len(s.Ops)-1)
+	}
+	return preview
+}
+
+func applyOpsFromUndoLog(state *EditorState, ops []undo.Op, logPrefix string) {
 	if len(ops) == 0 {
 		return
 	}
 
 	minPos := uint64(math.MaxUint64)
 	for _, op := range ops {
-		log.Printf("Redo operation: %#v\n", op)
+		log.Printf("%s operation: %#v\n", logPrefix, op)
 		if err := applyOpFromUndoLog(state, op); err != nil {
-			log.Printf("Could not apply redo op %v: %v\n", op, err)
+			log.Printf("Could not apply %s op %v: %v\n", strings.ToLower(logPrefix), op, err)
 			continue
 		}
 		if pos := op.Position(); pos < minPos {
@@ -75,3 +222,65 @@ func locateCursorAfterUndoOrRedo(minPos uint64) Locator {
 		return locate.ClosestCharOnLine(params.TextTree, minPos)
 	}
 }
+
+// SetUndoHistoryCache sets the cache used to persist undo history across sessions.
+// If the cache is nil, undo history is cleared whenever a document is loaded.
+func SetUndoHistoryCache(state *EditorState, cache *undo.HistoryCache) {
+	state.undoHistoryCache = cache
+}
+
+// persistUndoLog saves the undo log for a buffer to the undo history cache.
+// This should be called before the document is unloaded.
+func persistUndoLog(state *EditorState, buffer *BufferState) {
+	cache := state.undoHistoryCache
+	path := buffer.fileWatcher.Path()
+	checksum := buffer.fileWatcher.Checksum()
+	undoLog := buffer.undoLog
+	if cache == nil || path == "" || checksum == "" || buffer.fileFormat.Hex || undoLog.NumStates() <= 1 {
+		// Without a checksum, there's no way to tell whether the history applies to the file when it's reopened.
+		// Hex dumps are excluded because the history would not apply to the same file opened as text.
+		return
+	}
+
+	cfg := state.configRuleSet.ConfigForPath(path)
+	if cfg.UndoHistoryMaxSize == 0 {
+		return
+	}
+
+	maxAge := undoHistoryMaxAge(cfg)
+	if err := cache.Prune(maxAge); err != nil {
+		log.Printf("Error pruning undo history cache: %v\n", err)
+	}
+
+	if err := cache.Save(path, checksum, undoLog, cfg.UndoHistoryMaxSize<<10); err != nil {
+		log.Printf("Error saving undo history for '%s': %v\n", path, err)
+		return
+	}
+
+	log.Printf("Saved undo history for '%s'\n", path)
+}
+
+// restoreUndoLog returns the undo log for a file from the undo history cache.
+// If the file has changed since the history was saved, this returns a new, empty log.
+func restoreUndoLog(state *EditorState, path string, watcher *file.Watcher, format file.Format, cfg config.Config) *undo.Log {
+	cache := state.undoHistoryCache
+	checksum := watcher.Checksum()
+	if cache == nil || checksum == "" || format.Hex || cfg.UndoHistoryMaxSize == 0 {
+		return undo.NewLog()
+	}
+
+	undoLog, err := cache.Load(path, checksum, undoHistoryMaxAge(cfg))
+	if err != nil {
+		log.Printf("Error loading undo history for '%s': %v\n", path, err)
+		return undo.NewLog()
+	} else if undoLog == nil {
+		return undo.NewLog()
+	}
+
+	log.Printf("Restored undo history for '%s' with %d states\n", path, undoLog.NumStates())
+	return undoLog
+}
+
+func undoHistoryMaxAge(cfg config.Config) time.Duration {
+	return time.Duration(cfg.UndoHistoryMaxAge) * 24 * time.Hour
+}
@@ -1,13 +1,17 @@
 package state
 
 import (
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/aretext/aretext/clipboard"
+	"github.com/aretext/aretext/config"
 	"github.com/aretext/aretext/locate"
 	"github.com/aretext/aretext/syntax"
+	"github.com/aretext/aretext/undo"
 )
 
 func TestUndoAndRedo(t *testing.T) {
@@ -104,3 +108,166 @@ func TestUndoMultiByteUnicodeWithSyntaxHighlighting(t *testing.T) {
 	Redo(state)
 	assert.Equal(t, "丂丄丅丆丏 ¢ह€한", state.documentBuffer.textTree.String())
 }
+
+func TestMoveToOlderAndNewerUndoState(t *testing.T) {
+	state := NewEditorState(100, 100, nil, nil)
+
+	// Make an edit, undo it, then make a different edit to create a branch.
+	InsertRune(state, 'a')
+	CheckpointUndoLog(state)
+	Undo(state)
+	InsertRune(state, 'b')
+	CheckpointUndoLog(state)
+	assert.Equal(t, "b", state.documentBuffer.textTree.String())
+
+	// Undo can only return to the original document, but the older state is on the other branch.
+	MoveToOlderUndoState(state)
+	assert.Equal(t, "a", state.documentBuffer.textTree.String())
+	assert.Equal(t, StatusMsg{Style: StatusMsgStyleSuccess, Text: "Moved to change 1 of 2"}, state.StatusMsg())
+
+	MoveToOlderUndoState(state)
+	assert.Equal(t, "", state.documentBuffer.textTree.String())
+	assert.Equal(t, StatusMsg{Style: StatusMsgStyleSuccess, Text: "Returned to the original document"}, state.StatusMsg())
+
+	MoveToOlderUndoState(state)
+	assert.Equal(t, "", state.documentBuffer.textTree.String())
+	assert.Equal(t, StatusMsg{Style: StatusMsgStyleError, Text: "No other change in the undo history"}, state.StatusMsg())
+
+	MoveToNewerUndoState(state)
+	MoveToNewerUndoState(state)
+	assert.Equal(t, "b", state.documentBuffer.textTree.String())
+	assert.Equal(t, StatusMsg{Style: StatusMsgStyleSuccess, Text: "Moved to change 2 of 2"}, state.StatusMsg())
+}
+
+func TestMoveUndoStateEarlierAndLaterByArg(t *testing.T) {
+	state := NewEditorState(100, 100, nil, nil)
+	InsertRune(state, 'a')
+	CheckpointUndoLog(state)
+
+	MoveUndoStateEarlierByArg(state, "1h30m")
+	assert.Equal(t, "", state.documentBuffer.textTree.String())
+	MoveUndoStateLaterByArg(state, "10m")
+	assert.Equal(t, "a", state.documentBuffer.textTree.String())
+
+	for _, arg := range []string{"", "10", "-5m", "abc"} {
+		MoveUndoStateEarlierByArg(state, arg)
+		assert.Equal(t, "a", state.documentBuffer.textTree.String())
+		assert.Equal(t, StatusMsg{
+			Style: StatusMsgStyleError,
+			Text:  `Expected a duration like "earlier 10m" or "earlier 1h30m"`,
+		}, state.StatusMsg())
+	}
+}
+
+func TestShowUndoHistoryMenu(t *testing.T) {
+	state := NewEditorState(100, 100, nil, nil)
+	InsertRune(state, 'a')
+	CheckpointUndoLog(state)
+	Undo(state)
+	InsertRune(state, 'b')
+	InsertRune(state, 'c')
+	CheckpointUndoLog(state)
+	Undo(state)
+
+	ShowUndoHistoryMenu(state)
+	assert.True(t, state.Menu().Visible())
+	assert.Equal(t, MenuStyleUndoHistory, state.Menu().Style())
+
+	results, selectedIdx := state.Menu().SearchResults()
+	require.Equal(t, 3, len(results))
+	assert.Equal(t, 0, selectedIdx)
+	assert.Contains(t, results[0].Name, "original document [current] [saved]")
+	assert.Contains(t, results[1].Name, `insert "a"`)
+	assert.Contains(t, results[2].Name, `insert "b" and 1 more`)
+
+	// Select the state on the other branch.
+	MoveMenuSelection(state, 1)
+	ExecuteSelectedMenuItem(state)
+	assert.False(t, state.Menu().Visible())
+	assert.Equal(t, "a", state.documentBuffer.textTree.String())
+}
+
+func TestRestoreUndoHistoryAfterReopen(t *testing.T) {
+	path, cleanup := createTestFile(t, "abc")
+	defer cleanup()
+	cache := undo.NewHistoryCache(t.TempDir())
+
+	// Edit and save the document, then make another edit and quit without saving.
+	state := NewEditorState(100, 100, nil, nil)
+	SetUndoHistoryCache(state, cache)
+	LoadDocument(state, path, true, startOfDocLocator)
+	InsertRune(state, 'x')
+	CheckpointUndoLog(state)
+	SaveDocument(state)
+	InsertRune(state, 'y')
+	CheckpointUndoLog(state)
+	Quit(state)
+
+	// Reopen the document, which has the contents from the last save.
+	state = NewEditorState(100, 100, nil, nil)
+	SetUndoHistoryCache(state, cache)
+	LoadDocument(state, path, true, startOfDocLocator)
+	defer state.documentBuffer.fileWatcher.Stop()
+	assert.Equal(t, "xabc", state.documentBuffer.textTree.String())
+	assert.False(t, state.documentBuffer.hasUnsavedChanges())
+
+	// Redo restores the unsaved edit, and undo returns to the original document.
+	Redo(state)
+	assert.Equal(t, "xyabc", state.documentBuffer.textTree.String())
+	Undo(state)
+	Undo(state)
+	assert.Equal(t, "abc", state.documentBuffer.textTree.String())
+}
+
+func TestRestoreUndoHistoryFileChanged(t *testing.T) {
+	path, cleanup := createTestFile(t, "abc")
+	defer cleanup()
+	cache := undo.NewHistoryCache(t.TempDir())
+
+	state := NewEditorState(100, 100, nil, nil)
+	SetUndoHistoryCache(state, cache)
+	LoadDocument(state, path, true, startOfDocLocator)
+	InsertRune(state, 'x')
+	CheckpointUndoLog(state)
+	SaveDocument(state)
+	Quit(state)
+
+	// Modify the file outside the editor, so the history no longer applies.
+	err := os.WriteFile(path, []byte("xabcd"), 0644)
+	require.NoError(t, err)
+
+	state = NewEditorState(100, 100, nil, nil)
+	SetUndoHistoryCache(state, cache)
+	LoadDocument(state, path, true, startOfDocLocator)
+	defer state.documentBuffer.fileWatcher.Stop()
+	assert.Equal(t, 1, state.documentBuffer.undoLog.NumStates())
+	Undo(state)
+	assert.Equal(t, "xabcd", state.documentBuffer.textTree.String())
+}
+
+func TestRestoreUndoHistoryDisabled(t *testing.T) {
+	path, cleanup := createTestFile(t, "abc")
+	defer cleanup()
+	cache := undo.NewHistoryCache(t.TempDir())
+	configRuleSet := config.RuleSet{
+		{
+			Name:    "undoHistoryMaxSize",
+			Pattern: "**",
+			Config:  map[string]any{"undoHistoryMaxSize": 0},
+		},
+	}
+
+	state := NewEditorState(100, 100, configRuleSet, nil)
+	SetUndoHistoryCache(state, cache)
+	LoadDocument(state, path, true, startOfDocLocator)
+	InsertRune(state, 'x')
+	CheckpointUndoLog(state)
+	SaveDocument(state)
+	Quit(state)
+
+	state = NewEditorState(100, 100, configRuleSet, nil)
+	SetUndoHistoryCache(state, cache)
+	LoadDocument(state, path, true, startOfDocLocator)
+	defer state.documentBuffer.fileWatcher.Stop()
+	assert.Equal(t, 1, state.documentBuffer.undoLog.NumStates())
+}
@@ -12,59 +12,54 @@ const (
 	ScrollDirectionBackward
 )
 
-// ResizeView resizes the view to the specified width and height.
+// ResizeView resizes the view to the specified width and height, dividing the screen between panes.
 func ResizeView(state *EditorState, width, height uint64) {
 	state.screenWidth = width
 	state.screenHeight = height
-	state.documentBuffer.view.x = 0
-	state.documentBuffer.view.y = 0
-	state.documentBuffer.view.width = state.screenWidth
-	state.documentBuffer.view.height = 0
-	if height > 0 {
-		// Leave one line for the status bar at the bottom.
-		state.documentBuffer.view.height = height - 1
-	}
+	layoutPanes(state)
 }
 
 // ScrollViewToCursor moves the view origin so that the cursor is visible.
+// This opens any closed folds that hide the cursor.
 func ScrollViewToCursor(state *EditorState) {
 	buffer := state.documentBuffer
+	unfoldAtPosition(buffer, buffer.cursor.position)
 	scrollViewToPosition(buffer, buffer.cursor.position)
 }
 
+// scrollViewToPosition moves the view origin so that a position is visible.
+// If the position is hidden by a closed fold, this scrolls to the line before the fold instead.
 func scrollViewToPosition(buffer *BufferState, pos uint64) {
 	buffer.view.textOrigin = locate.ViewOriginAfterScroll(
-		pos,
+		visiblePosition(buffer, pos),
 		buffer.textTree,
 		buffer.LineWrapConfig(),
-		buffer.view.textOrigin,
+		buffer.FoldedRanges(),
+		visiblePosition(buffer, buffer.view.textOrigin),
 		buffer.view.height)
 }
 
 // ScrollViewByNumLines moves the view origin up or down by the specified number of lines.
+// Each closed fold counts as a single line.
 func ScrollViewByNumLines(state *EditorState, direction ScrollDirection, numLines uint64) {
 	buffer := state.documentBuffer
-	lineNum := buffer.textTree.LineNumForPosition(buffer.view.textOrigin)
+	lineNum := buffer.textTree.LineNumForPosition(visiblePosition(buffer, buffer.view.textOrigin))
 	if direction == ScrollDirectionForward {
-		lineNum += numLines
-	} else if lineNum >= numLines {
-		lineNum -= numLines
+		lineNum = lineNumBelowSkippingFolds(buffer, lineNum, numLines)
 	} else {
-		lineNum = 0
+		lineNum = lineNumAboveSkippingFolds(buffer, lineNum, numLines)
 	}
 
-	lineNum = locate.ClosestValidLineNum(buffer.textTree, lineNum)
-
 	// When scrolling to the end of the file, we want most of the last lines to remain visible.
 	// To achieve this, set the view origin (viewHeight - scrollMargin) lines above
 	// the last line.  This will leave a few blank lines past the end of the document
 	// (the scroll margin) for consistency with ScrollToCursor.
 	lastLineNum := locate.ClosestValidLineNum(buffer.textTree, buffer.textTree.NumLines())
-	if lastLineNum-lineNum < buffer.view.height {
-		if lastLineNum+locate.ScrollMargin+1 > buffer.view.height {
-			lineNum = lastLineNum + locate.ScrollMargin + 1 - buffer.view.height
+	if numLinesBetweenSkippingFolds(buffer, lineNum, lastLineNum) < buffer.view.height {
+		if buffer.view.height > locate.ScrollMargin {
+			lineNum = lineNumAboveSkippingFolds(buffer, lastLineNum, buffer.view.height-locate.ScrollMargin-1)
 		} else {
-			lineNum = 0
+			lineNum = lastLineNum + locate.ScrollMargin + 1 - buffer.view.height
 		}
 	}
 
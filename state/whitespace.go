@@ -0,0 +1,97 @@
+package state
+
+import (
+	"io"
+
+	"github.com/aretext/aretext/locate"
+)
+
+// TrimTrailingWhitespace removes spaces and tabs from the end of every line in the document.
+func TrimTrailingWhitespace(state *EditorState) {
+	preserveCursorAndViewLine(state, func() {
+		tree := state.documentBuffer.textTree
+		for lineNum := tree.NumLines(); lineNum > 0; lineNum-- {
+			// Iterate backwards so edits don't change the positions of lines we haven't processed yet.
+			startOfLinePos := locate.StartOfLineNum(tree, lineNum-1)
+			endOfLinePos := locate.NextLineBoundary(tree, true, startOfLinePos)
+			startOfTrailingWhitespacePos := endOfLinePos
+			reader := tree.ReverseReaderAtPosition(endOfLinePos)
+			for startOfTrailingWhitespacePos > startOfLinePos {
+				r, _, err := reader.ReadRune()
+				if err == io.EOF {
+					break
+				} else if err != nil {
+					panic(err) // should never happen because text should be valid UTF-8
+				}
+
+				if r != ' ' && r != '\t' {
+					break
+				}
+				startOfTrailingWhitespacePos--
+			}
+
+			if startOfTrailingWhitespacePos < endOfLinePos {
+				numToDelete := endOfLinePos - startOfTrailingWhitespacePos
+				deleteRunes(state, startOfTrailingWhitespacePos, numToDelete, true)
+			}
+		}
+	})
+}
+
+// TrimTrailingBlankLines removes empty or whitespace-only lines from the end of the document.
+func TrimTrailingBlankLines(state *EditorState) {
+	preserveCursorAndViewLine(state, func() {
+		tree := state.documentBuffer.textTree
+		n := tree.NumChars()
+		reader := tree.ReverseReaderAtPosition(n)
+		pos, deletePos := n, n
+		for pos > 0 {
+			r, _, err := reader.ReadRune()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				panic(err) // should never happen because text should be valid UTF-8
+			}
+
+			if r == '\n' {
+				deletePos = pos - 1
+			} else if r != ' ' && r != '\t' && r != '\r' {
+				break
+			}
+			pos--
+		}
+
+		if deletePos < n {
+			deleteRunes(state, deletePos, n-deletePos, true)
+		}
+	})
+}
+
+// preserveCursorAndViewLine keeps the cursor and view on the same line and column
+// after applying edits that may have changed positions in the document.
+func preserveCursorAndViewLine(state *EditorState, f func()) {
+	buffer := state.documentBuffer
+	cursorLineNum, cursorCol := locate.PosToLineNumAndCol(buffer.textTree, buffer.cursor.position)
+	textOriginLineNum := buffer.textTree.LineNumForPosition(buffer.view.textOrigin)
+
+	f()
+
+	lastLineNum := uint64(0)
+	if numLines := buffer.textTree.NumLines(); numLines > 0 {
+		lastLineNum = numLines - 1
+	}
+
+	if cursorLineNum > lastLineNum {
+		cursorLineNum = lastLineNum
+	}
+
+	if textOriginLineNum > lastLineNum {
+		textOriginLineNum = lastLineNum
+	}
+
+	buffer.cursor = cursorState{
+		position: locate.LineNumAndColToPos(buffer.textTree, cursorLineNum, cursorCol),
+	}
+	buffer.view.textOrigin = buffer.textTree.LineStartPosition(textOriginLineNum)
+	ScrollViewToCursor(state)
+}
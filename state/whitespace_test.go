@@ -0,0 +1,148 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/aretext/aretext/text"
+)
+
+func TestTrimTrailingWhitespace(t *testing.T) {
+	testCases := []struct {
+		name           string
+		inputString    string
+		cursorPos      uint64
+		expectedText   string
+		expectedCursor uint64
+	}{
+		{
+			name:           "empty",
+			inputString:    "",
+			expectedText:   "",
+			expectedCursor: 0,
+		},
+		{
+			name:           "no trailing whitespace",
+			inputString:    "abc\n  def",
+			cursorPos:      6,
+			expectedText:   "abc\n  def",
+			expectedCursor: 6,
+		},
+		{
+			name:           "trailing spaces and tabs",
+			inputString:    "abc  \n\t\n  def \t\nghi",
+			cursorPos:      16,
+			expectedText:   "abc\n\n  def\nghi",
+			expectedCursor: 11,
+		},
+		{
+			name:           "cursor in trailing whitespace",
+			inputString:    "abc   \ndef",
+			cursorPos:      4,
+			expectedText:   "abc\ndef",
+			expectedCursor: 2,
+		},
+		{
+			name:           "cursor on same line before trailing whitespace",
+			inputString:    "a  \nbcd   ",
+			cursorPos:      5,
+			expectedText:   "a\nbcd",
+			expectedCursor: 3,
+		},
+		{
+			name:           "carriage return line endings",
+			inputString:    "abc \r\ndef",
+			cursorPos:      0,
+			expectedText:   "abc\r\ndef",
+			expectedCursor: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			textTree, err := text.NewTreeFromString(tc.inputString)
+			require.NoError(t, err)
+			state := NewEditorState(100, 100, nil, nil)
+			state.documentBuffer.textTree = textTree
+			state.documentBuffer.cursor = cursorState{position: tc.cursorPos}
+			TrimTrailingWhitespace(state)
+			assert.Equal(t, tc.expectedText, textTree.String())
+			assert.Equal(t, tc.expectedCursor, state.documentBuffer.cursor.position)
+		})
+	}
+}
+
+func TestTrimTrailingBlankLines(t *testing.T) {
+	testCases := []struct {
+		name           string
+		inputString    string
+		cursorPos      uint64
+		expectedText   string
+		expectedCursor uint64
+	}{
+		{
+			name:           "empty",
+			inputString:    "",
+			expectedText:   "",
+			expectedCursor: 0,
+		},
+		{
+			name:           "no trailing blank lines",
+			inputString:    "abc\ndef",
+			expectedText:   "abc\ndef",
+			expectedCursor: 0,
+		},
+		{
+			name:           "trailing empty lines",
+			inputString:    "abc\n\n\n",
+			expectedText:   "abc",
+			expectedCursor: 0,
+		},
+		{
+			name:           "trailing whitespace-only lines",
+			inputString:    "abc\n  \n\t\n ",
+			expectedText:   "abc",
+			expectedCursor: 0,
+		},
+		{
+			name:           "preserve trailing whitespace on last non-blank line",
+			inputString:    "abc  \n\n",
+			expectedText:   "abc  ",
+			expectedCursor: 0,
+		},
+		{
+			name:           "blank lines in middle of document",
+			inputString:    "abc\n\n\ndef",
+			expectedText:   "abc\n\n\ndef",
+			expectedCursor: 0,
+		},
+		{
+			name:           "only blank lines",
+			inputString:    "\n  \n\n",
+			expectedText:   "",
+			expectedCursor: 0,
+		},
+		{
+			name:           "cursor on trailing blank line",
+			inputString:    "abc\n\n\n",
+			cursorPos:      5,
+			expectedText:   "abc",
+			expectedCursor: 0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			textTree, err := text.NewTreeFromString(tc.inputString)
+			require.NoError(t, err)
+			state := NewEditorState(100, 100, nil, nil)
+			state.documentBuffer.textTree = textTree
+			state.documentBuffer.cursor = cursorState{position: tc.cursorPos}
+			TrimTrailingBlankLines(state)
+			assert.Equal(t, tc.expectedText, textTree.String())
+			assert.Equal(t, tc.expectedCursor, state.documentBuffer.cursor.position)
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package languages
+
+import (
+	"github.com/aretext/aretext/syntax/parser"
+)
+
+// DiffParseFunc parses a unified diff.
+func DiffParseFunc() parser.Func {
+	// File headers must be checked before added/removed lines, since they also start with '+' or '-'.
+	parseFileHeaderLine := consumeString("+++ ").
+		Or(consumeString("--- ")).
+		Or(consumeString("diff ")).
+		ThenMaybe(consumeToNextLineFeed).
+		Map(recognizeToken(parser.TokenRoleKeyword))
+
+	parseHunkHeaderLine := consumeString("@@").
+		ThenMaybe(consumeToNextLineFeed).
+		Map(recognizeToken(parser.TokenRoleOperator))
+
+	parseAddedLine := consumeString("+").
+		ThenMaybe(consumeToNextLineFeed).
+		Map(recognizeToken(parser.TokenRoleDiffAdded))
+
+	parseRemovedLine := consumeString("-").
+		ThenMaybe(consumeToNextLineFeed).
+		Map(recognizeToken(parser.TokenRoleDiffRemoved))
+
+	// For example, "\ No newline at end of file"
+	parseNoteLine := consumeString("\\").
+		ThenMaybe(consumeToNextLineFeed).
+		Map(recognizeToken(parser.TokenRoleComment))
+
+	return parseFileHeaderLine.
+		Or(parseHunkHeaderLine).
+		Or(parseAddedLine).
+		Or(parseRemovedLine).
+		Or(parseNoteLine).
+		Or(consumeToNextLineFeed)
+}
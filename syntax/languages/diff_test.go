@@ -0,0 +1,60 @@
+package languages
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/aretext/aretext/syntax/parser"
+)
+
+func TestDiffParseFunc(t *testing.T) {
+	testCases := []struct {
+		name     string
+		text     string
+		expected []TokenWithText
+	}{
+		{
+			name:     "empty",
+			text:     "",
+			expected: []TokenWithText{},
+		},
+		{
+			name:     "context line",
+			text:     " unchanged line",
+			expected: []TokenWithText{},
+		},
+		{
+			name: "file headers",
+			text: "--- a/test.txt\n+++ b/test.txt\n",
+			expected: []TokenWithText{
+				{Role: parser.TokenRoleKeyword, Text: "--- a/test.txt\n"},
+				{Role: parser.TokenRoleKeyword, Text: "+++ b/test.txt\n"},
+			},
+		},
+		{
+			name: "hunk",
+			text: "@@ -1,2 +1,2 @@\n abc\n-def\n+xyz\n\\ No newline at end of file",
+			expected: []TokenWithText{
+				{Role: parser.TokenRoleOperator, Text: "@@ -1,2 +1,2 @@\n"},
+				{Role: parser.TokenRoleDiffRemoved, Text: "-def\n"},
+				{Role: parser.TokenRoleDiffAdded, Text: "+xyz\n"},
+				{Role: parser.TokenRoleComment, Text: "\\ No newline at end of file"},
+			},
+		},
+		{
+			name: "plus and minus within a line",
+			text: " a + b - c\n+",
+			expected: []TokenWithText{
+				{Role: parser.TokenRoleDiffAdded, Text: "+"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tokens := ParseTokensWithText(DiffParseFunc(), tc.text)
+			assert.Equal(t, tc.expected, tokens)
+		})
+	}
+}
@@ -11,6 +11,8 @@ const (
 	TokenRoleNumber
 	TokenRoleString
 	TokenRoleComment
+	TokenRoleDiffAdded
+	TokenRoleDiffRemoved
 )
 
 const (
@@ -24,11 +24,46 @@ const (
 	LanguageProtobuf  = Language("protobuf")
 	LanguageTodoTxt   = Language("todotxt")
 	LanguageMarkdown  = Language("markdown")
+	LanguageDiff      = Language("diff")
 )
 
 // languageToParseFunc maps each language to its parse func.
 var languageToParseFunc map[Language]parser.Func
 
+// CommentSyntax describes how to comment out a line in a language.
+type CommentSyntax struct {
+	// Prefix is inserted at the start of the commented line (for example, "//").
+	Prefix string
+
+	// Suffix is inserted at the end of the commented line (for example, "-->").
+	// This is empty for languages with line comments.
+	Suffix string
+}
+
+// IndentRules describe how auto-indent should adjust indentation for a language.
+type IndentRules struct {
+	// IndentAfter lists line endings (ignoring trailing whitespace) that increase
+	// the indentation of the next line, for example "{".
+	IndentAfter []string
+
+	// DedentOn lists strings that decrease the indentation of a line
+	// when they appear at the start of the line, for example "}".
+	DedentOn []string
+}
+
+var bracketIndentRules = IndentRules{
+	IndentAfter: []string{"{", "(", "["},
+	DedentOn:    []string{"}", ")", "]"},
+}
+
+// languageToIndentRules maps each language to its indent rules.
+// Languages without indent rules preserve the indentation of the previous line.
+var languageToIndentRules map[Language]IndentRules
+
+// languageToCommentSyntax maps each language to its comment syntax.
+// Languages without comments are omitted.
+var languageToCommentSyntax map[Language]CommentSyntax
+
 func init() {
 	languageToParseFunc = map[Language]parser.Func{
 		LanguagePlaintext: nil,
@@ -43,6 +78,38 @@ func init() {
 		LanguageProtobuf:  languages.ProtobufParseFunc(),
 		LanguageTodoTxt:   languages.TodoTxtParseFunc(),
 		LanguageMarkdown:  languages.MarkdownParseFunc(),
+		LanguageDiff:      languages.DiffParseFunc(),
+	}
+
+	languageToCommentSyntax = map[Language]CommentSyntax{
+		LanguageYaml:      {Prefix: "#"},
+		LanguageGo:        {Prefix: "//"},
+		LanguagePython:    {Prefix: "#"},
+		LanguageRust:      {Prefix: "//"},
+		LanguageC:         {Prefix: "//"},
+		LanguageGitCommit: {Prefix: "#"},
+		LanguageGitRebase: {Prefix: "#"},
+		LanguageProtobuf:  {Prefix: "//"},
+		LanguageMarkdown:  {Prefix: "<!--", Suffix: "-->"},
+	}
+
+	languageToIndentRules = map[Language]IndentRules{
+		LanguageJson: {
+			IndentAfter: []string{"{", "["},
+			DedentOn:    []string{"}", "]"},
+		},
+		LanguageYaml: {
+			IndentAfter: []string{"{", "[", ":"},
+			DedentOn:    []string{"}", "]"},
+		},
+		LanguageGo:   bracketIndentRules,
+		LanguageRust: bracketIndentRules,
+		LanguageC:    bracketIndentRules,
+		LanguagePython: {
+			IndentAfter: []string{"{", "(", "[", ":"},
+			DedentOn:    []string{"}", ")", "]"},
+		},
+		LanguageProtobuf: bracketIndentRules,
 	}
 
 	for language := range languageToParseFunc {
@@ -59,3 +126,15 @@ func ParserForLanguage(language Language) *parser.P {
 	}
 	return parser.New(parseFunc)
 }
+
+// CommentSyntaxForLanguage returns the comment syntax for a language.
+// If the language does not support comments, the prefix and suffix are empty.
+func CommentSyntaxForLanguage(language Language) CommentSyntax {
+	return languageToCommentSyntax[language]
+}
+
+// IndentRulesForLanguage returns the indent rules for a language.
+// If the language does not have indent rules, the returned rules are empty.
+func IndentRulesForLanguage(language Language) IndentRules {
+	return languageToIndentRules[language]
+}
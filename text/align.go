@@ -21,7 +21,11 @@ func Align(leftReader, rightReader io.Reader) ([]LineMatch, error) {
 	if err != nil {
 		return nil, err
 	}
+	return alignLineHashes(leftLineHashes, rightLineHashes), nil
+}
 
+// alignLineHashes matches lines with identical hashes in the left and right documents.
+func alignLineHashes(leftLineHashes, rightLineHashes []lineHash) []LineMatch {
 	leftLineCount, rightLineCount := uint64(len(leftLineHashes)), uint64(len(rightLineHashes))
 
 	if allLineHashesMatch(leftLineHashes, rightLineHashes) {
@@ -30,7 +34,7 @@ func Align(leftReader, rightReader io.Reader) ([]LineMatch, error) {
 		for i := 0; i < len(leftLineHashes); i++ {
 			matches[i] = LineMatch{LeftLineNum: uint64(i), RightLineNum: uint64(i)}
 		}
-		return matches, nil
+		return matches
 	}
 
 	// Align lines that occur exactly once in each document.
@@ -68,7 +72,7 @@ func Align(leftReader, rightReader io.Reader) ([]LineMatch, error) {
 		}
 	}
 
-	return matches, nil
+	return matches
 }
 
 // lineHash represents a hash of a line in a document.
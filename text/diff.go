@@ -0,0 +1,115 @@
+package text
+
+import (
+	"io"
+	"strings"
+)
+
+// DiffHunk represents a region of lines that differ between two documents.
+// Lines [LeftStartLineNum, LeftEndLineNum) in the left document are replaced by
+// lines [RightStartLineNum, RightEndLineNum) in the right document.
+// Either range may be empty if lines were only added or only removed.
+type DiffHunk struct {
+	LeftStartLineNum  uint64
+	LeftEndLineNum    uint64
+	RightStartLineNum uint64
+	RightEndLineNum   uint64
+}
+
+// SplitLines splits text into lines, each including its line feed.
+// The last line has no line feed if the text does not end with one.
+// The line numbers in a DiffHunk are indices into these lines.
+func SplitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// maxDiffGapSize limits the size of the table used to compare unaligned regions.
+// If the product of the number of left and right lines in a region exceeds this,
+// the entire region is reported as a single hunk.
+const maxDiffGapSize = 1 << 20
+
+// Diff returns the hunks that differ between the left and right documents.
+// It starts from the lines matched by Align, then finds the longest common subsequence of
+// the lines between each pair of aligned lines. This matches lines that Align skips because
+// they aren't unique, such as blank lines and braces, so each hunk is as small as possible.
+func Diff(leftReader, rightReader io.Reader) ([]DiffHunk, error) {
+	leftLineHashes, rightLineHashes, err := hashLeftAndRightLines(leftReader, rightReader)
+	if err != nil {
+		return nil, err
+	}
+
+	var hunks []DiffHunk
+	var leftLineNum, rightLineNum uint64
+	for _, match := range alignLineHashes(leftLineHashes, rightLineHashes) {
+		hunks = appendGapHunks(hunks, leftLineHashes, rightLineHashes, leftLineNum, match.LeftLineNum, rightLineNum, match.RightLineNum)
+		leftLineNum, rightLineNum = match.LeftLineNum+1, match.RightLineNum+1
+	}
+
+	// Unaligned lines after the last match.
+	leftLineCount, rightLineCount := uint64(len(leftLineHashes)), uint64(len(rightLineHashes))
+	hunks = appendGapHunks(hunks, leftLineHashes, rightLineHashes, leftLineNum, leftLineCount, rightLineNum, rightLineCount)
+	return hunks, nil
+}
+
+// appendGapHunks appends hunks for the differences between lines [leftStart, leftEnd) and [rightStart, rightEnd).
+func appendGapHunks(hunks []DiffHunk, left, right []lineHash, leftStart, leftEnd, rightStart, rightEnd uint64) []DiffHunk {
+	// Skip lines that match at the start and end of the region.
+	for leftStart < leftEnd && rightStart < rightEnd && left[leftStart] == right[rightStart] {
+		leftStart++
+		rightStart++
+	}
+	for leftStart < leftEnd && rightStart < rightEnd && left[leftEnd-1] == right[rightEnd-1] {
+		leftEnd--
+		rightEnd--
+	}
+
+	n, m := leftEnd-leftStart, rightEnd-rightStart
+	if n == 0 && m == 0 {
+		return hunks
+	} else if n == 0 || m == 0 || n*m > maxDiffGapSize {
+		return append(hunks, DiffHunk{leftStart, leftEnd, rightStart, rightEnd})
+	}
+
+	// lengths[i*(m+1)+j] is the length of the longest common subsequence
+	// of left[leftStart+i:leftEnd] and right[rightStart+j:rightEnd].
+	lengths := make([]int32, (n+1)*(m+1))
+	for i := int(n) - 1; i >= 0; i-- {
+		for j := int(m) - 1; j >= 0; j-- {
+			idx := i*int(m+1) + j
+			if left[leftStart+uint64(i)] == right[rightStart+uint64(j)] {
+				lengths[idx] = lengths[idx+int(m+1)+1] + 1
+			} else if a, b := lengths[idx+int(m+1)], lengths[idx+1]; a >= b {
+				lengths[idx] = a
+			} else {
+				lengths[idx] = b
+			}
+		}
+	}
+
+	// Follow the longest common subsequence, appending a hunk for each run of unmatched lines.
+	var i, j, hunkStartI, hunkStartJ uint64
+	for i < n && j < m {
+		if left[leftStart+i] == right[rightStart+j] {
+			if hunkStartI < i || hunkStartJ < j {
+				hunks = append(hunks, DiffHunk{leftStart + hunkStartI, leftStart + i, rightStart + hunkStartJ, rightStart + j})
+			}
+			i++
+			j++
+			hunkStartI, hunkStartJ = i, j
+		} else if lengths[(i+1)*(m+1)+j] >= lengths[i*(m+1)+j+1] {
+			i++
+		} else {
+			j++
+		}
+	}
+
+	if hunkStartI < n || hunkStartJ < m {
+		hunks = append(hunks, DiffHunk{leftStart + hunkStartI, leftEnd, rightStart + hunkStartJ, rightEnd})
+	}
+
+	return hunks
+}
@@ -0,0 +1,117 @@
+package text
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitLines(t *testing.T) {
+	testCases := []struct {
+		name     string
+		text     string
+		expected []string
+	}{
+		{name: "empty", text: "", expected: []string{}},
+		{name: "single line feed", text: "\n", expected: []string{"\n"}},
+		{name: "final line feed", text: "abc\ndef\n", expected: []string{"abc\n", "def\n"}},
+		{name: "no final line feed", text: "abc\ndef", expected: []string{"abc\n", "def"}},
+		{name: "blank lines", text: "abc\n\n\ndef", expected: []string{"abc\n", "\n", "\n", "def"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, SplitLines(tc.text))
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	testCases := []struct {
+		name      string
+		leftText  string
+		rightText string
+		expected  []DiffHunk
+	}{
+		{
+			name:      "both empty",
+			leftText:  "",
+			rightText: "",
+			expected:  nil,
+		},
+		{
+			name:      "identical",
+			leftText:  "abc\ndef\nghi",
+			rightText: "abc\ndef\nghi",
+			expected:  nil,
+		},
+		{
+			name:      "left empty",
+			leftText:  "",
+			rightText: "abc\ndef",
+			expected: []DiffHunk{
+				{LeftStartLineNum: 0, LeftEndLineNum: 0, RightStartLineNum: 0, RightEndLineNum: 2},
+			},
+		},
+		{
+			name:      "right empty",
+			leftText:  "abc\ndef",
+			rightText: "",
+			expected: []DiffHunk{
+				{LeftStartLineNum: 0, LeftEndLineNum: 2, RightStartLineNum: 0, RightEndLineNum: 0},
+			},
+		},
+		{
+			name:      "changed line",
+			leftText:  "abc\ndef\nghi",
+			rightText: "abc\nxyz\nghi",
+			expected: []DiffHunk{
+				{LeftStartLineNum: 1, LeftEndLineNum: 2, RightStartLineNum: 1, RightEndLineNum: 2},
+			},
+		},
+		{
+			name:      "added and removed lines",
+			leftText:  "abc\ndef\nghi\njkl",
+			rightText: "abc\nxyz\ndef\njkl",
+			expected: []DiffHunk{
+				{LeftStartLineNum: 1, LeftEndLineNum: 1, RightStartLineNum: 1, RightEndLineNum: 2},
+				{LeftStartLineNum: 2, LeftEndLineNum: 3, RightStartLineNum: 3, RightEndLineNum: 3},
+			},
+		},
+		{
+			name:      "lines that are not unique",
+			leftText:  "{\n}\n{\n}\n{\n}",
+			rightText: "{\n}\n{\nx\n}\n{\n}",
+			expected: []DiffHunk{
+				{LeftStartLineNum: 3, LeftEndLineNum: 3, RightStartLineNum: 3, RightEndLineNum: 4},
+			},
+		},
+		{
+			name:      "missing final line feed",
+			leftText:  "abc\ndef\n",
+			rightText: "abc\ndef",
+			expected: []DiffHunk{
+				{LeftStartLineNum: 1, LeftEndLineNum: 2, RightStartLineNum: 1, RightEndLineNum: 2},
+			},
+		},
+		{
+			name:      "changes at start and end",
+			leftText:  "abc\ndef\nghi",
+			rightText: "xyz\ndef\nuvw",
+			expected: []DiffHunk{
+				{LeftStartLineNum: 0, LeftEndLineNum: 1, RightStartLineNum: 0, RightEndLineNum: 1},
+				{LeftStartLineNum: 2, LeftEndLineNum: 3, RightStartLineNum: 2, RightEndLineNum: 3},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hunks, err := Diff(strings.NewReader(tc.leftText), strings.NewReader(tc.rightText))
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, hunks)
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package text
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Markers at the start of lines that delimit a merge conflict.
+const (
+	MergeConflictStartMarker = "<<<<<<<"
+	MergeConflictSepMarker   = "======="
+	MergeConflictEndMarker   = ">>>>>>>"
+)
+
+// MergeConflict is a region of merged text where the left and right documents
+// made different changes to the same lines of the base document.
+// The region includes the conflict markers.
+type MergeConflict struct {
+	StartLineNum uint64
+	EndLineNum   uint64
+}
+
+// MergeResult is the output of a three-way merge.
+type MergeResult struct {
+	Text      string
+	Conflicts []MergeConflict
+}
+
+// mergeSide identifies which document changed a region of the base document.
+type mergeSide int
+
+const (
+	mergeSideLeft = mergeSide(iota)
+	mergeSideRight
+)
+
+type mergeHunk struct {
+	DiffHunk
+	side mergeSide
+}
+
+// Merge combines changes from the left and right documents, both derived from the base document.
+// Changes to different lines of the base document are applied together.
+// If both documents changed the same lines differently, the merged text contains both versions
+// between conflict markers, labeled with leftLabel and rightLabel.
+func Merge(base, left, right string, leftLabel, rightLabel string) (MergeResult, error) {
+	leftHunks, err := Diff(strings.NewReader(base), strings.NewReader(left))
+	if err != nil {
+		return MergeResult{}, errors.Wrap(err, "Diff(base, left)")
+	}
+
+	rightHunks, err := Diff(strings.NewReader(base), strings.NewReader(right))
+	if err != nil {
+		return MergeResult{}, errors.Wrap(err, "Diff(base, right)")
+	}
+
+	hunks := make([]mergeHunk, 0, len(leftHunks)+len(rightHunks))
+	for _, h := range leftHunks {
+		hunks = append(hunks, mergeHunk{h, mergeSideLeft})
+	}
+	for _, h := range rightHunks {
+		hunks = append(hunks, mergeHunk{h, mergeSideRight})
+	}
+	sort.SliceStable(hunks, func(i, j int) bool {
+		if hunks[i].LeftStartLineNum != hunks[j].LeftStartLineNum {
+			return hunks[i].LeftStartLineNum < hunks[j].LeftStartLineNum
+		}
+		return hunks[i].LeftEndLineNum < hunks[j].LeftEndLineNum
+	})
+
+	baseLines, leftLines, rightLines := SplitLines(base), SplitLines(left), SplitLines(right)
+
+	var sb strings.Builder
+	var result MergeResult
+	var lineNum, baseLineNum uint64
+	var leftDelta, rightDelta int64 // Difference between line numbers in each document and the base document.
+	writeLines := func(lines []string) {
+		for _, line := range lines {
+			sb.WriteString(line)
+			lineNum++
+		}
+	}
+	writeMarker := func(marker string) {
+		// The previous line may be the last line of the document, without a line feed.
+		if s := sb.String(); len(s) > 0 && !strings.HasSuffix(s, "\n") {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(marker)
+		sb.WriteString("\n")
+		lineNum++
+	}
+
+	for i := 0; i < len(hunks); {
+		// Group hunks that overlap in the base document, since these must be resolved together.
+		start, end := hunks[i].LeftStartLineNum, hunks[i].LeftEndLineNum
+		sides := map[mergeSide]int64{hunks[i].side: hunkDelta(hunks[i].DiffHunk)}
+		j := i + 1
+		for j < len(hunks) && mergeHunksOverlap(start, end, hunks[j].DiffHunk) {
+			if hunks[j].LeftEndLineNum > end {
+				end = hunks[j].LeftEndLineNum
+			}
+			sides[hunks[j].side] += hunkDelta(hunks[j].DiffHunk)
+			j++
+		}
+
+		// Copy unchanged lines before the group.
+		writeLines(baseLines[baseLineNum:start])
+		baseLineNum = end
+
+		leftRegion := leftLines[int64(start)+leftDelta : int64(end)+leftDelta+sides[mergeSideLeft]]
+		rightRegion := rightLines[int64(start)+rightDelta : int64(end)+rightDelta+sides[mergeSideRight]]
+		leftDelta += sides[mergeSideLeft]
+		rightDelta += sides[mergeSideRight]
+
+		_, leftChanged := sides[mergeSideLeft]
+		_, rightChanged := sides[mergeSideRight]
+		if !rightChanged || (leftChanged && strings.Join(leftRegion, "") == strings.Join(rightRegion, "")) {
+			writeLines(leftRegion)
+		} else if !leftChanged {
+			writeLines(rightRegion)
+		} else {
+			conflict := MergeConflict{StartLineNum: lineNum}
+			writeMarker(MergeConflictStartMarker + " " + leftLabel)
+			writeLines(leftRegion)
+			writeMarker(MergeConflictSepMarker)
+			writeLines(rightRegion)
+			writeMarker(MergeConflictEndMarker + " " + rightLabel)
+			conflict.EndLineNum = lineNum
+			result.Conflicts = append(result.Conflicts, conflict)
+		}
+
+		i = j
+	}
+
+	endsWithConflict := len(result.Conflicts) > 0 && result.Conflicts[len(result.Conflicts)-1].EndLineNum == lineNum
+	writeLines(baseLines[baseLineNum:])
+
+	result.Text = sb.String()
+	if endsWithConflict && baseLineNum == uint64(len(baseLines)) && !strings.HasSuffix(left, "\n") {
+		// The document ends with a conflict, so remove the line feed after the end marker
+		// to preserve the missing line feed at the end of the document.
+		result.Text = strings.TrimSuffix(result.Text, "\n")
+	}
+	return result, nil
+}
+
+// mergeHunksOverlap returns whether a hunk overlaps the lines [start, end) of the base document.
+// Insertions at the start or end of the lines do not overlap, since the order of lines is unambiguous,
+// except for two insertions at the same line.
+func mergeHunksOverlap(start, end uint64, h DiffHunk) bool {
+	if start == end && h.LeftStartLineNum == h.LeftEndLineNum {
+		return start == h.LeftStartLineNum
+	}
+	return h.LeftStartLineNum < end && start < h.LeftEndLineNum
+}
+
+func hunkDelta(h DiffHunk) int64 {
+	return int64(h.RightEndLineNum-h.RightStartLineNum) - int64(h.LeftEndLineNum-h.LeftStartLineNum)
+}
@@ -0,0 +1,136 @@
+package text
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerge(t *testing.T) {
+	testCases := []struct {
+		name              string
+		base              string
+		left              string
+		right             string
+		expectedText      string
+		expectedConflicts []MergeConflict
+	}{
+		{
+			name:         "all empty",
+			base:         "",
+			left:         "",
+			right:        "",
+			expectedText: "",
+		},
+		{
+			name:         "no changes",
+			base:         "abc\ndef\nghi",
+			left:         "abc\ndef\nghi",
+			right:        "abc\ndef\nghi",
+			expectedText: "abc\ndef\nghi",
+		},
+		{
+			name:         "only left changed",
+			base:         "abc\ndef\nghi",
+			left:         "abc\nxyz\nghi",
+			right:        "abc\ndef\nghi",
+			expectedText: "abc\nxyz\nghi",
+		},
+		{
+			name:         "only right changed",
+			base:         "abc\ndef\nghi",
+			left:         "abc\ndef\nghi",
+			right:        "abc\ndef\nghi\njkl",
+			expectedText: "abc\ndef\nghi\njkl",
+		},
+		{
+			name:         "changes to different lines",
+			base:         "abc\ndef\nghi\njkl",
+			left:         "ABC\ndef\nghi\njkl",
+			right:        "abc\ndef\nghi\nJKL",
+			expectedText: "ABC\ndef\nghi\nJKL",
+		},
+		{
+			name:         "changes to adjacent lines",
+			base:         "abc\ndef\nghi",
+			left:         "abc\nDEF\nghi",
+			right:        "abc\ndef\nGHI",
+			expectedText: "abc\nDEF\nGHI",
+		},
+		{
+			name:         "insertion before changed line",
+			base:         "abc\ndef\nghi",
+			left:         "abc\nxyz\ndef\nghi",
+			right:        "abc\nDEF\nghi",
+			expectedText: "abc\nxyz\nDEF\nghi",
+		},
+		{
+			name:         "same change on both sides",
+			base:         "abc\ndef\nghi",
+			left:         "abc\nxyz\nghi",
+			right:        "abc\nxyz\nghi",
+			expectedText: "abc\nxyz\nghi",
+		},
+		{
+			name:         "conflicting changes",
+			base:         "abc\ndef\nghi\n",
+			left:         "abc\nleft\nghi\n",
+			right:        "abc\nright\nghi\n",
+			expectedText: "abc\n<<<<<<< ours\nleft\n=======\nright\n>>>>>>> theirs\nghi\n",
+			expectedConflicts: []MergeConflict{
+				{StartLineNum: 1, EndLineNum: 6},
+			},
+		},
+		{
+			name:         "conflicting insertions at the same line",
+			base:         "abc\n",
+			left:         "abc\nleft\n",
+			right:        "abc\nright\n",
+			expectedText: "abc\n<<<<<<< ours\nleft\n=======\nright\n>>>>>>> theirs\n",
+			expectedConflicts: []MergeConflict{
+				{StartLineNum: 1, EndLineNum: 6},
+			},
+		},
+		{
+			name:         "conflict at end of document without line feed",
+			base:         "abc\ndef",
+			left:         "abc\nleft",
+			right:        "abc\nright",
+			expectedText: "abc\n<<<<<<< ours\nleft\n=======\nright\n>>>>>>> theirs",
+			expectedConflicts: []MergeConflict{
+				{StartLineNum: 1, EndLineNum: 6},
+			},
+		},
+		{
+			name:         "conflict and clean merge",
+			base:         "abc\ndef\nghi\njkl\nmno",
+			left:         "abc\nleft\nghi\njkl\nmno",
+			right:        "ABC\nright\nghi\njkl\nMNO",
+			expectedText: "<<<<<<< ours\nabc\nleft\n=======\nABC\nright\n>>>>>>> theirs\nghi\njkl\nMNO",
+			expectedConflicts: []MergeConflict{
+				{StartLineNum: 0, EndLineNum: 7},
+			},
+		},
+		{
+			name:         "multiple conflicts",
+			base:         "a\nb\nc\nd\ne\n",
+			left:         "a\nB1\nc\nD1\ne\n",
+			right:        "a\nB2\nc\nD2\ne\n",
+			expectedText: "a\n<<<<<<< ours\nB1\n=======\nB2\n>>>>>>> theirs\nc\n<<<<<<< ours\nD1\n=======\nD2\n>>>>>>> theirs\ne\n",
+			expectedConflicts: []MergeConflict{
+				{StartLineNum: 1, EndLineNum: 6},
+				{StartLineNum: 7, EndLineNum: 12},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := Merge(tc.base, tc.left, tc.right, "ours", "theirs")
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedText, result.Text)
+			assert.Equal(t, tc.expectedConflicts, result.Conflicts)
+		})
+	}
+}
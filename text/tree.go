@@ -168,6 +168,55 @@ func (t *Tree) InsertAtPosition(charPos uint64, c rune) error {
 	return nil
 }
 
+// AppendString inserts a UTF-8 string at the end of the tree.
+// This is more efficient than inserting each character.
+// Returns an error if the string is invalid UTF-8.
+func (t *Tree) AppendString(s string) error {
+	other, err := NewTreeFromString(s)
+	if err != nil {
+		return err
+	}
+	t.AppendTree(other)
+	return nil
+}
+
+// AppendTree moves the text from another tree to the end of this tree.
+// This attaches the nodes of the other tree to the rightmost nodes of this tree,
+// so it takes time proportional to the height of the trees rather than the length of the text.
+// The other tree must not be used afterwards.
+func (t *Tree) AppendTree(other *Tree) {
+	// Link the leaves so readers can continue from this tree into the appended tree.
+	lastGroup, firstGroup := t.root.lastLeafGroup(), other.root.firstLeafGroup()
+	lastGroup.next = firstGroup
+	firstGroup.prev = lastGroup
+
+	// Every path from the root to a leaf has the same length, so the shorter tree becomes
+	// the rightmost child of a node in the taller tree at the same height.
+	// If this tree is shorter, add levels above its root until the heights match.
+	height, otherHeight := t.root.height(), other.root.height()
+	for height < otherHeight {
+		newGroup := innerNodeGroup{numNodes: 1}
+		newGroup.nodes[0] = *t.root
+		t.root = &innerNode{child: &newGroup}
+		t.root.recalculateChildKeys()
+		height++
+	}
+
+	splitNode := other.root
+	if height > otherHeight {
+		splitNode = t.root.appendNode(other.root, otherHeight, height)
+	}
+
+	if splitNode != nil {
+		newGroup := innerNodeGroup{numNodes: 2}
+		newGroup.nodes[0] = *t.root
+		newGroup.nodes[1] = *splitNode
+
+		t.root = &innerNode{child: &newGroup}
+		t.root.recalculateChildKeys()
+	}
+}
+
 // DeleteAtPosition removes the UTF-8 character at the specified position (0-indexed).
 // If charPos is past the end of the text, this has no effect.
 func (t *Tree) DeleteAtPosition(charPos uint64) (bool, rune) {
@@ -389,6 +438,69 @@ func (n *innerNode) insertAtPosition(charPos uint64, c rune) (invalidateKeys boo
 	return true, splitNode, nil
 }
 
+// appendNode adds a node with the given height as the rightmost descendant of this node.
+// If the child group is full, the group splits and this returns the new sibling of this node.
+func (n *innerNode) appendNode(node *innerNode, nodeHeight uint64, height uint64) *innerNode {
+	g := n.child.(*innerNodeGroup)
+	newNode := node
+	if height > nodeHeight+1 {
+		newNode = g.nodes[g.numNodes-1].appendNode(node, nodeHeight, height-1)
+	}
+
+	if newNode == nil {
+		n.recalculateChildKeys()
+		return nil
+	}
+
+	if g.numNodes < maxNodesPerGroup {
+		g.insertNode(g.numNodes, newNode)
+		n.recalculateChildKeys()
+		return nil
+	}
+
+	splitGroup := g.split()
+	splitGroup.insertNode(splitGroup.numNodes, newNode)
+	n.recalculateChildKeys()
+	splitNode := &innerNode{child: splitGroup}
+	splitNode.recalculateChildKeys()
+	return splitNode
+}
+
+// height returns the number of inner nodes on each path from this node to a leaf.
+func (n *innerNode) height() uint64 {
+	height := uint64(1)
+	for {
+		g, ok := n.child.(*innerNodeGroup)
+		if !ok {
+			return height
+		}
+		n = &g.nodes[0]
+		height++
+	}
+}
+
+func (n *innerNode) firstLeafGroup() *leafNodeGroup {
+	for {
+		switch g := n.child.(type) {
+		case *innerNodeGroup:
+			n = &g.nodes[0]
+		case *leafNodeGroup:
+			return g
+		}
+	}
+}
+
+func (n *innerNode) lastLeafGroup() *leafNodeGroup {
+	for {
+		switch g := n.child.(type) {
+		case *innerNodeGroup:
+			n = &g.nodes[g.numNodes-1]
+		case *leafNodeGroup:
+			return g
+		}
+	}
+}
+
 func (n *innerNode) deleteAtPosition(charPos uint64) (didDelete, wasNewline bool, r rune) {
 	nodeIdx, adjustedCharPos := n.locatePosition(charPos)
 	didDelete, wasNewline, r = n.child.deleteAtPosition(nodeIdx, adjustedCharPos)
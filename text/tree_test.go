@@ -1161,6 +1161,91 @@ func TestNodeSplit(t *testing.T) {
 	assert.Equal(t, 1341, len(tree.String()))
 }
 
+func TestAppendString(t *testing.T) {
+	testCases := []struct {
+		name    string
+		initial string
+		appends []string
+	}{
+		{
+			name:    "empty tree, empty string",
+			initial: "",
+			appends: []string{""},
+		},
+		{
+			name:    "empty tree, short string",
+			initial: "",
+			appends: []string{"abc\ndef"},
+		},
+		{
+			name:    "short tree, short string",
+			initial: "abc\n",
+			appends: []string{"def\nghi"},
+		},
+		{
+			name:    "short tree, long string",
+			initial: "abc\n",
+			appends: []string{lines(4096, 100)},
+		},
+		{
+			name:    "long tree, short string",
+			initial: lines(4096, 100),
+			appends: []string{"abc\n"},
+		},
+		{
+			name:    "long tree, long string",
+			initial: lines(4096, 100),
+			appends: []string{lines(4096, 50)},
+		},
+		{
+			name:    "many appends",
+			initial: "",
+			appends: []string{"a", lines(1024, 10), "\n", lines(100, 3), "", lines(8192, 20), "b\u00e9c", "\n\n"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tree, err := NewTreeFromString(tc.initial)
+			require.NoError(t, err)
+
+			expected := tc.initial
+			for _, s := range tc.appends {
+				err := tree.AppendString(s)
+				require.NoError(t, err)
+				expected += s
+			}
+
+			expectedTree, err := NewTreeFromString(expected)
+			require.NoError(t, err)
+			assert.Equal(t, expected, tree.String())
+			assert.Equal(t, expectedTree.NumChars(), tree.NumChars())
+			assert.Equal(t, expectedTree.NumLines(), tree.NumLines())
+			for i := uint64(0); i < expectedTree.NumLines(); i += 97 {
+				assert.Equal(t, expectedTree.LineStartPosition(i), tree.LineStartPosition(i))
+			}
+
+			// The tree remains editable after appending.
+			require.NoError(t, tree.InsertAtPosition(tree.NumChars(), 'x'))
+			require.NoError(t, tree.InsertAtPosition(0, 'y'))
+			assert.Equal(t, "y"+expected+"x", tree.String())
+
+			reverseReader := tree.ReverseReaderAtPosition(tree.NumChars())
+			retrievedBytes, err := io.ReadAll(&reverseReader)
+			require.NoError(t, err)
+			assert.Equal(t, len(expected)+2, len(retrievedBytes))
+		})
+	}
+}
+
+func TestAppendInvalidUtf8(t *testing.T) {
+	tree, err := NewTreeFromString("abc")
+	require.NoError(t, err)
+	err = tree.AppendString("\xff")
+	assert.Equal(t, InvalidUtf8Error, err)
+	assert.Equal(t, "abc", tree.String())
+}
+
 func BenchmarkLoad(b *testing.B) {
 	benchmarks := []struct {
 		name     string
@@ -0,0 +1,318 @@
+package undo
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// historyCacheVersion identifies the format of files in the history cache.
+// Files with a different version are ignored.
+const historyCacheVersion = 1
+
+const historyCacheExt = ".json"
+
+// HistoryCache persists undo logs to a directory, so undo history survives after the editor exits.
+//
+// Each log is stored with the checksum of the file when it was last loaded or saved.
+// The log is restored only if the file still has the same checksum,
+// since otherwise the undo operations would not apply to the file's contents.
+type HistoryCache struct {
+	dir string
+}
+
+// NewHistoryCache returns a cache that stores undo logs in the specified directory.
+// The directory is created when the first log is saved.
+func NewHistoryCache(dir string) *HistoryCache {
+	return &HistoryCache{dir: dir}
+}
+
+// DefaultHistoryCacheDir returns the directory for undo history in the user's cache directory.
+func DefaultHistoryCacheDir() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", errors.Wrap(err, "os.UserCacheDir")
+	}
+	return filepath.Join(cacheDir, "aretext", "undo"), nil
+}
+
+// Save writes the undo log for the file at path to the cache.
+// If the encoded log is larger than maxSize bytes, it is not saved and any previously cached log for the path is removed.
+func (c *HistoryCache) Save(path string, checksum string, l *Log, maxSize int) error {
+	data, err := json.Marshal(cachedLogFromLog(path, checksum, l))
+	if err != nil {
+		return errors.Wrap(err, "json.Marshal")
+	}
+
+	cachePath := c.cachePathForFile(path)
+	if len(data) > maxSize {
+		if err := os.Remove(cachePath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return errors.Wrap(err, "os.Remove")
+		}
+		return errors.Errorf("undo history is %d bytes, which exceeds the limit of %d bytes", len(data), maxSize)
+	}
+
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		return errors.Wrap(err, "os.MkdirAll")
+	}
+
+	// Write to a temporary file and rename it, so a crash can't leave a partially written log.
+	f, err := os.CreateTemp(c.dir, "tmp-*")
+	if err != nil {
+		return errors.Wrap(err, "os.CreateTemp")
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return errors.Wrap(err, "f.Write")
+	}
+
+	if err := f.Close(); err != nil {
+		return errors.Wrap(err, "f.Close")
+	}
+
+	if err := os.Rename(f.Name(), cachePath); err != nil {
+		return errors.Wrap(err, "os.Rename")
+	}
+
+	return nil
+}
+
+// Load reads the undo log for the file at path from the cache.
+// It returns nil if there is no cached log for the path, the file checksum doesn't match,
+// or the log was last saved more than maxAge ago.  A maxAge of zero means the log never expires.
+// The current state of the loaded log is the state when the file was last saved,
+// since that is the state of the file on disk.
+func (c *HistoryCache) Load(path string, checksum string, maxAge time.Duration) (*Log, error) {
+	cachePath := c.cachePathForFile(path)
+	fileInfo, err := os.Stat(cachePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "os.Stat")
+	}
+
+	if isExpired(fileInfo, maxAge) {
+		log.Printf("Removing expired undo history for '%s'\n", path)
+		if err := os.Remove(cachePath); err != nil {
+			return nil, errors.Wrap(err, "os.Remove")
+		}
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(cachePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "os.ReadFile")
+	}
+
+	var cl cachedLog
+	if err := json.Unmarshal(data, &cl); err != nil {
+		return nil, errors.Wrap(err, "json.Unmarshal")
+	}
+
+	if cl.Version != historyCacheVersion || cl.Path != path || cl.Checksum != checksum {
+		log.Printf("Ignoring undo history for '%s' because the file has changed\n", path)
+		return nil, nil
+	}
+
+	return cl.toLog()
+}
+
+// Prune removes every cached log that was last saved more than maxAge ago.
+// A maxAge of zero means logs never expire.
+func (c *HistoryCache) Prune(maxAge time.Duration) error {
+	if maxAge <= 0 {
+		return nil
+	}
+
+	entries, err := os.ReadDir(c.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return errors.Wrap(err, "os.ReadDir")
+	}
+
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), historyCacheExt) {
+			continue
+		}
+
+		fileInfo, err := entry.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return errors.Wrap(err, "entry.Info")
+		}
+
+		if isExpired(fileInfo, maxAge) {
+			err := os.Remove(filepath.Join(c.dir, entry.Name()))
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return errors.Wrap(err, "os.Remove")
+			}
+		}
+	}
+
+	return nil
+}
+
+// cachePathForFile returns the path in the cache for the undo log of a file.
+// The name is a hash of the file path, so it is unique and safe to use in any filesystem.
+func (c *HistoryCache) cachePathForFile(path string) string {
+	hash := sha256.Sum256([]byte(path))
+	return filepath.Join(c.dir, hex.EncodeToString(hash[:])+historyCacheExt)
+}
+
+func isExpired(fileInfo fs.FileInfo, maxAge time.Duration) bool {
+	return maxAge > 0 && time.Since(fileInfo.ModTime()) > maxAge
+}
+
+// cachedLog is the format of an undo log in the cache.
+// Nodes are referenced by their index, which is the same as their sequence number.
+type cachedLog struct {
+	Version          int
+	Path             string
+	Checksum         string
+	Nodes            []cachedNode
+	SavedNode        int
+	NumOpsAtLastSave int
+}
+
+type cachedNode struct {
+	Parent     int // -1 for the root node
+	RedoChild  int // -1 if the node has no children
+	Ops        []cachedOp
+	Checkpoint bool
+	Time       time.Time
+}
+
+type cachedOp struct {
+	Pos    uint64
+	Insert string `json:",omitempty"`
+	Delete string `json:",omitempty"`
+}
+
+func cachedLogFromLog(path string, checksum string, l *Log) cachedLog {
+	nodes := make([]cachedNode, 0, len(l.nodes))
+	for _, n := range l.nodes {
+		cn := cachedNode{
+			Parent:     -1,
+			RedoChild:  -1,
+			Ops:        make([]cachedOp, 0, len(n.ops)),
+			Checkpoint: n.checkpoint,
+			Time:       n.time,
+		}
+		if n.parent != nil {
+			cn.Parent = n.parent.seq
+		}
+		if n.redoChild != nil {
+			cn.RedoChild = n.redoChild.seq
+		}
+		for _, op := range n.ops {
+			cn.Ops = append(cn.Ops, cachedOp{
+				Pos:    op.pos,
+				Insert: op.insertText,
+				Delete: op.deleteText,
+			})
+		}
+		nodes = append(nodes, cn)
+	}
+
+	return cachedLog{
+		Version:          historyCacheVersion,
+		Path:             path,
+		Checksum:         checksum,
+		Nodes:            nodes,
+		SavedNode:        l.savedNode.seq,
+		NumOpsAtLastSave: l.numOpsAtLastSave,
+	}
+}
+
+func (cl cachedLog) toLog() (*Log, error) {
+	if len(cl.Nodes) == 0 || cl.Nodes[0].Parent != -1 {
+		return nil, errors.New("undo history has no root")
+	}
+
+	if cl.SavedNode < 0 || cl.SavedNode >= len(cl.Nodes) {
+		return nil, errors.New("undo history has invalid saved state")
+	}
+
+	nodes := make([]*node, 0, len(cl.Nodes))
+	for i, cn := range cl.Nodes {
+		n := &node{
+			seq:        i,
+			ops:        make([]Op, 0, len(cn.Ops)),
+			checkpoint: cn.Checkpoint,
+			time:       cn.Time,
+		}
+		for _, op := range cn.Ops {
+			n.ops = append(n.ops, Op{pos: op.Pos, insertText: op.Insert, deleteText: op.Delete})
+		}
+
+		// Every node is created after its parent, so the parent always has a lower index.
+		if i > 0 {
+			if cn.Parent < 0 || cn.Parent >= i {
+				return nil, errors.Errorf("undo history state %d has invalid parent", i)
+			}
+			n.parent = nodes[cn.Parent]
+			n.depth = n.parent.depth + 1
+			n.parent.children = append(n.parent.children, n)
+		}
+
+		nodes = append(nodes, n)
+	}
+
+	for i, cn := range cl.Nodes {
+		if cn.RedoChild < 0 {
+			continue
+		}
+		if cn.RedoChild >= len(nodes) || nodes[cn.RedoChild].parent != nodes[i] {
+			return nil, errors.Errorf("undo history state %d has invalid redo child", i)
+		}
+		nodes[i].redoChild = nodes[cn.RedoChild]
+	}
+
+	// The file on disk matches the saved state, so discard any changes added to the saved state
+	// after saving, along with any later states that depend on those changes.
+	savedNode := nodes[cl.SavedNode]
+	if len(savedNode.ops) < cl.NumOpsAtLastSave {
+		return nil, errors.New("undo history has invalid saved state")
+	} else if len(savedNode.ops) > cl.NumOpsAtLastSave {
+		savedNode.ops = savedNode.ops[:cl.NumOpsAtLastSave]
+		nodes = removeDescendants(nodes, savedNode)
+	}
+
+	return &Log{
+		nodes:            nodes,
+		current:          savedNode,
+		savedNode:        savedNode,
+		numOpsAtLastSave: cl.NumOpsAtLastSave,
+		now:              time.Now,
+	}, nil
+}
+
+// removeDescendants removes every descendant of a node, then renumbers the remaining nodes.
+// Every node must appear after its parent.
+func removeDescendants(nodes []*node, ancestor *node) []*node {
+	removed := make(map[*node]struct{})
+	result := make([]*node, 0, len(nodes))
+	for _, n := range nodes {
+		if _, ok := removed[n.parent]; ok || (n.parent != nil && n.parent == ancestor) {
+			removed[n] = struct{}{}
+			continue
+		}
+		n.seq = len(result)
+		result = append(result, n)
+	}
+	ancestor.children = nil
+	ancestor.redoChild = nil
+	return result
+}
@@ -0,0 +1,164 @@
+package undo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistoryCacheSaveAndLoad(t *testing.T) {
+	cache := NewHistoryCache(filepath.Join(t.TempDir(), "undo"))
+
+	// Create a log with two branches, then move to the original document after saving.
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+	log := NewLog()
+	log.now = func() time.Time { return now }
+	log.TrackLoad()
+	log.TrackOp(InsertOp(0, "a"))
+	log.Checkpoint()
+	log.TrackSave()
+	log.UndoToLastCheckpoint()
+	log.TrackOp(InsertOp(0, "b"))
+	log.TrackOp(DeleteOp(0, "b"))
+	log.Checkpoint()
+	expectedStates := log.States()
+
+	err := cache.Save("/test/file.txt", "abcd", log, 1024)
+	require.NoError(t, err)
+
+	loadedLog, err := cache.Load("/test/file.txt", "abcd", 0)
+	require.NoError(t, err)
+	require.NotNil(t, loadedLog)
+
+	// The loaded log starts from the saved state, which matches the file on disk.
+	assert.Equal(t, 1, loadedLog.CurrentSeq())
+	assert.False(t, loadedLog.HasUnsavedChanges())
+	for i := range expectedStates {
+		expectedStates[i].Current = expectedStates[i].Saved
+	}
+	assert.Equal(t, expectedStates, loadedLog.States())
+
+	// Both branches are still available.
+	assert.Equal(t, []Op{DeleteOp(0, "a"), InsertOp(0, "b"), DeleteOp(0, "b")}, loadedLog.MoveToNewerState())
+	assert.Equal(t, []Op{InsertOp(0, "b"), DeleteOp(0, "b")}, loadedLog.UndoToLastCheckpoint())
+	assert.Equal(t, 0, loadedLog.CurrentSeq())
+}
+
+func TestHistoryCacheLoadChangesAfterSave(t *testing.T) {
+	cache := NewHistoryCache(filepath.Join(t.TempDir(), "undo"))
+
+	// Add changes to the saved state after saving, then create another state from those changes.
+	log := NewLog()
+	log.TrackOp(InsertOp(0, "a"))
+	log.Checkpoint()
+	log.TrackOp(InsertOp(1, "b"))
+	log.TrackSave()
+	log.TrackOp(InsertOp(2, "c"))
+	log.Checkpoint()
+	log.TrackOp(InsertOp(3, "d"))
+	log.Checkpoint()
+	require.Equal(t, 4, log.NumStates())
+
+	err := cache.Save("/test/file.txt", "abcd", log, 1024)
+	require.NoError(t, err)
+
+	loadedLog, err := cache.Load("/test/file.txt", "abcd", 0)
+	require.NoError(t, err)
+	require.NotNil(t, loadedLog)
+
+	// The history up to the saved state is preserved, but not the later changes.
+	assert.Equal(t, 3, loadedLog.NumStates())
+	assert.Equal(t, 2, loadedLog.CurrentSeq())
+	assert.False(t, loadedLog.HasUnsavedChanges())
+	assert.Nil(t, loadedLog.MoveToNewerState())
+	assert.Equal(t, []Op{DeleteOp(1, "b")}, loadedLog.UndoToLastCheckpoint())
+
+	// New changes start from the saved state.
+	assert.Equal(t, []Op{InsertOp(1, "b")}, loadedLog.RedoToNextCheckpoint())
+	loadedLog.TrackOp(InsertOp(2, "x"))
+	assert.True(t, loadedLog.HasUnsavedChanges())
+}
+
+func TestHistoryCacheLoadMismatch(t *testing.T) {
+	cache := NewHistoryCache(t.TempDir())
+	log := NewLog()
+	log.TrackOp(InsertOp(0, "a"))
+	err := cache.Save("/test/file.txt", "abcd", log, 1024)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		path     string
+		checksum string
+	}{
+		{name: "different checksum", path: "/test/file.txt", checksum: "efgh"},
+		{name: "different path", path: "/test/other.txt", checksum: "abcd"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			loadedLog, err := cache.Load(tc.path, tc.checksum, 0)
+			require.NoError(t, err)
+			assert.Nil(t, loadedLog)
+		})
+	}
+}
+
+func TestHistoryCacheSaveTooLarge(t *testing.T) {
+	cache := NewHistoryCache(t.TempDir())
+	log := NewLog()
+	log.TrackOp(InsertOp(0, "a"))
+	err := cache.Save("/test/file.txt", "abcd", log, 1024)
+	require.NoError(t, err)
+
+	// Saving a log that exceeds the limit removes the previously saved log.
+	log.TrackOp(InsertOp(1, string(make([]byte, 2048))))
+	err = cache.Save("/test/file.txt", "abcd", log, 1024)
+	assert.Error(t, err)
+
+	loadedLog, err := cache.Load("/test/file.txt", "abcd", 0)
+	require.NoError(t, err)
+	assert.Nil(t, loadedLog)
+}
+
+func TestHistoryCacheExpired(t *testing.T) {
+	dir := t.TempDir()
+	cache := NewHistoryCache(dir)
+	log := NewLog()
+	log.TrackOp(InsertOp(0, "a"))
+	require.NoError(t, cache.Save("/test/old.txt", "abcd", log, 1024))
+	require.NoError(t, cache.Save("/test/new.txt", "abcd", log, 1024))
+
+	// Backdate the log for one of the files.
+	oldTime := time.Now().Add(-48 * time.Hour)
+	err := os.Chtimes(cache.cachePathForFile("/test/old.txt"), oldTime, oldTime)
+	require.NoError(t, err)
+
+	err = cache.Prune(24 * time.Hour)
+	require.NoError(t, err)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(entries))
+	assert.Equal(t, filepath.Base(cache.cachePathForFile("/test/new.txt")), entries[0].Name())
+
+	loadedLog, err := cache.Load("/test/new.txt", "abcd", 24*time.Hour)
+	require.NoError(t, err)
+	assert.NotNil(t, loadedLog)
+}
+
+func TestHistoryCacheLoadInvalid(t *testing.T) {
+	cache := NewHistoryCache(t.TempDir())
+	log := NewLog()
+	require.NoError(t, cache.Save("/test/file.txt", "abcd", log, 1024))
+
+	err := os.WriteFile(cache.cachePathForFile("/test/file.txt"), []byte("{invalid"), 0600)
+	require.NoError(t, err)
+
+	_, err = cache.Load("/test/file.txt", "abcd", 0)
+	assert.Error(t, err)
+}
@@ -1,97 +1,264 @@
 package undo
 
-// logEntry represents an entry in the undo log.
-// Some entries are "checkpoints" that partition entries into groups that can be undone/redone together.
-type logEntry struct {
+import (
+	"time"
+)
+
+// node is a document state in the undo tree.
+// The ops transform the document from the state of the parent node to the state of this node.
+type node struct {
+	// seq is the order in which the node was created.  The root node has seq zero.
+	seq int
+
+	// depth is the number of edges between this node and the root.
+	depth int
+
+	parent   *node
+	children []*node
+
+	// redoChild is the child to move to on redo.
+	// This is the most recently created or visited child.
+	redoChild *node
+
+	ops []Op
+
+	// checkpoint indicates that ops can no longer be appended to this node.
+	// Subsequent changes create a new child node instead.
 	checkpoint bool
-	op         Op
+
+	// time is when the last op was added to the node, or when the log was reset for the root node.
+	time time.Time
+}
+
+// inverseOps returns operations to transform the document from this node's state back to its parent's state.
+func (n *node) inverseOps() []Op {
+	ops := make([]Op, 0, len(n.ops))
+	for i := len(n.ops) - 1; i >= 0; i-- {
+		ops = append(ops, n.ops[i].Inverse())
+	}
+	return ops
 }
 
 // Log tracks changes to a document and generates undo/redo operations.
+//
+// Changes are stored in a tree, where each node is the state of the document at an undo checkpoint.
+// Undo moves to the parent node, and redo moves to the most recently visited child node.
+// Tracking a change after undoing creates a new branch, so no change is ever discarded.
+// Nodes are numbered in the order they were created, so it is also possible to move
+// through every state chronologically, regardless of which branch it's on.
 type Log struct {
-	// entries[0:numUndoEntries] are changes made before the current document state.  These changes can be undone.
-	// entries[numUndoEntries:len(entries)-1] are changes made after the current document state.  These changes can be redone.
-	entries              []logEntry
-	numUndoEntries       int
-	numEntriesAtLastSave int
+	// nodes contains every node in the tree, indexed by seq.
+	nodes   []*node
+	current *node
+
+	// savedNode and numOpsAtLastSave identify the document state when the document was last saved.
+	savedNode        *node
+	numOpsAtLastSave int
+
+	// now returns the current time.  Tests can override this.
+	now func() time.Time
 }
 
 // NewLog constructs a new, empty undo log.
 func NewLog() *Log {
-	return &Log{
-		entries:              make([]logEntry, 0, 256),
-		numUndoEntries:       0,
-		numEntriesAtLastSave: 0,
-	}
+	l := &Log{now: time.Now}
+	l.TrackLoad()
+	return l
 }
 
 // TrackOp tracks a change to the document.
-// This appends a new, uncommitted change and invalidates any future changes.
+// If the current state is not yet a checkpoint, the change is added to it.
+// Otherwise, this creates a new state, which starts a new branch if the current state already had changes after it.
 func (l *Log) TrackOp(op Op) {
-	if len(l.entries) > l.numUndoEntries {
-		// Invalidate future changes.
-		l.entries = l.entries[0:l.numUndoEntries]
-	}
-
-	if l.numEntriesAtLastSave > l.numUndoEntries {
-		// Invalidate a save point in the future.
-		l.numEntriesAtLastSave = -1
+	n := l.current
+	if n.checkpoint {
+		n = &node{
+			seq:    len(l.nodes),
+			depth:  n.depth + 1,
+			parent: n,
+		}
+		n.parent.children = append(n.parent.children, n)
+		n.parent.redoChild = n
+		l.nodes = append(l.nodes, n)
+		l.current = n
 	}
-
-	// Append a new undo entry.
-	l.entries = append(l.entries, logEntry{op: op})
-	l.numUndoEntries++
+	n.ops = append(n.ops, op)
+	n.time = l.now()
 }
 
 // TrackLoad removes all changes and resets the savepoint.
 func (l *Log) TrackLoad() {
-	l.entries = l.entries[:0]
-	l.numUndoEntries = 0
-	l.numEntriesAtLastSave = 0
+	root := &node{checkpoint: true, time: l.now()}
+	l.nodes = []*node{root}
+	l.current = root
+	l.savedNode = root
+	l.numOpsAtLastSave = 0
 }
 
-// TrackSave moves the savepoint to the current entry.
+// TrackSave moves the savepoint to the current state.
 func (l *Log) TrackSave() {
-	l.numEntriesAtLastSave = l.numUndoEntries
+	l.savedNode = l.current
+	l.numOpsAtLastSave = len(l.current.ops)
 }
 
-// Checkpoint marks the current entry as a checkpoint.
+// Checkpoint marks the current state as a checkpoint.
 func (l *Log) Checkpoint() {
-	if l.numUndoEntries == 0 {
-		return
-	}
-	l.entries[l.numUndoEntries-1].checkpoint = true
+	l.current.checkpoint = true
 }
 
 // UndoToLastCheckpoint returns operations to transform the document back to its state at the previous checkpoint.
 // It also moves the current position backwards in the log.
 func (l *Log) UndoToLastCheckpoint() []Op {
-	var ops []Op
-	for i := l.numUndoEntries - 1; i >= 0; i-- {
-		if i < l.numUndoEntries-1 && l.entries[i].checkpoint {
-			break
-		}
-		ops = append(ops, l.entries[i].op.Inverse())
+	n := l.current
+	if n.parent == nil {
+		return nil
 	}
-	l.numUndoEntries -= len(ops)
-	return ops
+	n.parent.redoChild = n
+	l.current = n.parent
+	return n.inverseOps()
 }
 
 // RedoToNextCheckpoint returns operations to to transform the document to its state at the next checkpoint.
 // It also moves the current position forward in the log.
 func (l *Log) RedoToNextCheckpoint() []Op {
-	var ops []Op
-	for i := l.numUndoEntries; i < len(l.entries); i++ {
-		ops = append(ops, l.entries[i].op)
-		if i > l.numUndoEntries && l.entries[i].checkpoint {
+	n := l.current.redoChild
+	if n == nil {
+		return nil
+	}
+	l.current = n
+	return n.ops
+}
+
+// MoveToOlderState returns operations to transform the document to the state created before the current state.
+// Unlike undo, this can move to a state on a different branch.
+func (l *Log) MoveToOlderState() []Op {
+	if l.current.seq == 0 {
+		return nil
+	}
+	return l.moveTo(l.nodes[l.current.seq-1])
+}
+
+// MoveToNewerState returns operations to transform the document to the state created after the current state.
+// Unlike redo, this can move to a state on a different branch.
+func (l *Log) MoveToNewerState() []Op {
+	if l.current.seq+1 >= len(l.nodes) {
+		return nil
+	}
+	return l.moveTo(l.nodes[l.current.seq+1])
+}
+
+// MoveEarlier returns operations to transform the document to its state the specified duration
+// before the last change in the current state.
+func (l *Log) MoveEarlier(d time.Duration) []Op {
+	return l.moveToTime(l.current.time.Add(-d))
+}
+
+// MoveLater returns operations to transform the document to its state the specified duration
+// after the last change in the current state.
+func (l *Log) MoveLater(d time.Duration) []Op {
+	return l.moveToTime(l.current.time.Add(d))
+}
+
+// moveToTime moves to the most recently created state with its last change at or before the specified time.
+// If every state is newer, it moves to the original state of the document.
+func (l *Log) moveToTime(t time.Time) []Op {
+	target := l.nodes[0]
+	for i := len(l.nodes) - 1; i > 0; i-- {
+		if !l.nodes[i].time.After(t) {
+			target = l.nodes[i]
 			break
 		}
 	}
-	l.numUndoEntries += len(ops)
+	return l.moveTo(target)
+}
+
+// MoveToState returns operations to transform the document to the state with the specified sequence number.
+// The sequence numbers are available from States.
+func (l *Log) MoveToState(seq int) []Op {
+	if seq < 0 || seq >= len(l.nodes) {
+		return nil
+	}
+	return l.moveTo(l.nodes[seq])
+}
+
+// moveTo returns operations to transform the document from the current state to the target state.
+// This undoes changes up to the closest common ancestor, then redoes changes down to the target.
+// Afterwards, redo from any state on the path will follow the path towards the target.
+func (l *Log) moveTo(target *node) []Op {
+	var ops []Op
+	var redoPath []*node
+	up, down := l.current, target
+	for up != down {
+		if up.depth >= down.depth {
+			ops = append(ops, up.inverseOps()...)
+			up.parent.redoChild = up
+			up = up.parent
+		} else {
+			redoPath = append(redoPath, down)
+			down = down.parent
+		}
+	}
+
+	for i := len(redoPath) - 1; i >= 0; i-- {
+		n := redoPath[i]
+		n.parent.redoChild = n
+		ops = append(ops, n.ops...)
+	}
+
+	l.current = target
 	return ops
 }
 
+// State describes a document state in the undo log.
+type State struct {
+	// Seq is the order in which the state was created.  The original document is zero.
+	Seq int
+
+	// Time is when the last change in the state was made.
+	Time time.Time
+
+	// Ops are the changes from the parent state to this state.
+	Ops []Op
+
+	// Current indicates that this is the current state of the document.
+	Current bool
+
+	// Saved indicates that the document was last saved in this state.
+	Saved bool
+}
+
+// CurrentSeq returns the sequence number of the current state.
+func (l *Log) CurrentSeq() int {
+	return l.current.seq
+}
+
+// Position returns the sequence number of the current state and the number of changes in it.
+// Together these identify the contents of the document since the log was last reset.
+func (l *Log) Position() (seq int, numOps int) {
+	return l.current.seq, len(l.current.ops)
+}
+
+// NumStates returns the number of states in the log, including the original document.
+func (l *Log) NumStates() int {
+	return len(l.nodes)
+}
+
+// States returns every state in the log, ordered by sequence number.
+func (l *Log) States() []State {
+	states := make([]State, 0, len(l.nodes))
+	for _, n := range l.nodes {
+		states = append(states, State{
+			Seq:     n.seq,
+			Time:    n.time,
+			Ops:     append([]Op(nil), n.ops...),
+			Current: n == l.current,
+			Saved:   n == l.savedNode && len(n.ops) == l.numOpsAtLastSave,
+		})
+	}
+	return states
+}
+
 // HasUnsavedChanges returns whether the log has unsaved changes.
 func (l *Log) HasUnsavedChanges() bool {
-	return l.numUndoEntries != l.numEntriesAtLastSave
+	return l.current != l.savedNode || len(l.current.ops) != l.numOpsAtLastSave
 }
@@ -2,6 +2,7 @@ package undo
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -128,3 +129,109 @@ func TestTrackLoad(t *testing.T) {
 	assert.Equal(t, 0, len(log.UndoToLastCheckpoint()))
 	assert.Equal(t, 0, len(log.RedoToNextCheckpoint()))
 }
+
+func TestUndoKeepsBranches(t *testing.T) {
+	log := NewLog()
+	log.TrackOp(InsertOp(0, "a"))
+	log.Checkpoint()
+	log.TrackOp(InsertOp(1, "b"))
+	log.Checkpoint()
+
+	// Undo, then make a different change, which starts a new branch.
+	log.UndoToLastCheckpoint()
+	log.TrackOp(InsertOp(1, "c"))
+	log.Checkpoint()
+
+	// Undo and redo follow the most recent branch.
+	assert.Equal(t, []Op{DeleteOp(1, "c")}, log.UndoToLastCheckpoint())
+	assert.Equal(t, []Op{InsertOp(1, "c")}, log.RedoToNextCheckpoint())
+
+	// The original branch is still available.
+	assert.Equal(t, []Op{DeleteOp(1, "c"), InsertOp(1, "b")}, log.MoveToState(2))
+	assert.Equal(t, 2, log.CurrentSeq())
+
+	// Redo now follows the original branch.
+	assert.Equal(t, []Op{DeleteOp(1, "b")}, log.UndoToLastCheckpoint())
+	assert.Equal(t, []Op{InsertOp(1, "b")}, log.RedoToNextCheckpoint())
+}
+
+func TestMoveToOlderAndNewerState(t *testing.T) {
+	log := NewLog()
+	log.TrackOp(InsertOp(0, "a"))
+	log.Checkpoint()
+	log.TrackOp(InsertOp(1, "b"))
+	log.Checkpoint()
+	log.UndoToLastCheckpoint()
+	log.TrackOp(InsertOp(1, "c"))
+	log.Checkpoint()
+
+	// States in order: 0 (original), 1 "a", 2 "ab", 3 "ac".
+	assert.Equal(t, 3, log.CurrentSeq())
+	assert.Equal(t, 0, len(log.MoveToNewerState()))
+
+	assert.Equal(t, []Op{DeleteOp(1, "c"), InsertOp(1, "b")}, log.MoveToOlderState())
+	assert.Equal(t, 2, log.CurrentSeq())
+
+	assert.Equal(t, []Op{DeleteOp(1, "b")}, log.MoveToOlderState())
+	assert.Equal(t, 1, log.CurrentSeq())
+
+	assert.Equal(t, []Op{DeleteOp(0, "a")}, log.MoveToOlderState())
+	assert.Equal(t, 0, log.CurrentSeq())
+	assert.Equal(t, 0, len(log.MoveToOlderState()))
+
+	assert.Equal(t, []Op{InsertOp(0, "a")}, log.MoveToNewerState())
+	assert.Equal(t, []Op{InsertOp(1, "b")}, log.MoveToNewerState())
+	assert.Equal(t, []Op{DeleteOp(1, "b"), InsertOp(1, "c")}, log.MoveToNewerState())
+	assert.Equal(t, 3, log.CurrentSeq())
+}
+
+func TestMoveEarlierAndLater(t *testing.T) {
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+	log := NewLog()
+	log.now = func() time.Time { return now }
+	log.TrackLoad()
+
+	// Make a change every minute.
+	for i, s := range []string{"a", "b", "c", "d"} {
+		now = now.Add(time.Minute)
+		log.TrackOp(InsertOp(uint64(i), s))
+		log.Checkpoint()
+	}
+	assert.Equal(t, 4, log.CurrentSeq())
+
+	assert.Equal(t, []Op{DeleteOp(3, "d"), DeleteOp(2, "c")}, log.MoveEarlier(2*time.Minute))
+	assert.Equal(t, 2, log.CurrentSeq())
+
+	assert.Equal(t, []Op{InsertOp(2, "c")}, log.MoveLater(90*time.Second))
+	assert.Equal(t, 3, log.CurrentSeq())
+
+	// Moving earlier than the first change returns to the original document.
+	log.MoveEarlier(time.Hour)
+	assert.Equal(t, 0, log.CurrentSeq())
+
+	log.MoveLater(time.Hour)
+	assert.Equal(t, 4, log.CurrentSeq())
+}
+
+func TestStates(t *testing.T) {
+	now := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+	log := NewLog()
+	log.now = func() time.Time { return now }
+	log.TrackLoad()
+
+	now = now.Add(time.Minute)
+	log.TrackOp(InsertOp(0, "a"))
+	log.TrackOp(DeleteOp(1, "b"))
+	log.Checkpoint()
+	log.TrackSave()
+	now = now.Add(time.Minute)
+	log.TrackOp(InsertOp(0, "c"))
+
+	expected := []State{
+		{Seq: 0, Time: time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC), Ops: nil},
+		{Seq: 1, Time: time.Date(2022, 1, 1, 12, 1, 0, 0, time.UTC), Ops: []Op{InsertOp(0, "a"), DeleteOp(1, "b")}, Saved: true},
+		{Seq: 2, Time: time.Date(2022, 1, 1, 12, 2, 0, 0, time.UTC), Ops: []Op{InsertOp(0, "c")}, Current: true},
+	}
+	assert.Equal(t, expected, log.States())
+	assert.Equal(t, 3, log.NumStates())
+}
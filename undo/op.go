@@ -47,6 +47,12 @@ func (op Op) TextToInsert() string {
 	return op.insertText
 }
 
+// TextToDelete returns the text deleted by the op.
+// This will be an empty string if TextToInsert is a non-empty string.
+func (op Op) TextToDelete() string {
+	return op.deleteText
+}
+
 // NumRunesToDelete returns the number of runes deleted at the position.
 // This will be zero if TextToInsert is a non-empty string.
 func (op Op) NumRunesToDelete() int {